@@ -0,0 +1,67 @@
+package main
+
+import (
+    "strconv"
+    "strings"
+)
+
+// renderBoard draws a position in domain notation (see
+// domain.FormatPosition) as ASCII art, with the column letters and row
+// numbers used for moves. Cells on a winning line are bracketed. Each layer
+// of a multi-layer board is drawn under its own heading.
+func renderBoard(position string, line []int) string {
+    board, _, _ := strings.Cut(position, " ")
+    onLine := map[int]bool{}
+    for _, idx := range line {
+        onLine[idx] = true
+    }
+    layers := strings.Split(board, "|")
+    var b strings.Builder
+    idx := 0
+    for l, layer := range layers {
+        rows := strings.Split(layer, "/")
+        if len(layers) > 1 {
+            b.WriteString("Layer " + strconv.Itoa(l+1) + "\n")
+        }
+        cols := len(rows[0])
+        b.WriteString("    ")
+        for c := 0; c < cols; c++ {
+            b.WriteString(" " + string(rune('a'+c)) + "  ")
+        }
+        b.WriteString("\n")
+        for r, row := range rows {
+            if r > 0 {
+                b.WriteString("    " + strings.Repeat("---+", cols-1) + "---\n")
+            }
+            b.WriteString(padLeft(strconv.Itoa(r+1), 3) + " ")
+            for c, cell := range row {
+                text := string(cell)
+                if cell == '.' {
+                    text = " "
+                }
+                if onLine[idx] {
+                    text = "[" + text + "]"
+                } else {
+                    text = " " + text + " "
+                }
+                if c > 0 {
+                    b.WriteString("|")
+                }
+                b.WriteString(text)
+                idx++
+            }
+            b.WriteString("\n")
+        }
+        if l < len(layers)-1 {
+            b.WriteString("\n")
+        }
+    }
+    return b.String()
+}
+
+func padLeft(s string, n int) string {
+    if len(s) >= n {
+        return s
+    }
+    return strings.Repeat(" ", n-len(s)) + s
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+    "bufio"
+    "bytes"
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "net/http"
+    "net/http/cookiejar"
+    "net/url"
+    "strings"
+)
+
+// game is a game as the server's JSON API describes it.
+type game struct {
+    ID       string   `json:"id"`
+    Variant  string   `json:"variant"`
+    Position string   `json:"position"`
+    Moves    []string `json:"moves"`
+    Turn     string   `json:"turn"`
+    Status   string   `json:"status"`
+    Result   string   `json:"result"`
+    Winner   string   `json:"winner"`
+    Line     []int    `json:"line"`
+    // Seats holds the two seats by role: "x" and "o", or e.g. "order" and
+    // "chaos"; Turn, Winner and our seat name them the same way.
+    Seats map[string]seat `json:"seats"`
+}
+
+type seat struct {
+    Name  string `json:"name"`
+    Taken bool   `json:"taken"`
+}
+
+// seated is the answer to create and join: the role of our seat ("" when
+// watching) and the game.
+type seated struct {
+    Seat string `json:"seat"`
+    Game game   `json:"game"`
+}
+
+// client talks to a ttt-server's JSON API as one player, whose ID travels in
+// the player_id cookie like a browser's.
+type client struct {
+    base *url.URL
+    http *http.Client
+}
+
+// newClient returns a client for the server at base. An empty player lets
+// the server assign an ID.
+func newClient(base, player string) (*client, error) {
+    u, err := url.Parse(strings.TrimSuffix(base, "/"))
+    if err != nil || u.Scheme == "" || u.Host == "" {
+        return nil, fmt.Errorf("invalid server URL %q", base)
+    }
+    jar, _ := cookiejar.New(nil)
+    if player != "" {
+        jar.SetCookies(u, []*http.Cookie{{Name: "player_id", Value: player, Path: "/"}})
+    }
+    return &client{base: u, http: &http.Client{Jar: jar}}, nil
+}
+
+// player returns our player ID once the server has assigned or accepted it.
+func (c *client) player() string {
+    for _, ck := range c.http.Jar.Cookies(c.base) {
+        if ck.Name == "player_id" {
+            return ck.Value
+        }
+    }
+    return ""
+}
+
+// call sends in as JSON and decodes the answer into out. Error answers
+// become errors carrying the server's message.
+func (c *client) call(ctx context.Context, method, path string, in, out any) error {
+    var body bytes.Buffer
+    if in != nil {
+        if err := json.NewEncoder(&body).Encode(in); err != nil {
+            return err
+        }
+    }
+    req, err := http.NewRequestWithContext(ctx, method, c.base.String()+path, &body)
+    if err != nil {
+        return err
+    }
+    req.Header.Set("Content-Type", "application/json")
+    resp, err := c.http.Do(req)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode >= 300 {
+        var e struct {
+            Error string `json:"error"`
+        }
+        if json.NewDecoder(resp.Body).Decode(&e) == nil && e.Error != "" {
+            return errors.New(e.Error)
+        }
+        return fmt.Errorf("server answered %s", resp.Status)
+    }
+    return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// create starts a game and takes a seat in it.
+func (c *client) create(ctx context.Context, variant, side, first string) (seated, error) {
+    var s seated
+    err := c.call(ctx, "POST", "/api/games", map[string]string{"variant": variant, "side": side, "first": first}, &s)
+    return s, err
+}
+
+// join takes a free seat in game id, or watches when there is none.
+func (c *client) join(ctx context.Context, id string) (seated, error) {
+    var s seated
+    err := c.call(ctx, "POST", "/api/games/"+url.PathEscape(id)+"/join", nil, &s)
+    return s, err
+}
+
+// move plays a move in record notation, e.g. "b2".
+func (c *client) move(ctx context.Context, id, move string) (game, error) {
+    var g game
+    err := c.call(ctx, "POST", "/api/games/"+url.PathEscape(id)+"/moves", map[string]string{"move": move}, &g)
+    return g, err
+}
+
+// events streams the states of game id until ctx is done or the connection
+// ends, at which point the channel is closed.
+func (c *client) events(ctx context.Context, id string) (<-chan game, error) {
+    req, err := http.NewRequestWithContext(ctx, "GET", c.base.String()+"/api/games/"+url.PathEscape(id)+"/events", nil)
+    if err != nil {
+        return nil, err
+    }
+    req.Header.Set("Accept", "text/event-stream")
+    resp, err := c.http.Do(req)
+    if err != nil {
+        return nil, err
+    }
+    if resp.StatusCode != http.StatusOK {
+        resp.Body.Close()
+        return nil, fmt.Errorf("event stream: server answered %s", resp.Status)
+    }
+    out := make(chan game)
+    go func() {
+        defer close(out)
+        defer resp.Body.Close()
+        sc := bufio.NewScanner(resp.Body)
+        var name string
+        var data []string
+        for sc.Scan() {
+            line := sc.Text()
+            switch {
+            case line == "":
+                var g game
+                if name == "state" && json.Unmarshal([]byte(strings.Join(data, "\n")), &g) == nil {
+                    select {
+                    case out <- g:
+                    case <-ctx.Done():
+                        return
+                    }
+                }
+                name, data = "", nil
+            case strings.HasPrefix(line, "event:"):
+                name = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+            case strings.HasPrefix(line, "data:"):
+                data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+            }
+        }
+    }()
+    return out, nil
+}
@@ -0,0 +1,134 @@
+// Command ttt-cli plays tic-tac-toe in the terminal against players on a
+// ttt-server, through its JSON API, or offline against the built-in bot or
+// a second player at the same keyboard.
+//
+//	ttt-cli                       create a game and wait for an opponent
+//	ttt-cli -game ID              join game ID
+//	ttt-cli -variant wild -side o create a Wild game and play O
+//	ttt-cli -offline -level easy  play the bot offline
+//	ttt-cli -offline -opponent human
+//	                              two players take turns at one keyboard
+package main
+
+import (
+    "context"
+    "flag"
+    "fmt"
+    "math/rand"
+    "os"
+    "os/signal"
+    "strings"
+    "syscall"
+    "time"
+
+    "github.com/jaminalder/codex-tic-tac-toe/internal/bot"
+    "github.com/jaminalder/codex-tic-tac-toe/internal/domain"
+)
+
+func main() {
+    server := flag.String("server", envOr("TTT_SERVER", "http://localhost:8080"), "server URL")
+    gameID := flag.String("game", "", "join this game instead of creating one")
+    player := flag.String("player", os.Getenv("TTT_PLAYER"), "player ID to play as, e.g. to rejoin a game (the server assigns one when empty)")
+    variant := flag.String("variant", "standard", "variant of a new game")
+    side := flag.String("side", "", "seat to take in a new game: x, o or random (first free when empty; x offline)")
+    first := flag.String("first", "x", "who moves first in a new game: x, o or random")
+    offline := flag.Bool("offline", false, "play on this terminal without a server")
+    opponent := flag.String("opponent", "bot", "offline opponent: bot or human")
+    level := flag.String("level", "hard", "offline bot difficulty: easy, medium or hard")
+    flag.Parse()
+
+    if *offline {
+        if err := runOffline(*variant, *side, *first, *opponent, *level); err != nil {
+            fail(err)
+        }
+        return
+    }
+
+    ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+    defer stop()
+    c, err := newClient(*server, *player)
+    if err != nil {
+        fail(err)
+    }
+    var s seated
+    if *gameID == "" {
+        s, err = c.create(ctx, *variant, *side, *first)
+    } else {
+        s, err = c.join(ctx, *gameID)
+    }
+    if err != nil {
+        fail(err)
+    }
+    fmt.Printf("Game %s (%s). Others join with: ttt-cli -game %s, or in a browser at %s/game/%s\n", s.Game.ID, s.Game.Variant, s.Game.ID, c.base, s.Game.ID)
+    if s.Seat == "" {
+        fmt.Println("Both seats are taken; watching.")
+    } else {
+        fmt.Printf("Playing as player %s; pass -player %s to rejoin.\n", c.player(), c.player())
+    }
+    if err := playOnline(ctx, c, s, os.Stdin, os.Stdout); err != nil && ctx.Err() == nil {
+        fail(err)
+    }
+}
+
+// runOffline sets up an offline game from the command-line flags and plays
+// it on stdin and stdout.
+func runOffline(variant, side, first, opponent, level string) error {
+    v, err := domain.ParseVariant(variant)
+    if err != nil {
+        return err
+    }
+    if v == domain.Quantum {
+        return fmt.Errorf("the %s variant cannot be played offline", v.Title())
+    }
+    rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+    pick := func(s string) (domain.Cell, error) {
+        switch strings.ToLower(s) {
+        case "", "x":
+            return domain.X, nil
+        case "o":
+            return domain.O, nil
+        case "random":
+            if rng.Intn(2) == 0 {
+                return domain.X, nil
+            }
+            return domain.O, nil
+        }
+        return domain.Empty, fmt.Errorf("invalid side %q (want x, o or random)", s)
+    }
+    g := domain.NewVariant(v)
+    if g.Turn, err = pick(first); err != nil {
+        return err
+    }
+    bots := map[domain.Cell]bot.Mover{}
+    switch strings.ToLower(opponent) {
+    case "human":
+    case "bot":
+        human, err := pick(side)
+        if err != nil {
+            return err
+        }
+        b, err := newLevelBot(level, v, rng)
+        if err != nil {
+            return err
+        }
+        bots[domain.Opponent(human)] = b
+        fmt.Printf("%s game against the %s bot; you play %s.\n", v.Title(), strings.ToLower(level), v.SideName(human))
+    default:
+        return fmt.Errorf("unknown opponent %q (want bot or human)", opponent)
+    }
+    _, err = playOffline(g, bots, os.Stdin, os.Stdout)
+    return err
+}
+
+// envOr returns the environment variable key, or def when unset.
+func envOr(key, def string) string {
+    if v := os.Getenv(key); v != "" {
+        return v
+    }
+    return def
+}
+
+func fail(err error) {
+    fmt.Fprintln(os.Stderr, "ttt-cli:", err)
+    os.Exit(1)
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+    "errors"
+    "fmt"
+    "io"
+    "math/rand"
+    "strings"
+
+    "github.com/jaminalder/codex-tic-tac-toe/internal/bot"
+    "github.com/jaminalder/codex-tic-tac-toe/internal/domain"
+    "github.com/jaminalder/codex-tic-tac-toe/internal/record"
+)
+
+const offlineHelp = `Type a move as column letter and row number, e.g. b2; on the Qubic board
+add the layer, e.g. b2:3. Where you pick the mark or number, put it first,
+e.g. Ob2 or 5b2. Other commands: undo, board, help, quit.`
+
+// levelBot plays the built-in bot at a difficulty: with probability random
+// it makes a random legal move, otherwise the best move it finds.
+type levelBot struct {
+    best   bot.Mover
+    random float64
+    rng    *rand.Rand
+}
+
+func (b levelBot) BestMove(g domain.Game) (domain.Move, error) {
+    moves := g.LegalMoves()
+    if len(moves) == 0 {
+        return domain.Move{}, bot.ErrGameOver
+    }
+    if b.rng.Float64() < b.random {
+        return moves[b.rng.Intn(len(moves))], nil
+    }
+    return b.best.BestMove(g)
+}
+
+// newLevelBot returns the bot for level (easy, medium or hard) in games of
+// v. On the 3x3 board it builds on the perfect solver, so hard never loses;
+// larger boards use a depth-limited search. Variants bot.For has no bot for,
+// such as Order and Chaos, are refused.
+func newLevelBot(level string, v domain.Variant, rng *rand.Rand) (bot.Mover, error) {
+    if _, err := bot.For(v); err != nil {
+        return nil, fmt.Errorf("the bot cannot play %s; play -opponent human instead", v.Title())
+    }
+    small := v.Geometry().Cells() == 9
+    switch strings.ToLower(level) {
+    case "easy":
+        if small {
+            return levelBot{best: bot.NewSolver(), random: 0.7, rng: rng}, nil
+        }
+        return levelBot{best: bot.AlphaBeta{Depth: 1}, random: 0.3, rng: rng}, nil
+    case "medium":
+        if small {
+            return levelBot{best: bot.NewSolver(), random: 0.3, rng: rng}, nil
+        }
+        return bot.AlphaBeta{Depth: 2}, nil
+    case "hard", "":
+        if small {
+            return bot.NewSolver(), nil
+        }
+        return bot.AlphaBeta{Depth: bot.DefaultDepth}, nil
+    }
+    return nil, fmt.Errorf("unknown level %q (want easy, medium or hard)", level)
+}
+
+// takeBack returns g with its last n moves undone, replayed from its start.
+func takeBack(g domain.Game, n int) domain.Game {
+    if n > len(g.History) {
+        n = len(g.History)
+    }
+    out := g.Start()
+    for _, m := range g.History[:len(g.History)-n] {
+        out.PlayAt(m.Index, m.Mark)
+    }
+    return out
+}
+
+// playOffline plays g on this terminal. Sides with a Mover in bots are
+// played by the computer, the others by whoever types on in. Undo takes
+// back moves until it is a human's turn again. Once the game is over or the
+// players quit it prints the game record and returns the game.
+func playOffline(g domain.Game, bots map[domain.Cell]bot.Mover, in io.Reader, out io.Writer) (domain.Game, error) {
+    lines := readLines(in)
+    name := func(side domain.Cell) string { return g.Variant.SideName(side) }
+    show := func() {
+        fmt.Fprintln(out)
+        fmt.Fprint(out, renderBoard(domain.FormatPosition(g), g.Line))
+        switch {
+        case g.Over && g.Winner != domain.Empty:
+            fmt.Fprintln(out, name(g.Winner)+" wins.")
+        case g.Over:
+            fmt.Fprintln(out, "Draw.")
+        case bots[g.Turn] != nil:
+            fmt.Fprintln(out, name(g.Turn)+" (bot) is thinking.")
+        default:
+            fmt.Fprintln(out, name(g.Turn)+" to move.")
+        }
+    }
+    fmt.Fprintln(out, offlineHelp)
+    show()
+    for !g.Over {
+        if b := bots[g.Turn]; b != nil {
+            m, err := b.BestMove(g)
+            if err != nil {
+                return g, err
+            }
+            if err := g.PlayAt(m.Index, m.Mark); err != nil {
+                return g, err
+            }
+            if !g.Variant.ChoosesMark() {
+                m.Mark = domain.Empty
+            }
+            fmt.Fprintf(out, "%s plays %s.\n", name(m.Side), record.MoveText(m, g.Geometry()))
+            show()
+            continue
+        }
+        line, ok := <-lines
+        if !ok {
+            break
+        }
+        switch strings.ToLower(line) {
+        case "":
+            continue
+        case "q", "quit", "exit":
+        case "?", "help":
+            fmt.Fprintln(out, offlineHelp)
+            continue
+        case "board":
+            show()
+            continue
+        case "u", "undo":
+            n := 1
+            for n < len(g.History) && bots[g.History[len(g.History)-n].Side] != nil {
+                n++
+            }
+            if len(g.History) == 0 || bots[g.History[len(g.History)-n].Side] != nil {
+                fmt.Fprintln(out, "Nothing to undo.")
+                continue
+            }
+            g = takeBack(g, n)
+            show()
+            continue
+        default:
+            m, err := record.ParseMove(line, g.Geometry())
+            if err != nil {
+                fmt.Fprintf(out, "invalid move %q\n", line)
+                continue
+            }
+            if m.Mark == domain.Empty {
+                m.Mark = g.Turn
+            }
+            if err := g.PlayAt(m.Index, m.Mark); err != nil {
+                if errors.Is(err, domain.ErrInvalidMark) && g.Variant.ChoosesMark() {
+                    err = fmt.Errorf("%w; name it before the square, e.g. %s", err, offlineExample(g))
+                }
+                fmt.Fprintln(out, err)
+                continue
+            }
+            show()
+            continue
+        }
+        break
+    }
+    fmt.Fprintln(out)
+    fmt.Fprint(out, record.FromGame(g).String())
+    return g, nil
+}
+
+// offlineExample returns a legal move of g in notation, to show how marks
+// are named.
+func offlineExample(g domain.Game) string {
+    moves := g.LegalMoves()
+    if len(moves) == 0 {
+        return ""
+    }
+    return record.MoveText(moves[0], g.Geometry())
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+    "math/rand"
+    "strings"
+    "testing"
+
+    "github.com/jaminalder/codex-tic-tac-toe/internal/bot"
+    "github.com/jaminalder/codex-tic-tac-toe/internal/domain"
+)
+
+func TestPlayOfflineHotSeat(t *testing.T) {
+    // O takes back a2 and plays c1 instead; X then completes a1-b2-c3.
+    in := strings.NewReader("b2\na2\nundo\nc1\nzz\nc1\na1\na3\nc3\n")
+    var out strings.Builder
+    g, err := playOffline(domain.New(), nil, in, &out)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if g.Outcome != domain.XWon {
+        t.Fatalf("outcome %v; output:\n%s", g.Outcome, out.String())
+    }
+    text := out.String()
+    for _, want := range []string{"O to move.", "invalid move \"zz\"", "cell occupied", "X wins.", "1. b2 c1 2. a1 a3 3. c3 1-0\n"} {
+        if !strings.Contains(text, want) {
+            t.Fatalf("output lacks %q:\n%s", want, text)
+        }
+    }
+}
+
+func TestPlayOfflineAgainstBot(t *testing.T) {
+    rng := rand.New(rand.NewSource(1))
+    hard, err := newLevelBot("hard", domain.Standard, rng)
+    if err != nil {
+        t.Fatal(err)
+    }
+    // Undo takes back the bot's reply along with our move.
+    var out strings.Builder
+    g, err := playOffline(domain.New(), map[domain.Cell]bot.Mover{domain.O: hard}, strings.NewReader("b2\nundo\nquit\n"), &out)
+    if err != nil || len(g.History) != 0 || g.Turn != domain.X {
+        t.Fatalf("after undo: %+v, err=%v; output:\n%s", g, err, out.String())
+    }
+
+    // Trying every square in order never beats the perfect bot.
+    out.Reset()
+    g, err = playOffline(domain.New(), map[domain.Cell]bot.Mover{domain.O: hard}, strings.NewReader("a1\nb1\nc1\na2\nb2\nc2\na3\nb3\nc3\n"), &out)
+    if err != nil || !g.Over || g.Outcome == domain.XWon {
+        t.Fatalf("game against hard bot: %+v, err=%v; output:\n%s", g, err, out.String())
+    }
+    if !strings.Contains(out.String(), "O (bot) is thinking.") {
+        t.Fatalf("output lacks the bot's turn:\n%s", out.String())
+    }
+
+    // Larger boards and the easy level play legal moves to the end.
+    easy, err := newLevelBot("easy", domain.Qubic, rng)
+    if err != nil {
+        t.Fatal(err)
+    }
+    g, err = playOffline(domain.NewVariant(domain.Qubic), map[domain.Cell]bot.Mover{domain.X: easy, domain.O: easy}, strings.NewReader(""), &out)
+    if err != nil || !g.Over {
+        t.Fatalf("bot against bot on Qubic: %+v, err=%v", g.Outcome, err)
+    }
+    if _, err := newLevelBot("hard", domain.OrderChaos, rng); err == nil {
+        t.Fatalf("expected Order and Chaos to be refused")
+    }
+    if _, err := newLevelBot("expert", domain.Standard, rng); err == nil {
+        t.Fatal("expected an error for an unknown level")
+    }
+}
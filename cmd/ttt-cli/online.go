@@ -0,0 +1,136 @@
+package main
+
+import (
+    "bufio"
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "strings"
+
+)
+
+const onlineHelp = `Type a move as column letter and row number, e.g. b2; on the Qubic board
+add the layer, e.g. b2:3. Where you pick the mark or number, put it first,
+e.g. Ob2 or 5b2. Other commands: board, help, quit.`
+
+// readLines delivers the lines of in until it ends, then closes the channel.
+func readLines(in io.Reader) <-chan string {
+    out := make(chan string)
+    go func() {
+        defer close(out)
+        sc := bufio.NewScanner(in)
+        for sc.Scan() {
+            out <- strings.TrimSpace(sc.Text())
+        }
+    }()
+    return out
+}
+
+// playOnline plays the game we are seated in: it shows every new state the
+// server streams and sends the moves typed on in. It returns nil once the
+// game is over or the player quits.
+func playOnline(ctx context.Context, c *client, s seated, in io.Reader, out io.Writer) error {
+    ctx, cancel := context.WithCancel(ctx)
+    defer cancel()
+    states, err := c.events(ctx, s.Game.ID)
+    if err != nil {
+        return err
+    }
+    lines := readLines(in)
+    fmt.Fprintln(out, onlineHelp)
+    current := s.Game
+    shown := ""
+    show := func(g game) bool {
+        // States from the stream may trail the answer to our own move.
+        if len(g.Moves) < len(current.Moves) {
+            return false
+        }
+        current = g
+        // The stream repeats states for presence changes; show each once.
+        key := fmt.Sprint(len(g.Moves), g.Status, g.Seats)
+        if key == shown {
+            return false
+        }
+        shown = key
+        fmt.Fprintln(out)
+        fmt.Fprint(out, renderBoard(g.Position, g.Line))
+        fmt.Fprintln(out, statusLine(g, s.Seat))
+        return true
+    }
+    show(current)
+    for {
+        select {
+        case <-ctx.Done():
+            return ctx.Err()
+        case g, ok := <-states:
+            if !ok {
+                return errors.New("lost the connection to the server")
+            }
+            if show(g) && g.Status != "started" {
+                return nil
+            }
+        case line, ok := <-lines:
+            if !ok {
+                return nil
+            }
+            switch strings.ToLower(line) {
+            case "":
+            case "q", "quit", "exit":
+                return nil
+            case "?", "help":
+                fmt.Fprintln(out, onlineHelp)
+            case "board":
+                shown = ""
+                show(current)
+            default:
+                if s.Seat == "" {
+                    fmt.Fprintln(out, "You are watching this game.")
+                    continue
+                }
+                g, err := c.move(ctx, s.Game.ID, line)
+                if err != nil {
+                    fmt.Fprintln(out, err)
+                    continue
+                }
+                if show(g) && g.Status != "started" {
+                    return nil
+                }
+            }
+        }
+    }
+}
+
+// statusLine says whose turn it is, or how the game ended, from the point of
+// view of the player in seat ("" when watching).
+func statusLine(g game, seat string) string {
+    name := func(role string) string { return g.Seats[role].Name }
+    switch g.Status {
+    case "aborted":
+        return "The game was ended by an administrator."
+    case "over":
+        if g.Winner != "" {
+            return name(g.Winner) + " wins."
+        }
+        return "Draw."
+    }
+    opponent, full := "", true
+    for role, st := range g.Seats {
+        if role != seat {
+            opponent = role
+        }
+        full = full && st.Taken
+    }
+    switch {
+    case seat == "":
+        return name(g.Turn) + " to move."
+    case g.Turn == seat && !full:
+        return "Your move as " + name(seat) + " (waiting for an opponent to join)."
+    case g.Turn == seat:
+        return "Your move as " + name(seat) + "."
+    case !full:
+        return "Waiting for an opponent to join as " + name(opponent) + "."
+    default:
+        return "Waiting for " + name(opponent) + " to move."
+    }
+}
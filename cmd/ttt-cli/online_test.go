@@ -0,0 +1,125 @@
+package main
+
+import (
+    "bytes"
+    "context"
+    "io"
+    "net/http/httptest"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/jaminalder/codex-tic-tac-toe/internal/app"
+    "github.com/jaminalder/codex-tic-tac-toe/internal/web"
+)
+
+// syncBuffer is a bytes.Buffer safe for a writer and a concurrent reader.
+type syncBuffer struct {
+    mu sync.Mutex
+    b  bytes.Buffer
+}
+
+func (s *syncBuffer) Write(p []byte) (int, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return s.b.Write(p)
+}
+
+func (s *syncBuffer) String() string {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return s.b.String()
+}
+
+func TestRenderBoard(t *testing.T) {
+    got := renderBoard("X.O/.X./..X -", []int{0, 4, 8})
+    want := "" +
+        "     a   b   c  \n" +
+        "  1 [X]|   | O \n" +
+        "    ---+---+---\n" +
+        "  2    |[X]|   \n" +
+        "    ---+---+---\n" +
+        "  3    |   |[X]\n"
+    if got != want {
+        t.Fatalf("unexpected board:\n%s\nwant:\n%s", got, want)
+    }
+    qubic := renderBoard("..../..../..../....|..../..../..../....|..../..../..../....|..../..../..../X... o", nil)
+    if !strings.Contains(qubic, "Layer 4\n") || strings.Count(qubic, " X ") != 1 {
+        t.Fatalf("unexpected Qubic board:\n%s", qubic)
+    }
+}
+
+func TestPlayOnlineAgainstServer(t *testing.T) {
+    svc := app.NewService()
+    srv := httptest.NewServer(web.NewServer(svc))
+    defer srv.Close()
+    c, err := newClient(srv.URL, "")
+    if err != nil {
+        t.Fatal(err)
+    }
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    s, err := c.create(ctx, "standard", "x", "x")
+    if err != nil || s.Seat != "x" {
+        t.Fatalf("create: %+v, err=%v", s, err)
+    }
+    id := s.Game.ID
+    svc.Join(context.Background(), id, "opponent")
+
+    in, typed := io.Pipe()
+    var out syncBuffer
+    done := make(chan error, 1)
+    go func() { done <- playOnline(ctx, c, s, in, &out) }()
+    waitMoves := func(n int) {
+        t.Helper()
+        for {
+            if gs, _ := svc.Get(id); gs.Game.Moves >= n {
+                return
+            }
+            select {
+            case <-ctx.Done():
+                t.Fatalf("timed out waiting for move %d; output:\n%s", n, out.String())
+            case <-time.After(5 * time.Millisecond):
+            }
+        }
+    }
+
+    // waitPrompt waits until we have been asked for our move n times since
+    // the opponent joined, as a player would before typing.
+    waitPrompt := func(n int) {
+        t.Helper()
+        for strings.Count(out.String(), "Your move as X.") < n {
+            select {
+            case <-ctx.Done():
+                t.Fatalf("timed out waiting for prompt %d; output:\n%s", n, out.String())
+            case <-time.After(5 * time.Millisecond):
+            }
+        }
+    }
+
+    // X takes the anti-diagonal c1, b2, a3 while O plays the top row.
+    io.WriteString(typed, "zz\nb2\n")
+    waitMoves(1)
+    svc.Play(context.Background(), id, "opponent", 0, 0)
+    waitPrompt(1)
+    io.WriteString(typed, "c1\n")
+    waitMoves(3)
+    svc.Play(context.Background(), id, "opponent", 0, 1)
+    waitPrompt(2)
+    io.WriteString(typed, "a3\n")
+    select {
+    case err := <-done:
+        if err != nil {
+            t.Fatalf("playOnline: %v", err)
+        }
+    case <-ctx.Done():
+        t.Fatalf("game did not end; output:\n%s", out.String())
+    }
+    text := out.String()
+    for _, want := range []string{"Your move as X.", "invalid move \"zz\"", "Waiting for O to move.", "X wins."} {
+        if !strings.Contains(text, want) {
+            t.Fatalf("output lacks %q:\n%s", want, text)
+        }
+    }
+}
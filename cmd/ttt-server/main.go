@@ -0,0 +1,150 @@
+// Command ttt-server serves the tic-tac-toe web app.
+package main
+
+import (
+    "context"
+    "errors"
+    "flag"
+    "fmt"
+    "log/slog"
+    "net/http"
+    "os"
+    "os/signal"
+    "strconv"
+    "strings"
+    "syscall"
+    "time"
+
+    "github.com/jaminalder/codex-tic-tac-toe/internal/app"
+    "github.com/jaminalder/codex-tic-tac-toe/internal/logging"
+    "github.com/jaminalder/codex-tic-tac-toe/internal/web"
+)
+
+func main() {
+    addr := flag.String("addr", envOr("TTT_ADDR", ":8080"), "listen address")
+    logFormat := flag.String("log-format", envOr("TTT_LOG_FORMAT", "text"), "log format: text or json")
+    logLevel := flag.String("log-level", envOr("TTT_LOG_LEVEL", "info"), "log level: debug, info, warn or error")
+    templateDir := flag.String("templates-dir", "", "development: load templates from this directory and reload them on change")
+    adminToken := flag.String("admin-token", os.Getenv("TTT_ADMIN_TOKEN"), "token for /admin (disabled when empty)")
+    bots := botTokensFlag{}
+    if v := os.Getenv("TTT_BOT_TOKENS"); v != "" {
+        if err := bots.Set(v); err != nil {
+            fmt.Fprintln(os.Stderr, "TTT_BOT_TOKENS:", err)
+            os.Exit(2)
+        }
+    }
+    flag.Var(bots, "bot-tokens", "bot accounts for /api/bot as name:token pairs, comma-separated")
+    limits := web.DefaultRateLimits()
+    flag.Var((*limitFlag)(&limits.Create), "limit-create", "game creation limit per client as rate/s:burst (0:0 disables)")
+    flag.Var((*limitFlag)(&limits.Play), "limit-play", "move limit per client as rate/s:burst")
+    flag.Var((*limitFlag)(&limits.Events), "limit-events", "event stream connect limit per client as rate/s:burst")
+    flag.IntVar(&limits.MaxStreams, "max-streams", limits.MaxStreams, "concurrent event streams per client IP (0 = unlimited)")
+    flag.BoolVar(&limits.TrustProxy, "trust-proxy", false, "take client IPs from X-Forwarded-For / X-Real-IP")
+    flag.Parse()
+
+    log, err := logging.New(os.Stderr, *logFormat, *logLevel)
+    if err != nil {
+        fmt.Fprintln(os.Stderr, err)
+        os.Exit(2)
+    }
+    slog.SetDefault(log)
+
+    svc := app.NewService()
+    svc.SetLogger(log)
+    handler, err := web.NewServerWithOptions(svc, web.Options{
+        Logger:      log,
+        AdminToken:  *adminToken,
+        RateLimits:  &limits,
+        TemplateDir: *templateDir,
+        BotTokens:   bots,
+    })
+    if err != nil {
+        log.Error("invalid configuration", "err", err)
+        os.Exit(1)
+    }
+    srv := &http.Server{
+        Addr:              *addr,
+        Handler:           handler,
+        ReadHeaderTimeout: 10 * time.Second,
+    }
+
+    ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+    defer stop()
+    errc := make(chan error, 1)
+    go func() {
+        log.Info("listening", "addr", *addr)
+        errc <- srv.ListenAndServe()
+    }()
+
+    select {
+    case err := <-errc:
+        if !errors.Is(err, http.ErrServerClosed) {
+            log.Error("server failed", "err", err)
+            os.Exit(1)
+        }
+    case <-ctx.Done():
+        log.Info("shutting down")
+        // Fail readiness and end event streams before draining connections.
+        svc.Close()
+        shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+        defer cancel()
+        if err := srv.Shutdown(shutdownCtx); err != nil {
+            log.Error("shutdown failed", "err", err)
+        }
+    }
+}
+
+// envOr returns the environment variable key, or def when unset.
+func envOr(key, def string) string {
+    if v := os.Getenv(key); v != "" {
+        return v
+    }
+    return def
+}
+
+// limitFlag parses a web.Limit written as "rate:burst".
+type limitFlag web.Limit
+
+func (f *limitFlag) String() string {
+    return strconv.FormatFloat(f.Rate, 'g', -1, 64) + ":" + strconv.Itoa(f.Burst)
+}
+
+func (f *limitFlag) Set(v string) error {
+    rateStr, burstStr, ok := strings.Cut(v, ":")
+    if !ok {
+        return fmt.Errorf("want rate:burst, got %q", v)
+    }
+    rate, err := strconv.ParseFloat(rateStr, 64)
+    if err != nil {
+        return fmt.Errorf("invalid rate %q", rateStr)
+    }
+    burst, err := strconv.Atoi(burstStr)
+    if err != nil {
+        return fmt.Errorf("invalid burst %q", burstStr)
+    }
+    *f = limitFlag{Rate: rate, Burst: burst}
+    return nil
+}
+
+// botTokensFlag collects bot accounts written as "name:token,name:token",
+// keyed by token.
+type botTokensFlag map[string]string
+
+func (f botTokensFlag) String() string {
+    var pairs []string
+    for _, name := range f {
+        pairs = append(pairs, name+":***")
+    }
+    return strings.Join(pairs, ",")
+}
+
+func (f botTokensFlag) Set(v string) error {
+    for _, pair := range strings.Split(v, ",") {
+        name, token, ok := strings.Cut(strings.TrimSpace(pair), ":")
+        if !ok || name == "" || token == "" {
+            return fmt.Errorf("want name:token, got %q", pair)
+        }
+        f[token] = name
+    }
+    return nil
+}
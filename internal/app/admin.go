@@ -0,0 +1,139 @@
+package app
+
+import (
+    "context"
+    "errors"
+    "sort"
+    "time"
+
+    "github.com/jaminalder/codex-tic-tac-toe/internal/domain"
+)
+
+// ErrClosed is returned by Ping once the service is shutting down.
+var ErrClosed = errors.New("service closed")
+
+// GameSummary is an administrative view of one game.
+type GameSummary struct {
+    ID string
+    // X and O are the player IDs in the seats moving as X and O.
+    X           string
+    O           string
+    Moves       int
+    Over        bool
+    Outcome     domain.Outcome
+    Aborted     bool
+    Subscribers int
+    Created     time.Time
+    Updated     time.Time
+}
+
+// Ping reports whether the service can serve games.
+func (s *Service) Ping() error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if s.closed {
+        return ErrClosed
+    }
+    return nil
+}
+
+// Close marks the service as shutting down and ends every event and
+// challenge stream so that HTTP shutdown is not held up by long-lived
+// connections.
+func (s *Service) Close() {
+    s.mu.Lock()
+    s.closed = true
+    var all []*subscriber
+    for _, set := range s.subs {
+        for sub := range set {
+            all = append(all, sub)
+        }
+    }
+    var challengeSubs []*challengeSubscriber
+    for _, set := range s.challengeSubs {
+        for sub := range set {
+            challengeSubs = append(challengeSubs, sub)
+        }
+    }
+    s.mu.Unlock()
+    for _, sub := range all {
+        sub.close()
+    }
+    for _, sub := range challengeSubs {
+        sub.close()
+    }
+}
+
+// List returns summaries of all games, most recently updated first.
+func (s *Service) List() []GameSummary {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    out := make([]GameSummary, 0, len(s.games))
+    for id, gs := range s.games {
+        out = append(out, GameSummary{
+            ID:          id,
+            X:           gs.PlayerOf(domain.X),
+            O:           gs.PlayerOf(domain.O),
+            Moves:       gs.Game.Moves,
+            Over:        gs.Game.Over,
+            Outcome:     gs.Game.Outcome,
+            Aborted:     gs.Aborted,
+            Subscribers: len(s.subs[id]),
+            Created:     gs.Created,
+            Updated:     gs.Updated,
+        })
+    }
+    sort.Slice(out, func(i, j int) bool {
+        if out[i].Updated.Equal(out[j].Updated) {
+            return out[i].ID < out[j].ID
+        }
+        return out[i].Updated.After(out[j].Updated)
+    })
+    return out
+}
+
+// EndGame force-ends a game in progress without a winner and broadcasts the board.
+func (s *Service) EndGame(ctx context.Context, id string) (*GameState, error) {
+    s.mu.Lock()
+    gs, ok := s.games[id]
+    if !ok {
+        s.mu.Unlock()
+        return nil, ErrNotFound
+    }
+    if err := gs.Game.End(); err != nil {
+        s.mu.Unlock()
+        return nil, err
+    }
+    gs.Aborted = true
+    gs.Updated = time.Now()
+    s.metrics.gamesFinished.WithLabelValues(outcomeLabel(gs.Game.Outcome)).Inc()
+    s.loggerLocked(ctx).Info("game force-ended", "game_id", id)
+    cp := *gs
+    subs := s.copySubsLocked(id)
+    payload := s.render(cp)
+    s.mu.Unlock()
+
+    s.publish(id, subs, Event{Name: EventBoard, Data: payload})
+    return &cp, nil
+}
+
+// DeleteGame removes a game and disconnects its subscribers.
+func (s *Service) DeleteGame(ctx context.Context, id string) error {
+    s.mu.Lock()
+    if _, ok := s.games[id]; !ok {
+        s.mu.Unlock()
+        return ErrNotFound
+    }
+    subs := s.copySubsLocked(id)
+    delete(s.games, id)
+    delete(s.subs, id)
+    delete(s.online, id)
+    delete(s.lastChat, id)
+    s.loggerLocked(ctx).Info("game deleted", "game_id", id)
+    s.mu.Unlock()
+
+    for sub := range subs {
+        sub.close()
+    }
+    return nil
+}
@@ -0,0 +1,140 @@
+package app
+
+import (
+    "context"
+    "errors"
+    "testing"
+    "time"
+
+    "github.com/jaminalder/codex-tic-tac-toe/internal/domain"
+)
+
+func TestListSummarizesGames(t *testing.T) {
+    s := NewServiceWithRenderer(testRenderer)
+    g1, _ := s.CreateGame(context.Background())
+    time.Sleep(time.Millisecond)
+    g2, _ := s.CreateGame(context.Background())
+    s.Join(context.Background(), g2.ID, "p1")
+
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+    s.Subscribe(ctx, g1.ID, "p9")
+
+    list := s.List()
+    if len(list) != 2 {
+        t.Fatalf("expected 2 games, got %d", len(list))
+    }
+    if list[0].ID != g2.ID || list[0].X != "p1" {
+        t.Fatalf("expected most recently updated game first, got %+v", list[0])
+    }
+    if list[1].ID != g1.ID || list[1].Subscribers != 1 {
+        t.Fatalf("expected subscriber count for g1, got %+v", list[1])
+    }
+}
+
+func TestEndGameForcesOverAndBroadcasts(t *testing.T) {
+    s := NewServiceWithRenderer(testRenderer)
+    gs, _ := s.CreateGame(context.Background())
+    s.Join(context.Background(), gs.ID, "p1")
+    s.Join(context.Background(), gs.ID, "p2")
+    ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+    defer cancel()
+    ch, unsub := s.Subscribe(ctx, gs.ID, "")
+    defer unsub()
+
+    st, err := s.EndGame(context.Background(), gs.ID)
+    if err != nil {
+        t.Fatalf("EndGame: %v", err)
+    }
+    if !st.Game.Over || !st.Aborted || st.Game.Outcome != domain.Aborted {
+        t.Fatalf("expected aborted game, got %+v", st)
+    }
+    if _, err := s.Play(context.Background(), gs.ID, "p1", 0, 0); !errors.Is(err, domain.ErrGameOver) {
+        t.Fatalf("expected ErrGameOver after force-end, got %v", err)
+    }
+    if _, err := s.EndGame(context.Background(), gs.ID); !errors.Is(err, domain.ErrGameOver) {
+        t.Fatalf("expected ErrGameOver when ending twice, got %v", err)
+    }
+    for {
+        select {
+        case ev := <-ch:
+            if ev.Name == EventBoard {
+                return
+            }
+        case <-ctx.Done():
+            t.Fatalf("expected board broadcast after force-end")
+        }
+    }
+}
+
+func TestDeleteGameDisconnectsSubscribers(t *testing.T) {
+    s := NewServiceWithRenderer(testRenderer)
+    gs, _ := s.CreateGame(context.Background())
+    ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+    defer cancel()
+    ch, _ := s.Subscribe(ctx, gs.ID, "")
+
+    if err := s.DeleteGame(context.Background(), gs.ID); err != nil {
+        t.Fatalf("DeleteGame: %v", err)
+    }
+    if _, ok := s.Get(gs.ID); ok {
+        t.Fatalf("expected game removed")
+    }
+    if err := s.DeleteGame(context.Background(), gs.ID); !errors.Is(err, ErrNotFound) {
+        t.Fatalf("expected ErrNotFound, got %v", err)
+    }
+    for {
+        select {
+        case _, ok := <-ch:
+            if !ok {
+                return
+            }
+        case <-ctx.Done():
+            t.Fatalf("expected subscriber channel closed")
+        }
+    }
+}
+
+func TestDeletedGameStaysDeletedOnResubscribe(t *testing.T) {
+    s := NewServiceWithRenderer(testRenderer)
+    gs, _ := s.CreateGame(context.Background())
+    if err := s.DeleteGame(context.Background(), gs.ID); err != nil {
+        t.Fatalf("DeleteGame: %v", err)
+    }
+    // A reconnecting event stream must not bring the game back.
+    ch, unsub := s.Subscribe(context.Background(), gs.ID, "p1")
+    defer unsub()
+    if _, ok := <-ch; ok {
+        t.Fatalf("expected a closed channel for a deleted game")
+    }
+    if _, ok := s.Get(gs.ID); ok {
+        t.Fatalf("subscribing recreated the deleted game")
+    }
+}
+
+func TestCloseFailsPingAndEndsStreams(t *testing.T) {
+    s := NewService()
+    gs, _ := s.CreateGame(context.Background())
+    if err := s.Ping(); err != nil {
+        t.Fatalf("expected ready service, got %v", err)
+    }
+    ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+    defer cancel()
+    ch, _ := s.Subscribe(ctx, gs.ID, "")
+
+    s.Close()
+    if err := s.Ping(); !errors.Is(err, ErrClosed) {
+        t.Fatalf("expected ErrClosed, got %v", err)
+    }
+    for open := true; open; {
+        select {
+        case _, open = <-ch:
+        case <-ctx.Done():
+            t.Fatalf("expected stream closed on shutdown")
+        }
+    }
+    late, _ := s.Subscribe(ctx, gs.ID, "")
+    if _, ok := <-late; ok {
+        t.Fatalf("expected closed stream after shutdown")
+    }
+}
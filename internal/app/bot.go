@@ -0,0 +1,85 @@
+package app
+
+import (
+    "context"
+    "errors"
+
+    "github.com/jaminalder/codex-tic-tac-toe/internal/bot"
+    "github.com/jaminalder/codex-tic-tac-toe/internal/domain"
+)
+
+// PlayBot seats a computer player as playerID in game id and returns its
+// seat. The player moves with m in the background, as Drive, until the game
+// is over or deleted, or the service closes. Quantum games cannot be played
+// this way.
+func (s *Service) PlayBot(ctx context.Context, id, playerID string, m bot.Mover) (domain.Cell, error) {
+    seat, _, err := s.Join(ctx, id, playerID)
+    if err != nil {
+        return domain.Empty, err
+    }
+    if seat == domain.Empty {
+        return domain.Empty, ErrNoSeat
+    }
+    // The bot outlives the call that seated it but keeps logging through
+    // its logger.
+    log := s.logger(ctx)
+    go func() {
+        err := s.Drive(context.Background(), id, playerID, m)
+        if err != nil && !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrClosed) {
+            log.Error("bot stopped", "game_id", id, "player_id", playerID, "err", err)
+        }
+    }()
+    return seat, nil
+}
+
+// Drive plays the moves m picks for playerID, who must be seated in game id,
+// whenever it is their turn. It returns nil once the game is over, and
+// otherwise ctx's error, ErrNotFound once the game is deleted, or the error
+// with which m failed or the game rejected its move.
+func (s *Service) Drive(ctx context.Context, id, playerID string, m bot.Mover) error {
+    events, unsubscribe := s.Subscribe(ctx, id, playerID)
+    defer func() { unsubscribe() }()
+    for {
+        gs, ok := s.Get(id)
+        if !ok {
+            return ErrNotFound
+        }
+        if gs.Game.Over {
+            return nil
+        }
+        seat := gs.SeatOf(playerID)
+        if seat == domain.Empty {
+            return ErrNotAPlayer
+        }
+        if gs.Game.Turn == seat {
+            mv, err := m.BestMove(gs.Game)
+            if ctx.Err() != nil {
+                return ctx.Err()
+            }
+            if err != nil {
+                return err
+            }
+            geo := gs.Game.Geometry()
+            _, err = s.PlayMark(ctx, id, playerID, mv.Index/geo.Cols, mv.Index%geo.Cols, mv.Mark)
+            if err != nil && !errors.Is(err, ErrNotYourTurn) && !errors.Is(err, domain.ErrGameOver) {
+                return err
+            }
+            // Played, or the game moved on meanwhile; look again.
+            continue
+        }
+        select {
+        case <-ctx.Done():
+            return ctx.Err()
+        case _, ok := <-events:
+            if ok {
+                continue
+            }
+            // Dropped as a slow subscriber, or the game or service is gone.
+            if err := s.Ping(); err != nil {
+                return err
+            }
+            unsubscribe()
+            events, unsubscribe = s.Subscribe(ctx, id, playerID)
+        }
+    }
+}
@@ -0,0 +1,79 @@
+package app
+
+import (
+    "context"
+    "testing"
+    "time"
+
+    "github.com/jaminalder/codex-tic-tac-toe/internal/domain"
+)
+
+// firstMove plays the first legal move.
+type firstMove struct{}
+
+func (firstMove) BestMove(g domain.Game) (domain.Move, error) {
+    return g.LegalMoves()[0], nil
+}
+
+// waitMoves polls game id until it has n moves.
+func waitMoves(t *testing.T, s *Service, id string, n int) *GameState {
+    t.Helper()
+    deadline := time.Now().Add(2 * time.Second)
+    for {
+        gs, ok := s.Get(id)
+        if ok && gs.Game.Moves >= n {
+            return gs
+        }
+        if time.Now().After(deadline) {
+            t.Fatalf("timed out waiting for move %d", n)
+        }
+        time.Sleep(5 * time.Millisecond)
+    }
+}
+
+func TestPlayBotAnswersMoves(t *testing.T) {
+    ctx := context.Background()
+    s := NewServiceWithRenderer(testRenderer)
+    gs, _ := s.CreateGameWithOptions(ctx, GameOptions{First: domain.O, Creator: "human", CreatorSide: domain.X})
+    seat, err := s.PlayBot(ctx, gs.ID, "bot:test", firstMove{})
+    if err != nil || seat != domain.O {
+        t.Fatalf("PlayBot: seat %v, err %v", seat, err)
+    }
+    // The bot moves first, then answers each human move.
+    if got := waitMoves(t, s, gs.ID, 1); got.Game.Board[0] != domain.O {
+        t.Fatalf("expected the bot's first move in a1, got %+v", got.Game.Board)
+    }
+    s.Join(ctx, gs.ID, "human")
+    if _, err := s.Play(ctx, gs.ID, "human", 2, 2); err != nil {
+        t.Fatalf("human move: %v", err)
+    }
+    if got := waitMoves(t, s, gs.ID, 3); got.Game.Board[1] != domain.O {
+        t.Fatalf("expected the bot's reply in b1, got %+v", got.Game.Board)
+    }
+    if _, err := s.PlayBot(ctx, gs.ID, "bot:other", firstMove{}); err != ErrNoSeat {
+        t.Fatalf("expected ErrNoSeat for a full game, got %v", err)
+    }
+}
+
+func TestDriveReturnsWhenGameEnds(t *testing.T) {
+    ctx := context.Background()
+    s := NewServiceWithRenderer(testRenderer)
+    gs, _ := s.CreateGame(ctx)
+    if err := s.Drive(ctx, gs.ID, "stranger", firstMove{}); err != ErrNotAPlayer {
+        t.Fatalf("expected ErrNotAPlayer for an unseated player, got %v", err)
+    }
+    // With both seats driven by the same mover the game plays itself out.
+    s.Join(ctx, gs.ID, "a")
+    s.Join(ctx, gs.ID, "b")
+    done := make(chan error, 1)
+    go func() { done <- s.Drive(ctx, gs.ID, "b", firstMove{}) }()
+    if err := s.Drive(ctx, gs.ID, "a", firstMove{}); err != nil {
+        t.Fatalf("Drive: %v", err)
+    }
+    if err := <-done; err != nil {
+        t.Fatalf("Drive: %v", err)
+    }
+    if got, _ := s.Get(gs.ID); !got.Game.Over {
+        t.Fatalf("expected the game to be over, got %+v", got.Game)
+    }
+}
@@ -0,0 +1,197 @@
+package app
+
+import (
+    "context"
+    "errors"
+    "sort"
+    "sync"
+    "time"
+
+    "github.com/jaminalder/codex-tic-tac-toe/internal/domain"
+)
+
+// Errors returned by the challenge functions.
+var (
+    ErrNoSeat      = errors.New("no free seat")
+    ErrNoChallenge = errors.New("no such challenge")
+)
+
+// Challenge invites a player, typically a bot, to take a seat in a game.
+type Challenge struct {
+    GameID     string
+    Player     string // the challenged player ID
+    Challenger string // player ID of whoever issued the challenge
+    Variant    domain.Variant
+    Created    time.Time
+}
+
+// challengeSubscriber receives the new challenges for one player.
+type challengeSubscriber struct {
+    mu     sync.Mutex
+    ch     chan Challenge
+    closed bool
+}
+
+func (s *challengeSubscriber) close() {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if !s.closed {
+        s.closed = true
+        close(s.ch)
+    }
+}
+
+// offer delivers c without blocking; it reports false when the subscriber is full.
+func (s *challengeSubscriber) offer(c Challenge) bool {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if s.closed {
+        return true
+    }
+    select {
+    case s.ch <- c:
+        return true
+    default:
+        return false
+    }
+}
+
+// Challenge invites playerID into game id on behalf of challenger. The game
+// must be in progress with a seat playerID could claim.
+func (s *Service) Challenge(ctx context.Context, id, challenger, playerID string) (*Challenge, error) {
+    s.mu.Lock()
+    gs, ok := s.games[id]
+    if !ok {
+        s.mu.Unlock()
+        return nil, ErrNotFound
+    }
+    if gs.Game.Over {
+        s.mu.Unlock()
+        return nil, domain.ErrGameOver
+    }
+    if gs.SeatOf(playerID) == domain.Empty && gs.openSeat(playerID) == domain.Empty {
+        s.mu.Unlock()
+        return nil, ErrNoSeat
+    }
+    c := Challenge{GameID: id, Player: playerID, Challenger: challenger, Variant: gs.Game.Variant, Created: time.Now()}
+    pending := s.challenges[playerID]
+    if pending == nil {
+        pending = make(map[string]Challenge)
+        s.challenges[playerID] = pending
+    }
+    pending[id] = c
+    var subs []*challengeSubscriber
+    for sub := range s.challengeSubs[playerID] {
+        subs = append(subs, sub)
+    }
+    s.loggerLocked(ctx).Info("challenge issued", "game_id", id, "player_id", playerID, "challenger", challenger)
+    s.mu.Unlock()
+
+    for _, sub := range subs {
+        if !sub.offer(c) {
+            // drop slow subscriber; it finds the challenge in Challenges when it reconnects
+            s.mu.Lock()
+            delete(s.challengeSubs[playerID], sub)
+            s.mu.Unlock()
+            sub.close()
+        }
+    }
+    return &c, nil
+}
+
+// Challenges lists the challenges waiting for playerID, oldest first. Those
+// whose game has since finished or gone are left out.
+func (s *Service) Challenges(playerID string) []Challenge {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    var out []Challenge
+    for id, c := range s.challenges[playerID] {
+        if gs, ok := s.games[id]; !ok || gs.Game.Over {
+            delete(s.challenges[playerID], id)
+            continue
+        }
+        out = append(out, c)
+    }
+    sort.Slice(out, func(i, j int) bool { return out[i].Created.Before(out[j].Created) })
+    return out
+}
+
+// SubscribeChallenges streams the challenges issued to playerID from now on.
+// The channel closes when ctx is done, on unsubscribe, or when the service
+// closes.
+func (s *Service) SubscribeChallenges(ctx context.Context, playerID string) (<-chan Challenge, func()) {
+    sub := &challengeSubscriber{ch: make(chan Challenge, subscriberBuffer)}
+    s.mu.Lock()
+    if s.closed {
+        s.mu.Unlock()
+        sub.close()
+        return sub.ch, func() {}
+    }
+    set := s.challengeSubs[playerID]
+    if set == nil {
+        set = make(map[*challengeSubscriber]struct{})
+        s.challengeSubs[playerID] = set
+    }
+    set[sub] = struct{}{}
+    s.mu.Unlock()
+
+    unsubOnce := &sync.Once{}
+    unsub := func() {
+        unsubOnce.Do(func() {
+            s.mu.Lock()
+            if set, ok := s.challengeSubs[playerID]; ok {
+                delete(set, sub)
+                if len(set) == 0 {
+                    delete(s.challengeSubs, playerID)
+                }
+            }
+            s.mu.Unlock()
+            sub.close()
+        })
+    }
+    go func() {
+        <-ctx.Done()
+        unsub()
+    }()
+    return sub.ch, unsub
+}
+
+// AcceptChallenge takes up the challenge to playerID in game id by joining
+// it, and returns the seat claimed.
+func (s *Service) AcceptChallenge(ctx context.Context, id, playerID string) (domain.Cell, *GameState, error) {
+    if err := s.takeChallenge(id, playerID); err != nil {
+        return domain.Empty, nil, err
+    }
+    side, gs, err := s.Join(ctx, id, playerID)
+    if err == nil && side == domain.Empty {
+        err = ErrNoSeat
+    }
+    if err != nil {
+        return domain.Empty, nil, err
+    }
+    s.logger(ctx).Info("challenge accepted", "game_id", id, "player_id", playerID)
+    return side, gs, nil
+}
+
+// DeclineChallenge withdraws the challenge to playerID in game id.
+func (s *Service) DeclineChallenge(ctx context.Context, id, playerID string) error {
+    if err := s.takeChallenge(id, playerID); err != nil {
+        return err
+    }
+    s.logger(ctx).Info("challenge declined", "game_id", id, "player_id", playerID)
+    return nil
+}
+
+// takeChallenge removes the challenge to playerID in game id.
+func (s *Service) takeChallenge(id, playerID string) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if _, ok := s.challenges[playerID][id]; !ok {
+        return ErrNoChallenge
+    }
+    delete(s.challenges[playerID], id)
+    if len(s.challenges[playerID]) == 0 {
+        delete(s.challenges, playerID)
+    }
+    return nil
+}
@@ -0,0 +1,81 @@
+package app
+
+import (
+    "context"
+    "errors"
+    "testing"
+    "time"
+
+    "github.com/jaminalder/codex-tic-tac-toe/internal/domain"
+)
+
+func TestChallengeDeliveredAndAccepted(t *testing.T) {
+    s := NewServiceWithRenderer(testRenderer)
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+    ch, _ := s.SubscribeChallenges(ctx, "bot:a")
+
+    gs, _ := s.CreateGameWithOptions(context.Background(), GameOptions{Creator: "human", CreatorSide: domain.X})
+    if _, err := s.Challenge(context.Background(), gs.ID, "human", "bot:a"); err != nil {
+        t.Fatalf("challenge: %v", err)
+    }
+    select {
+    case c := <-ch:
+        if c.GameID != gs.ID || c.Challenger != "human" || c.Player != "bot:a" {
+            t.Fatalf("unexpected challenge %+v", c)
+        }
+    case <-time.After(time.Second):
+        t.Fatal("challenge not delivered")
+    }
+    if pending := s.Challenges("bot:a"); len(pending) != 1 {
+        t.Fatalf("expected one pending challenge, got %+v", pending)
+    }
+
+    side, _, err := s.AcceptChallenge(context.Background(), gs.ID, "bot:a")
+    if err != nil || side != domain.O {
+        t.Fatalf("bot should take O beside the creator's X, got %v, err=%v", side, err)
+    }
+    if pending := s.Challenges("bot:a"); len(pending) != 0 {
+        t.Fatalf("accepted challenge still pending: %+v", pending)
+    }
+    if _, _, err := s.AcceptChallenge(context.Background(), gs.ID, "bot:a"); !errors.Is(err, ErrNoChallenge) {
+        t.Fatalf("expected ErrNoChallenge on a second accept, got %v", err)
+    }
+}
+
+func TestChallengeDeclinedAndRefused(t *testing.T) {
+    s := NewServiceWithRenderer(testRenderer)
+    gs, _ := s.CreateGame(context.Background())
+    if _, err := s.Challenge(context.Background(), gs.ID, "human", "bot:a"); err != nil {
+        t.Fatalf("challenge: %v", err)
+    }
+    if err := s.DeclineChallenge(context.Background(), gs.ID, "bot:a"); err != nil {
+        t.Fatalf("decline: %v", err)
+    }
+    if pending := s.Challenges("bot:a"); len(pending) != 0 {
+        t.Fatalf("declined challenge still pending: %+v", pending)
+    }
+
+    s.Join(context.Background(), gs.ID, "p1")
+    s.Join(context.Background(), gs.ID, "p2")
+    if _, err := s.Challenge(context.Background(), gs.ID, "p1", "bot:a"); !errors.Is(err, ErrNoSeat) {
+        t.Fatalf("expected ErrNoSeat for a full game, got %v", err)
+    }
+    if _, err := s.Challenge(context.Background(), "missing", "p1", "bot:a"); !errors.Is(err, ErrNotFound) {
+        t.Fatalf("expected ErrNotFound, got %v", err)
+    }
+}
+
+func TestCloseEndsChallengeStreams(t *testing.T) {
+    s := NewServiceWithRenderer(testRenderer)
+    ch, _ := s.SubscribeChallenges(context.Background(), "bot:a")
+    s.Close()
+    select {
+    case _, ok := <-ch:
+        if ok {
+            t.Fatal("expected the stream to close, got a challenge")
+        }
+    case <-time.After(time.Second):
+        t.Fatal("challenge stream still open after Close")
+    }
+}
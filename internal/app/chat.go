@@ -0,0 +1,169 @@
+package app
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "regexp"
+    "strings"
+    "time"
+    "unicode/utf8"
+
+    "github.com/jaminalder/codex-tic-tac-toe/internal/domain"
+)
+
+// Chat limits.
+const (
+    MaxChatLength  = 280 // runes per message
+    MaxChatHistory = 100 // messages kept per game
+)
+
+// ChatInterval is the minimum delay between two messages from the same player ID
+// in a game.
+var ChatInterval = time.Second
+
+// Errors returned by PostChat.
+var (
+    ErrChatEmpty       = errors.New("chat message empty")
+    ErrChatTooLong     = errors.New("chat message too long")
+    ErrChatRateLimited = errors.New("chat rate limited")
+    ErrChatRejected    = errors.New("chat message rejected")
+)
+
+// ChatMessage is a single message posted to a game's chat.
+type ChatMessage struct {
+    PlayerID string
+    Seat     domain.Cell // X or O for players, Empty for spectators
+    SeatName string      // what the seat is called, e.g. "X" or "Order"; "" for spectators
+    Text     string
+    At       time.Time
+}
+
+// ChatFilter moderates messages before they are stored. It returns the text
+// to store (possibly rewritten) or an error to reject the message.
+type ChatFilter interface {
+    Filter(gameID, playerID, text string) (string, error)
+}
+
+// ChatFilterFunc adapts a function to ChatFilter.
+type ChatFilterFunc func(gameID, playerID, text string) (string, error)
+
+// Filter calls f.
+func (f ChatFilterFunc) Filter(gameID, playerID, text string) (string, error) {
+    return f(gameID, playerID, text)
+}
+
+// WordFilter masks whole words from a blocklist, ignoring case.
+type WordFilter struct {
+    re *regexp.Regexp
+}
+
+// NewWordFilter builds a WordFilter for the given words.
+func NewWordFilter(words ...string) *WordFilter {
+    quoted := make([]string, 0, len(words))
+    for _, w := range words {
+        if w = strings.TrimSpace(w); w != "" {
+            quoted = append(quoted, regexp.QuoteMeta(w))
+        }
+    }
+    if len(quoted) == 0 {
+        return &WordFilter{}
+    }
+    return &WordFilter{re: regexp.MustCompile(`(?i)\b(` + strings.Join(quoted, "|") + `)\b`)}
+}
+
+// Filter replaces blocked words with asterisks.
+func (f *WordFilter) Filter(gameID, playerID, text string) (string, error) {
+    if f.re == nil {
+        return text, nil
+    }
+    return f.re.ReplaceAllStringFunc(text, func(w string) string {
+        return strings.Repeat("*", utf8.RuneCountInString(w))
+    }), nil
+}
+
+// SetChatFilter installs a moderation filter; nil disables filtering.
+func (s *Service) SetChatFilter(f ChatFilter) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.chatFilter = f
+}
+
+// SetChatRenderer replaces the renderer used for chat broadcasts.
+func (s *Service) SetChatRenderer(renderer func(ChatMessage) []byte) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if renderer == nil {
+        s.renderChat = func(m ChatMessage) []byte { return nil }
+        return
+    }
+    s.renderChat = renderer
+}
+
+// PostChat validates, moderates and stores a message, then broadcasts it.
+func (s *Service) PostChat(ctx context.Context, id, playerID, text string) (*ChatMessage, error) {
+    text = strings.TrimSpace(text)
+    if text == "" {
+        return nil, ErrChatEmpty
+    }
+    if utf8.RuneCountInString(text) > MaxChatLength {
+        return nil, ErrChatTooLong
+    }
+
+    s.mu.Lock()
+    if _, ok := s.games[id]; !ok {
+        s.mu.Unlock()
+        return nil, ErrNotFound
+    }
+    now := time.Now()
+    last := s.lastChat[id]
+    if t, ok := last[playerID]; ok && now.Sub(t) < ChatInterval {
+        s.mu.Unlock()
+        return nil, ErrChatRateLimited
+    }
+    if last == nil {
+        last = make(map[string]time.Time)
+        s.lastChat[id] = last
+    }
+    // Entries past the interval no longer limit anyone.
+    for pid, t := range last {
+        if now.Sub(t) >= ChatInterval {
+            delete(last, pid)
+        }
+    }
+    last[playerID] = now
+    filter := s.chatFilter
+    log := s.loggerLocked(ctx)
+    s.mu.Unlock()
+
+    // Filters may be slow (e.g. remote moderation), so run them unlocked.
+    if filter != nil {
+        filtered, err := filter.Filter(id, playerID, text)
+        if err != nil {
+            log.Info("chat message rejected", "game_id", id, "player_id", playerID, "err", err)
+            return nil, fmt.Errorf("%w: %v", ErrChatRejected, err)
+        }
+        text = filtered
+    }
+
+    s.mu.Lock()
+    gs, ok := s.games[id]
+    if !ok {
+        s.mu.Unlock()
+        return nil, ErrNotFound
+    }
+    msg := ChatMessage{PlayerID: playerID, Text: text, At: time.Now()}
+    if msg.Seat = gs.SeatOf(playerID); msg.Seat != domain.Empty {
+        msg.SeatName = gs.SeatName(msg.Seat)
+    }
+    gs.Chat = append(gs.Chat, msg)
+    if len(gs.Chat) > MaxChatHistory {
+        gs.Chat = gs.Chat[len(gs.Chat)-MaxChatHistory:]
+    }
+    subs := s.copySubsLocked(id)
+    ev := Event{Name: EventChat, Data: s.renderChat(msg)}
+    s.mu.Unlock()
+
+    s.publish(id, subs, ev)
+    return &msg, nil
+}
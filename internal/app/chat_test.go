@@ -0,0 +1,140 @@
+package app
+
+import (
+    "context"
+    "errors"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/jaminalder/codex-tic-tac-toe/internal/domain"
+)
+
+func TestPostChatStoresAndBroadcasts(t *testing.T) {
+    s := NewServiceWithRenderer(testRenderer)
+    s.SetChatRenderer(func(m ChatMessage) []byte { return []byte(m.PlayerID + ": " + m.Text) })
+    gs, _ := s.CreateGame(context.Background())
+    s.Join(context.Background(), gs.ID, "p1")
+
+    ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
+    defer cancel()
+    ch, unsub := s.Subscribe(ctx, gs.ID, "p3")
+    defer unsub()
+
+    msg, err := s.PostChat(context.Background(), gs.ID, "p1", "  good luck  ")
+    if err != nil {
+        t.Fatalf("PostChat error: %v", err)
+    }
+    if msg.Text != "good luck" || msg.Seat != domain.X {
+        t.Fatalf("unexpected message: %+v", msg)
+    }
+    latest, _ := s.Get(gs.ID)
+    if len(latest.Chat) != 1 || latest.Chat[0].Text != "good luck" {
+        t.Fatalf("expected message stored with game, got %+v", latest.Chat)
+    }
+    for {
+        select {
+        case ev := <-ch:
+            if ev.Name != EventChat {
+                continue
+            }
+            if string(ev.Data) != "p1: good luck" {
+                t.Fatalf("unexpected chat payload: %q", ev.Data)
+            }
+            return
+        case <-ctx.Done():
+            t.Fatalf("timed out waiting for chat event")
+        }
+    }
+}
+
+func TestPostChatValidation(t *testing.T) {
+    s := NewService()
+    gs, _ := s.CreateGame(context.Background())
+    if _, err := s.PostChat(context.Background(), gs.ID, "p1", "   "); !errors.Is(err, ErrChatEmpty) {
+        t.Fatalf("expected ErrChatEmpty, got %v", err)
+    }
+    if _, err := s.PostChat(context.Background(), gs.ID, "p1", strings.Repeat("a", MaxChatLength+1)); !errors.Is(err, ErrChatTooLong) {
+        t.Fatalf("expected ErrChatTooLong, got %v", err)
+    }
+    if _, err := s.PostChat(context.Background(), "missing", "p1", "hi"); !errors.Is(err, ErrNotFound) {
+        t.Fatalf("expected ErrNotFound, got %v", err)
+    }
+}
+
+func TestPostChatRateLimitedPerPlayer(t *testing.T) {
+    s := NewService()
+    gs, _ := s.CreateGame(context.Background())
+    if _, err := s.PostChat(context.Background(), gs.ID, "p1", "one"); err != nil {
+        t.Fatalf("first message failed: %v", err)
+    }
+    if _, err := s.PostChat(context.Background(), gs.ID, "p1", "two"); !errors.Is(err, ErrChatRateLimited) {
+        t.Fatalf("expected ErrChatRateLimited, got %v", err)
+    }
+    // Another player is unaffected
+    if _, err := s.PostChat(context.Background(), gs.ID, "p2", "three"); err != nil {
+        t.Fatalf("other player should not be limited: %v", err)
+    }
+}
+
+func TestChatRateLimitStateIsPruned(t *testing.T) {
+    old := ChatInterval
+    ChatInterval = 0
+    defer func() { ChatInterval = old }()
+
+    s := NewService()
+    gs, _ := s.CreateGame(context.Background())
+    for _, pid := range []string{"p1", "p2", "p3"} {
+        if _, err := s.PostChat(context.Background(), gs.ID, pid, "hi"); err != nil {
+            t.Fatalf("PostChat %s: %v", pid, err)
+        }
+    }
+    // Entries older than the interval are evicted on the next insert.
+    if n := len(s.lastChat[gs.ID]); n != 1 {
+        t.Fatalf("expected 1 rate limit entry, got %d", n)
+    }
+    if err := s.DeleteGame(context.Background(), gs.ID); err != nil {
+        t.Fatal(err)
+    }
+    if _, ok := s.lastChat[gs.ID]; ok {
+        t.Fatal("rate limit entries outlived the game")
+    }
+}
+
+func TestPostChatFilter(t *testing.T) {
+    s := NewService()
+    gs, _ := s.CreateGame(context.Background())
+    s.SetChatFilter(NewWordFilter("darn"))
+    msg, err := s.PostChat(context.Background(), gs.ID, "p1", "Darn, missed it")
+    if err != nil {
+        t.Fatalf("PostChat error: %v", err)
+    }
+    if msg.Text != "****, missed it" {
+        t.Fatalf("expected masked word, got %q", msg.Text)
+    }
+
+    s.SetChatFilter(ChatFilterFunc(func(gameID, playerID, text string) (string, error) {
+        return "", errors.New("links not allowed")
+    }))
+    if _, err := s.PostChat(context.Background(), gs.ID, "p2", "http://spam"); !errors.Is(err, ErrChatRejected) {
+        t.Fatalf("expected ErrChatRejected, got %v", err)
+    }
+}
+
+func TestChatHistoryIsBounded(t *testing.T) {
+    old := ChatInterval
+    ChatInterval = 0
+    defer func() { ChatInterval = old }()
+
+    s := NewService()
+    gs, _ := s.CreateGame(context.Background())
+    for i := 0; i < MaxChatHistory+5; i++ {
+        if _, err := s.PostChat(context.Background(), gs.ID, "p1", "msg"); err != nil {
+            t.Fatalf("message %d failed: %v", i, err)
+        }
+    }
+    latest, _ := s.Get(gs.ID)
+    if len(latest.Chat) != MaxChatHistory {
+        t.Fatalf("expected %d messages kept, got %d", MaxChatHistory, len(latest.Chat))
+    }
+}
@@ -0,0 +1,97 @@
+package app
+
+import (
+    "github.com/jaminalder/codex-tic-tac-toe/internal/domain"
+    "github.com/prometheus/client_golang/prometheus"
+)
+
+// Stats is a point-in-time summary of the service.
+type Stats struct {
+    Games       int
+    ActiveGames int // games not yet over
+    Subscribers int
+}
+
+// serviceMetrics holds the service collectors. They always exist so callers
+// need no nil checks; RegisterMetrics exposes them to a registry.
+type serviceMetrics struct {
+    gamesCreated       prometheus.Counter
+    movesPlayed        prometheus.Counter
+    gamesFinished      *prometheus.CounterVec
+    droppedSubscribers prometheus.Counter
+}
+
+func newServiceMetrics() *serviceMetrics {
+    return &serviceMetrics{
+        gamesCreated: prometheus.NewCounter(prometheus.CounterOpts{
+            Name: "ttt_games_created_total",
+            Help: "Games created.",
+        }),
+        movesPlayed: prometheus.NewCounter(prometheus.CounterOpts{
+            Name: "ttt_moves_played_total",
+            Help: "Moves applied to games.",
+        }),
+        gamesFinished: prometheus.NewCounterVec(prometheus.CounterOpts{
+            Name: "ttt_games_finished_total",
+            Help: "Games finished, by outcome (x, o, draw, aborted).",
+        }, []string{"outcome"}),
+        droppedSubscribers: prometheus.NewCounter(prometheus.CounterOpts{
+            Name: "ttt_sse_subscribers_dropped_total",
+            Help: "Event subscribers dropped for not keeping up.",
+        }),
+    }
+}
+
+// outcomeLabel names the result of a finished game for metrics.
+func outcomeLabel(o domain.Outcome) string {
+    switch o {
+    case domain.XWon:
+        return "x"
+    case domain.OWon:
+        return "o"
+    case domain.Aborted:
+        return "aborted"
+    default:
+        return "draw"
+    }
+}
+
+// Stats returns counts of games and subscribers.
+func (s *Service) Stats() Stats {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    st := Stats{Games: len(s.games)}
+    for _, gs := range s.games {
+        if gs.Game.Outcome == domain.InProgress {
+            st.ActiveGames++
+        }
+    }
+    for _, set := range s.subs {
+        st.Subscribers += len(set)
+    }
+    return st
+}
+
+// RegisterMetrics registers the service counters and gauges with reg.
+func (s *Service) RegisterMetrics(reg prometheus.Registerer) error {
+    collectors := []prometheus.Collector{
+        s.metrics.gamesCreated,
+        s.metrics.movesPlayed,
+        s.metrics.gamesFinished,
+        s.metrics.droppedSubscribers,
+        prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+            Name: "ttt_games_active",
+            Help: "Games in progress.",
+        }, func() float64 { return float64(s.Stats().ActiveGames) }),
+        prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+            Name: "ttt_sse_subscribers",
+            Help: "Connected event stream subscribers.",
+        }, func() float64 { return float64(s.Stats().Subscribers) }),
+    }
+    for _, c := range collectors {
+        if err := reg.Register(c); err != nil {
+            return err
+        }
+    }
+    return nil
+}
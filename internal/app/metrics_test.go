@@ -0,0 +1,78 @@
+package app
+
+import (
+    "context"
+    "testing"
+
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetricsCountGamesMovesAndOutcomes(t *testing.T) {
+    s := NewServiceWithRenderer(testRenderer)
+    reg := prometheus.NewRegistry()
+    if err := s.RegisterMetrics(reg); err != nil {
+        t.Fatalf("RegisterMetrics: %v", err)
+    }
+    gs, _ := s.CreateGame(context.Background())
+    s.CreateGame(context.Background())
+    s.Join(context.Background(), gs.ID, "p1")
+    s.Join(context.Background(), gs.ID, "p2")
+    // X wins on the top row
+    for i, m := range [][3]any{{"p1", 0, 0}, {"p2", 1, 0}, {"p1", 0, 1}, {"p2", 1, 1}, {"p1", 0, 2}} {
+        if _, err := s.Play(context.Background(), gs.ID, m[0].(string), m[1].(int), m[2].(int)); err != nil {
+            t.Fatalf("move %d: %v", i, err)
+        }
+    }
+
+    if got := testutil.ToFloat64(s.metrics.gamesCreated); got != 2 {
+        t.Fatalf("games created = %v, want 2", got)
+    }
+    if got := testutil.ToFloat64(s.metrics.movesPlayed); got != 5 {
+        t.Fatalf("moves played = %v, want 5", got)
+    }
+    if got := testutil.ToFloat64(s.metrics.gamesFinished.WithLabelValues("x")); got != 1 {
+        t.Fatalf("games finished x = %v, want 1", got)
+    }
+    if st := s.Stats(); st.Games != 2 || st.ActiveGames != 1 {
+        t.Fatalf("unexpected stats: %+v", st)
+    }
+    other, _ := s.CreateGame(context.Background())
+    s.EndGame(context.Background(), other.ID)
+    if got := testutil.ToFloat64(s.metrics.gamesFinished.WithLabelValues("aborted")); got != 1 {
+        t.Fatalf("games finished aborted = %v, want 1", got)
+    }
+    if got := testutil.ToFloat64(s.metrics.gamesFinished.WithLabelValues("draw")); got != 0 {
+        t.Fatalf("games finished draw = %v, want 0", got)
+    }
+}
+
+func TestMetricsCountDroppedSubscribers(t *testing.T) {
+    s := NewServiceWithRenderer(testRenderer)
+    gs, _ := s.CreateGame(context.Background())
+    s.Join(context.Background(), gs.ID, "p1")
+    s.Join(context.Background(), gs.ID, "p2")
+
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+    _, _ = s.Subscribe(ctx, gs.ID, "") // never read
+    if st := s.Stats(); st.Subscribers != 1 {
+        t.Fatalf("expected 1 subscriber, got %+v", st)
+    }
+    moves := [][2]int{{0, 0}, {1, 1}, {0, 1}, {0, 2}, {2, 0}, {1, 0}, {1, 2}, {2, 1}, {2, 2}}
+    for i, m := range moves {
+        pid := "p1"
+        if i%2 == 1 {
+            pid = "p2"
+        }
+        if _, err := s.Play(context.Background(), gs.ID, pid, m[0], m[1]); err != nil {
+            t.Fatalf("move %d: %v", i, err)
+        }
+    }
+    if got := testutil.ToFloat64(s.metrics.droppedSubscribers); got != 1 {
+        t.Fatalf("dropped subscribers = %v, want 1", got)
+    }
+    if st := s.Stats(); st.Subscribers != 0 {
+        t.Fatalf("expected dropped subscriber removed, got %+v", st)
+    }
+}
@@ -3,6 +3,7 @@ package app
 import (
     "context"
     "errors"
+    "log/slog"
     "sync"
     "time"
 
@@ -15,31 +16,252 @@ var (
     ErrNotFound    = errors.New("game not found")
     ErrNotYourTurn = errors.New("not your turn")
     ErrNotAPlayer  = errors.New("not a player")
+    ErrNotQuantum  = errors.New("not a quantum game")
 )
 
-// GameState is the in-memory state tracked per game.
+// Role names a seat by what its player does in the game's variant: RoleX
+// and RoleO move as X and O, and in Order and Chaos RoleOrder moves as X and
+// RoleChaos as O. Its text names the seat in the JSON APIs.
+type Role string
+
+const (
+    RoleX     Role = "x"
+    RoleO     Role = "o"
+    RoleOrder Role = "order"
+    RoleChaos Role = "chaos"
+)
+
+// Roles returns the two roles of a game of v, the one moving as X first.
+func Roles(v domain.Variant) [2]Role {
+    if v == domain.OrderChaos {
+        return [2]Role{RoleOrder, RoleChaos}
+    }
+    return [2]Role{RoleX, RoleO}
+}
+
+// Seat is one seat of a game: its role and the ID of the player holding it,
+// "" while it is free.
+type Seat struct {
+    Role   Role
+    Player string
+}
+
+// GameState is the in-memory state tracked per game. Seats holds the two
+// seats by role (see Roles), the one moving as X first.
 type GameState struct {
     ID      string
     Game    domain.Game
-    X       string
-    O       string
+    Seats   [2]Seat
     Created time.Time
     Updated time.Time
+    Chat    []ChatMessage
+    // Aborted is set when an administrator force-ended the game.
+    Aborted bool
+    // Quantum holds the spooky marks of a Quantum game; Game then mirrors
+    // its classical view (see domain.QuantumGame.Game).
+    Quantum domain.QuantumGame
+    // Creator is the player who created the game and CreatorSide the seat
+    // kept for them until they join; Empty keeps none.
+    Creator     string
+    CreatorSide domain.Cell
+}
+
+// Outcome reports how the game ended (domain.InProgress while running).
+func (gs GameState) Outcome() domain.Outcome { return gs.Game.Outcome }
+
+// WinningLine returns the board indexes of the winning line, or nil.
+func (gs GameState) WinningLine() []int { return gs.Game.Line }
+
+// SeatOf returns the side playerID is seated as, or Empty for spectators.
+func (gs GameState) SeatOf(playerID string) domain.Cell {
+    switch {
+    case playerID == "":
+        return domain.Empty
+    case playerID == gs.Seats[0].Player:
+        return domain.X
+    case playerID == gs.Seats[1].Player:
+        return domain.O
+    default:
+        return domain.Empty
+    }
+}
+
+// seat returns the seat moving as side, or nil for Empty.
+func (gs *GameState) seat(side domain.Cell) *Seat {
+    switch side {
+    case domain.X:
+        return &gs.Seats[0]
+    case domain.O:
+        return &gs.Seats[1]
+    default:
+        return nil
+    }
+}
+
+// PlayerOf returns the player ID seated as side, or "" when the seat is free.
+func (gs GameState) PlayerOf(side domain.Cell) string {
+    if st := gs.seat(side); st != nil {
+        return st.Player
+    }
+    return ""
+}
+
+// Player returns the player ID holding the seat with role r, or "" when the
+// seat is free or the game has no such role.
+func (gs GameState) Player(r Role) string {
+    for _, st := range gs.Seats {
+        if r != "" && st.Role == r {
+            return st.Player
+        }
+    }
+    return ""
+}
+
+// RoleOf returns the role of the seat moving as side, or "" for Empty.
+func (gs GameState) RoleOf(side domain.Cell) Role {
+    if st := gs.seat(side); st != nil {
+        return st.Role
+    }
+    return ""
+}
+
+// SideOf returns the side the seat with role r moves as, or Empty when the
+// game has no such role.
+func (gs GameState) SideOf(r Role) domain.Cell {
+    switch {
+    case r == "":
+        return domain.Empty
+    case r == gs.Seats[0].Role:
+        return domain.X
+    case r == gs.Seats[1].Role:
+        return domain.O
+    default:
+        return domain.Empty
+    }
+}
+
+// openSeat returns the free seat playerID may claim: the creator's chosen
+// seat for the creator, otherwise the first free seat not kept for them, or
+// Empty when there is none.
+func (gs GameState) openSeat(playerID string) domain.Cell {
+    kept := domain.Empty
+    if gs.CreatorSide != domain.Empty && gs.SeatOf(gs.Creator) == domain.Empty {
+        kept = gs.CreatorSide
+    }
+    if kept != domain.Empty && playerID == gs.Creator {
+        return kept
+    }
+    for _, side := range []domain.Cell{domain.X, domain.O} {
+        if gs.PlayerOf(side) == "" && side != kept {
+            return side
+        }
+    }
+    return domain.Empty
+}
+
+// SeatName returns what the seat moving as side is called in this game: "X"
+// or "O", or its role such as "Order"; Empty is "spectator".
+func (gs GameState) SeatName(side domain.Cell) string {
+    if side == domain.Empty {
+        return "spectator"
+    }
+    return gs.Game.Variant.SideName(side)
+}
+
+// RemainingNumbers returns the numbers seat has left to place in a Numerical
+// game (odd for whichever seat moved first, even for the other), or nil for
+// other variants.
+func (gs GameState) RemainingNumbers(seat domain.Cell) []int {
+    if gs.Game.Variant != domain.Numerical {
+        return nil
+    }
+    return domain.RemainingNumbers(gs.Game.Board, seat == gs.Game.OddSide())
+}
+
+// Event names published to subscribers.
+const (
+    EventBoard    = "board"
+    EventPresence = "presence"
+    EventChat     = "chat"
+)
+
+// Event is a named payload delivered to subscribers.
+type Event struct {
+    Name string
+    Data []byte
+}
+
+// Presence describes who is connected to a game's event stream. XSeat and
+// OSeat name the seats (see GameState.SeatName).
+type Presence struct {
+    ID           string
+    XSeat, OSeat string
+    XOnline      bool
+    OOnline      bool
+    Spectators   int
 }
 
+// subscriberBuffer is the per-subscriber queue length before it is considered slow.
+// Besides board updates every connect and disconnect to a game publishes a
+// presence event, so a page load (the player's own stream plus an opponent or
+// spectator arriving) can queue several events before a healthy client gets to
+// read; a buffer of one would drop it. Eight absorbs such bursts while still
+// cutting off a client that stops reading within a few moves.
+const subscriberBuffer = 8
+
 type subscriber struct {
-    ch       chan []byte
-    closeOnce sync.Once
+    mu       sync.Mutex
+    ch       chan Event
+    playerID string
+    closed   bool
 }
 
-func (s *subscriber) close() { s.closeOnce.Do(func() { close(s.ch) }) }
+func (s *subscriber) close() {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if !s.closed {
+        s.closed = true
+        close(s.ch)
+    }
+}
+
+// offer delivers ev without blocking; it reports false when the subscriber is full.
+// Sends and close are serialized so a concurrent unsubscribe cannot panic a publisher.
+func (s *subscriber) offer(ev Event) bool {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if s.closed {
+        return true
+    }
+    select {
+    case s.ch <- ev:
+        return true
+    default:
+        return false
+    }
+}
 
 // Service manages games and subscribers.
 type Service struct {
     mu     sync.Mutex
     games  map[string]*GameState
     subs   map[string]map[*subscriber]struct{}
-    render func(GameState) []byte
+    // online counts event stream connections per game and player ID.
+    online         map[string]map[string]int
+    render         func(GameState) []byte
+    renderPresence func(Presence) []byte
+    renderChat     func(ChatMessage) []byte
+    chatFilter     ChatFilter
+    // lastChat is the time of each player's most recent chat message per
+    // game; a game's entries go with it.
+    lastChat map[string]map[string]time.Time
+    metrics  *serviceMetrics
+    log      *slog.Logger
+    closed   bool
+    // challenges holds the pending challenges per challenged player ID and
+    // game ID; challengeSubs the streams waiting for new ones.
+    challenges    map[string]map[string]Challenge
+    challengeSubs map[string]map[*challengeSubscriber]struct{}
 }
 
 // NewService creates a service with a default renderer (encodes nothing useful).
@@ -53,7 +275,15 @@ func NewServiceWithRenderer(renderer func(GameState) []byte) *Service {
     return &Service{
         games:  make(map[string]*GameState),
         subs:   make(map[string]map[*subscriber]struct{}),
+        online: make(map[string]map[string]int),
         render: renderer,
+        renderPresence: func(p Presence) []byte { return nil },
+        renderChat: func(m ChatMessage) []byte { return nil },
+        lastChat: make(map[string]map[string]time.Time),
+        metrics:  newServiceMetrics(),
+        log:      slog.Default(),
+        challenges:    make(map[string]map[string]Challenge),
+        challengeSubs: make(map[string]map[*challengeSubscriber]struct{}),
     }
 }
 
@@ -68,14 +298,107 @@ func (s *Service) SetRenderer(renderer func(GameState) []byte) {
     s.render = renderer
 }
 
+// SetLogger replaces the service logger; nil restores slog.Default().
+func (s *Service) SetLogger(log *slog.Logger) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if log == nil {
+        log = slog.Default()
+    }
+    s.log = log
+}
+
+type loggerKey struct{}
+
+// WithLogger returns ctx carrying log. Service methods called with such a
+// context log through it, so their lines carry its attributes, e.g. the ID
+// of the HTTP request that caused them.
+func WithLogger(ctx context.Context, log *slog.Logger) context.Context {
+    return context.WithValue(ctx, loggerKey{}, log)
+}
+
+// LoggerFrom returns the logger carried by ctx, or fallback.
+func LoggerFrom(ctx context.Context, fallback *slog.Logger) *slog.Logger {
+    if log, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+        return log
+    }
+    return fallback
+}
+
+// loggerLocked returns the logger for a call made with ctx.
+func (s *Service) loggerLocked(ctx context.Context) *slog.Logger {
+    return LoggerFrom(ctx, s.log)
+}
+
+// logger is loggerLocked for callers not holding s.mu.
+func (s *Service) logger(ctx context.Context) *slog.Logger {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return s.loggerLocked(ctx)
+}
+
+// SetPresenceRenderer replaces the renderer used for presence broadcasts.
+func (s *Service) SetPresenceRenderer(renderer func(Presence) []byte) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if renderer == nil {
+        s.renderPresence = func(p Presence) []byte { return nil }
+        return
+    }
+    s.renderPresence = renderer
+}
+
 // CreateGame creates and registers a new game.
-func (s *Service) CreateGame() (*GameState, error) {
+func (s *Service) CreateGame(ctx context.Context) (*GameState, error) {
+    return s.CreateGameWithOptions(ctx, GameOptions{})
+}
+
+// GameOptions configures a new game; the zero value is a standard game.
+type GameOptions struct {
+    Variant domain.Variant
+    // First is the side that moves first; Empty means X.
+    First domain.Cell
+    // Creator is the creating player's ID. When CreatorSide is X or O that
+    // seat is kept for the creator: Join gives it to them and never to
+    // anyone else.
+    Creator     string
+    CreatorSide domain.Cell
+}
+
+// CreateGameWithOptions creates and registers a new game configured by opts.
+func (s *Service) CreateGameWithOptions(ctx context.Context, opts GameOptions) (*GameState, error) {
+    g := domain.NewVariant(opts.Variant)
+    if opts.First == domain.O {
+        g.Turn = domain.O
+    }
+    return s.create(ctx, g, opts)
+}
+
+// CreateGameFrom creates and registers a new game starting from g, e.g. a parsed position.
+func (s *Service) CreateGameFrom(ctx context.Context, g domain.Game) (*GameState, error) {
+    return s.create(ctx, g, GameOptions{})
+}
+
+func (s *Service) create(ctx context.Context, g domain.Game, opts GameOptions) (*GameState, error) {
     s.mu.Lock()
     defer s.mu.Unlock()
     id := uuid.NewString()
     now := time.Now()
-    gs := &GameState{ID: id, Game: domain.New(), Created: now, Updated: now}
+    gs := &GameState{ID: id, Game: g, Created: now, Updated: now, Creator: opts.Creator}
+    for i, r := range Roles(g.Variant) {
+        gs.Seats[i].Role = r
+    }
+    if opts.Creator != "" && (opts.CreatorSide == domain.X || opts.CreatorSide == domain.O) {
+        gs.CreatorSide = opts.CreatorSide
+    }
+    if g.Variant == domain.Quantum {
+        gs.Quantum = domain.NewQuantumGame()
+        gs.Quantum.Turn = g.Turn
+        gs.Game = gs.Quantum.Game()
+    }
     s.games[id] = gs
+    s.metrics.gamesCreated.Inc()
+    s.loggerLocked(ctx).Info("game created", "game_id", id, "variant", g.Variant.String(), "first", gs.SeatName(g.Start().Turn))
     cp := *gs
     return &cp, nil
 }
@@ -92,33 +415,104 @@ func (s *Service) Get(id string) (*GameState, bool) {
     return &cp, true
 }
 
-// Join assigns a seat to the player if available; returns Empty for spectators.
-func (s *Service) Join(id, playerID string) (domain.Cell, *GameState, error) {
+// Join assigns a seat to the player if available; returns Empty for
+// spectators. A seat kept for the game's creator goes only to them.
+func (s *Service) Join(ctx context.Context, id, playerID string) (domain.Cell, *GameState, error) {
     s.mu.Lock()
-    defer s.mu.Unlock()
     gs, ok := s.games[id]
     if !ok {
+        s.mu.Unlock()
         return domain.Empty, nil, ErrNotFound
     }
-    side := domain.Empty
-    if gs.X == "" || gs.X == playerID {
-        gs.X = playerID
-        side = domain.X
-    } else if gs.O == "" || gs.O == playerID {
-        gs.O = playerID
-        side = domain.O
+    side := gs.SeatOf(playerID)
+    claimed := false
+    if side == domain.Empty {
+        side = gs.openSeat(playerID)
+        if st := gs.seat(side); st != nil {
+            st.Player, claimed = playerID, true
+        }
     }
     gs.Updated = time.Now()
+    if claimed {
+        s.loggerLocked(ctx).Info("seat claimed", "game_id", id, "player_id", playerID, "seat", gs.SeatName(side))
+    }
     cp := *gs
+    if !claimed || s.online[id][playerID] == 0 {
+        s.mu.Unlock()
+        return side, &cp, nil
+    }
+    // A connected spectator took a seat: presence changed.
+    subs := s.copySubsLocked(id)
+    ev := Event{Name: EventPresence, Data: s.renderPresence(s.presenceLocked(id))}
+    s.mu.Unlock()
+    s.publish(id, subs, ev)
     return side, &cp, nil
 }
 
 // Play validates seat and turn, applies a move, updates timestamps, and broadcasts.
-func (s *Service) Play(id, playerID string, r, c int) (*GameState, error) {
-    var payload []byte
-    var cp GameState
-    var toDrop []*subscriber
+func (s *Service) Play(ctx context.Context, id, playerID string, r, c int) (*GameState, error) {
+    return s.PlayMark(ctx, id, playerID, r, c, domain.Empty)
+}
+
+// PlayMark is Play with the mark chosen by the player, for variants that
+// allow it; Empty places the player's own mark.
+func (s *Service) PlayMark(ctx context.Context, id, playerID string, r, c int, mark domain.Cell) (*GameState, error) {
+    return s.move(ctx, id, playerID, func(gs *GameState, seat domain.Cell) error {
+        if mark == domain.Empty {
+            mark = seat
+        }
+        if err := gs.Game.PlayMark(r, c, mark); err != nil {
+            return err
+        }
+        s.loggerLocked(ctx).Debug("move played", "game_id", id, "player_id", playerID, "seat", gs.SeatName(seat), "mark", mark.String(), "row", r, "col", c)
+        return nil
+    })
+}
+
+// PlaceSpooky places the player's spooky mark in squares a and b (board
+// indexes) of a Quantum game.
+func (s *Service) PlaceSpooky(ctx context.Context, id, playerID string, a, b int) (*GameState, error) {
+    return s.move(ctx, id, playerID, func(gs *GameState, seat domain.Cell) error {
+        if gs.Game.Variant != domain.Quantum {
+            return ErrNotQuantum
+        }
+        if gs.Game.Over {
+            // e.g. ended by an administrator
+            return domain.ErrGameOver
+        }
+        if err := gs.Quantum.Place(a, b); err != nil {
+            return err
+        }
+        gs.Game = gs.Quantum.Game()
+        s.loggerLocked(ctx).Debug("spooky mark placed", "game_id", id, "player_id", playerID, "seat", gs.SeatName(seat), "a", a, "b", b)
+        return nil
+    })
+}
+
+// Collapse resolves the pending cycle of a Quantum game by putting the mark
+// that closed it in square idx. It is the collapsing player's turn, so only
+// they may choose.
+func (s *Service) Collapse(ctx context.Context, id, playerID string, idx int) (*GameState, error) {
+    return s.move(ctx, id, playerID, func(gs *GameState, seat domain.Cell) error {
+        if gs.Game.Variant != domain.Quantum {
+            return ErrNotQuantum
+        }
+        if gs.Game.Over {
+            // e.g. ended by an administrator
+            return domain.ErrGameOver
+        }
+        if err := gs.Quantum.Collapse(idx); err != nil {
+            return err
+        }
+        gs.Game = gs.Quantum.Game()
+        s.loggerLocked(ctx).Debug("cycle collapsed", "game_id", id, "player_id", playerID, "seat", gs.SeatName(seat), "square", idx)
+        return nil
+    })
+}
 
+// move validates that playerID holds the seat to move in game id, lets apply
+// change the game, then updates timestamps and metrics and broadcasts.
+func (s *Service) move(ctx context.Context, id, playerID string, apply func(gs *GameState, seat domain.Cell) error) (*GameState, error) {
     s.mu.Lock()
     gs, ok := s.games[id]
     if !ok {
@@ -126,12 +520,8 @@ func (s *Service) Play(id, playerID string, r, c int) (*GameState, error) {
         return nil, ErrNotFound
     }
     // Validate player is seated
-    var seat domain.Cell
-    if gs.X == playerID {
-        seat = domain.X
-    } else if gs.O == playerID {
-        seat = domain.O
-    } else {
+    seat := gs.SeatOf(playerID)
+    if seat == domain.Empty {
         s.mu.Unlock()
         return nil, ErrNotAPlayer
     }
@@ -140,31 +530,51 @@ func (s *Service) Play(id, playerID string, r, c int) (*GameState, error) {
         s.mu.Unlock()
         return nil, ErrNotYourTurn
     }
-    // Apply move
-    if err := gs.Game.Play(r, c); err != nil {
+    if err := apply(gs, seat); err != nil {
         s.mu.Unlock()
         return nil, err
     }
     gs.Updated = time.Now()
+    s.metrics.movesPlayed.Inc()
+    if gs.Game.Over {
+        s.metrics.gamesFinished.WithLabelValues(outcomeLabel(gs.Game.Outcome)).Inc()
+        s.loggerLocked(ctx).Info("game finished", "game_id", id, "outcome", gs.Game.Outcome.String(), "moves", gs.Game.Moves)
+    }
 
     // Snapshot state and subscribers
-    cp = *gs
+    cp := *gs
     subs := s.copySubsLocked(id)
-    payload = s.render(cp)
+    payload := s.render(cp)
     s.mu.Unlock()
 
-    // Fan-out; drop slow subscribers by closing and marking for deletion
+    s.publish(id, subs, Event{Name: EventBoard, Data: payload})
+    return &cp, nil
+}
+
+// Presence reports who is connected to the game's event stream.
+func (s *Service) Presence(id string) (Presence, bool) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if _, ok := s.games[id]; !ok {
+        return Presence{}, false
+    }
+    return s.presenceLocked(id), true
+}
+
+// publish fans out an event; slow subscribers are dropped by closing and deleting them.
+func (s *Service) publish(id string, subs map[*subscriber]struct{}, ev Event) {
+    var toDrop []*subscriber
     for sub := range subs {
-        select {
-        case sub.ch <- payload:
-        default:
+        if !sub.offer(ev) {
             // drop slow subscriber
             sub.close()
             toDrop = append(toDrop, sub)
         }
     }
     if len(toDrop) > 0 {
+        s.metrics.droppedSubscribers.Add(float64(len(toDrop)))
         s.mu.Lock()
+        s.log.Warn("dropped slow subscribers", "game_id", id, "count", len(toDrop))
         for _, sub := range toDrop {
             if set, ok := s.subs[id]; ok {
                 delete(set, sub)
@@ -172,24 +582,32 @@ func (s *Service) Play(id, playerID string, r, c int) (*GameState, error) {
         }
         s.mu.Unlock()
     }
-    return &cp, nil
 }
 
-// Subscribe registers a subscriber for a game. Returns a channel and an unsubscribe func.
-func (s *Service) Subscribe(ctx context.Context, id string) (<-chan []byte, func()) {
+// Subscribe registers a subscriber for a game on behalf of playerID (empty for anonymous).
+// Returns a channel of events and an unsubscribe func. Presence is broadcast on connect and disconnect.
+// The channel is closed at once when the game does not exist (e.g. it was
+// deleted) or the service is shutting down.
+func (s *Service) Subscribe(ctx context.Context, id, playerID string) (<-chan Event, func()) {
     s.mu.Lock()
-    defer s.mu.Unlock()
-    if _, ok := s.games[id]; !ok {
-        // create lazily to allow subscriptions before CreateGame in some flows
-        s.games[id] = &GameState{ID: id, Game: domain.New(), Created: time.Now(), Updated: time.Now()}
+    if _, ok := s.games[id]; !ok || s.closed {
+        s.mu.Unlock()
+        ch := make(chan Event)
+        close(ch)
+        return ch, func() {}
     }
     set := s.subs[id]
     if set == nil {
         set = make(map[*subscriber]struct{})
         s.subs[id] = set
     }
-    sub := &subscriber{ch: make(chan []byte, 1)}
+    sub := &subscriber{ch: make(chan Event, subscriberBuffer), playerID: playerID}
     set[sub] = struct{}{}
+    s.trackLocked(id, playerID, 1)
+    subs := s.copySubsLocked(id)
+    ev := Event{Name: EventPresence, Data: s.renderPresence(s.presenceLocked(id))}
+    s.mu.Unlock()
+    s.publish(id, subs, ev)
 
     unsubOnce := &sync.Once{}
     unsub := func() {
@@ -198,8 +616,12 @@ func (s *Service) Subscribe(ctx context.Context, id string) (<-chan []byte, func
             if set, ok := s.subs[id]; ok {
                 delete(set, sub)
             }
+            s.trackLocked(id, playerID, -1)
+            subs := s.copySubsLocked(id)
+            ev := Event{Name: EventPresence, Data: s.renderPresence(s.presenceLocked(id))}
             s.mu.Unlock()
             sub.close()
+            s.publish(id, subs, ev)
         })
     }
     go func() {
@@ -209,6 +631,45 @@ func (s *Service) Subscribe(ctx context.Context, id string) (<-chan []byte, func
     return sub.ch, unsub
 }
 
+// trackLocked adjusts the connection count of playerID in game id by delta.
+func (s *Service) trackLocked(id, playerID string, delta int) {
+    conns := s.online[id]
+    if conns == nil {
+        conns = make(map[string]int)
+        s.online[id] = conns
+    }
+    conns[playerID] += delta
+    if conns[playerID] <= 0 {
+        delete(conns, playerID)
+    }
+    if len(conns) == 0 {
+        delete(s.online, id)
+    }
+}
+
+// presenceLocked derives presence from connected player IDs and seats.
+// Anonymous connections each count as a spectator.
+func (s *Service) presenceLocked(id string) Presence {
+    p := Presence{ID: id, XSeat: "X", OSeat: "O"}
+    gs := s.games[id]
+    if gs != nil {
+        p.XSeat, p.OSeat = gs.SeatName(domain.X), gs.SeatName(domain.O)
+    }
+    for pid, n := range s.online[id] {
+        switch {
+        case pid == "":
+            p.Spectators += n
+        case gs != nil && gs.SeatOf(pid) == domain.X:
+            p.XOnline = true
+        case gs != nil && gs.SeatOf(pid) == domain.O:
+            p.OOnline = true
+        default:
+            p.Spectators++
+        }
+    }
+    return p
+}
+
 func (s *Service) copySubsLocked(id string) map[*subscriber]struct{} {
     out := make(map[*subscriber]struct{})
     if set, ok := s.subs[id]; ok {
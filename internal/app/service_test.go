@@ -15,7 +15,7 @@ func testRenderer(gs GameState) []byte { return []byte(fmt.Sprintf("moves=%d", g
 
 func TestCreateAndGet(t *testing.T) {
     s := NewServiceWithRenderer(testRenderer)
-    gs, err := s.CreateGame()
+    gs, err := s.CreateGame(context.Background())
     if err != nil {
         t.Fatalf("CreateGame error: %v", err)
     }
@@ -36,45 +36,71 @@ func TestCreateAndGet(t *testing.T) {
 
 func TestJoinSeatsAndRejoin(t *testing.T) {
     s := NewServiceWithRenderer(testRenderer)
-    gs, _ := s.CreateGame()
+    gs, _ := s.CreateGame(context.Background())
     p1, p2, p3 := "p1", "p2", "p3"
 
-    side, _, err := s.Join(gs.ID, p1)
+    side, _, err := s.Join(context.Background(), gs.ID, p1)
     if err != nil || side != domain.X {
         t.Fatalf("p1 should claim X, got %v, err=%v", side, err)
     }
-    side, _, err = s.Join(gs.ID, p2)
+    side, _, err = s.Join(context.Background(), gs.ID, p2)
     if err != nil || side != domain.O {
         t.Fatalf("p2 should claim O, got %v, err=%v", side, err)
     }
-    side, _, err = s.Join(gs.ID, p1)
+    side, _, err = s.Join(context.Background(), gs.ID, p1)
     if err != nil || side != domain.X {
         t.Fatalf("p1 rejoin should keep X, got %v, err=%v", side, err)
     }
-    side, _, err = s.Join(gs.ID, p3)
+    side, _, err = s.Join(context.Background(), gs.ID, p3)
     if err != nil || side != domain.Empty {
         t.Fatalf("p3 should spectate (Empty), got %v, err=%v", side, err)
     }
 }
 
+func TestJoinKeepsCreatorSideAndFirstMover(t *testing.T) {
+    s := NewServiceWithRenderer(testRenderer)
+    gs, _ := s.CreateGameWithOptions(context.Background(), GameOptions{First: domain.O, Creator: "p1", CreatorSide: domain.O})
+    if gs.Game.Turn != domain.O || gs.Game.Start().Turn != domain.O {
+        t.Fatalf("expected O to move first, got turn %v", gs.Game.Turn)
+    }
+    // Someone else arriving first must not take the creator's seat.
+    side, _, err := s.Join(context.Background(), gs.ID, "p2")
+    if err != nil || side != domain.X {
+        t.Fatalf("p2 should get X, got %v, err=%v", side, err)
+    }
+    if side, _, _ := s.Join(context.Background(), gs.ID, "p3"); side != domain.Empty {
+        t.Fatalf("p3 should spectate while O is kept, got %v", side)
+    }
+    side, _, err = s.Join(context.Background(), gs.ID, "p1")
+    if err != nil || side != domain.O {
+        t.Fatalf("creator should get O, got %v, err=%v", side, err)
+    }
+    if _, err := s.PlayMark(context.Background(), gs.ID, "p2", 0, 0, domain.X); !errors.Is(err, ErrNotYourTurn) {
+        t.Fatalf("expected X to wait for O, got %v", err)
+    }
+    if _, err := s.PlayMark(context.Background(), gs.ID, "p1", 0, 0, domain.O); err != nil {
+        t.Fatalf("O should open the game: %v", err)
+    }
+}
+
 func TestPlayEnforcesTurnAndSpectatorBlocked(t *testing.T) {
     s := NewServiceWithRenderer(testRenderer)
-    gs, _ := s.CreateGame()
+    gs, _ := s.CreateGame(context.Background())
     p1, p2, p3 := "p1", "p2", "p3"
-    s.Join(gs.ID, p1) // X
-    s.Join(gs.ID, p2) // O
-    s.Join(gs.ID, p3) // spectator
+    s.Join(context.Background(), gs.ID, p1) // X
+    s.Join(context.Background(), gs.ID, p2) // O
+    s.Join(context.Background(), gs.ID, p3) // spectator
 
     // O cannot play first
-    if _, err := s.Play(gs.ID, p2, 0, 0); !errors.Is(err, ErrNotYourTurn) {
+    if _, err := s.Play(context.Background(), gs.ID, p2, 0, 0); !errors.Is(err, ErrNotYourTurn) {
         t.Fatalf("expected ErrNotYourTurn, got %v", err)
     }
     // spectator cannot play
-    if _, err := s.Play(gs.ID, p3, 0, 0); !errors.Is(err, ErrNotAPlayer) {
+    if _, err := s.Play(context.Background(), gs.ID, p3, 0, 0); !errors.Is(err, ErrNotAPlayer) {
         t.Fatalf("expected ErrNotAPlayer, got %v", err)
     }
     // X plays
-    st, err := s.Play(gs.ID, p1, 0, 0)
+    st, err := s.Play(context.Background(), gs.ID, p1, 0, 0)
     if err != nil {
         t.Fatalf("X play failed: %v", err)
     }
@@ -82,73 +108,360 @@ func TestPlayEnforcesTurnAndSpectatorBlocked(t *testing.T) {
         t.Fatalf("unexpected state after X move: turn=%v moves=%d cell0=%v", st.Game.Turn, st.Game.Moves, st.Game.Board[0])
     }
     // X cannot play again
-    if _, err := s.Play(gs.ID, p1, 1, 1); !errors.Is(err, ErrNotYourTurn) {
+    if _, err := s.Play(context.Background(), gs.ID, p1, 1, 1); !errors.Is(err, ErrNotYourTurn) {
         t.Fatalf("expected ErrNotYourTurn for X again, got %v", err)
     }
 }
 
 func TestSubscribeAndBroadcast(t *testing.T) {
     s := NewServiceWithRenderer(testRenderer)
-    gs, _ := s.CreateGame()
+    gs, _ := s.CreateGame(context.Background())
     p1, p2 := "p1", "p2"
-    s.Join(gs.ID, p1)
-    s.Join(gs.ID, p2)
+    s.Join(context.Background(), gs.ID, p1)
+    s.Join(context.Background(), gs.ID, p2)
 
     ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
     defer cancel()
-    ch, unsub := s.Subscribe(ctx, gs.ID)
+    ch, unsub := s.Subscribe(ctx, gs.ID, "")
     defer unsub()
 
     // Trigger an update: X plays
-    if _, err := s.Play(gs.ID, p1, 0, 0); err != nil {
+    if _, err := s.Play(context.Background(), gs.ID, p1, 0, 0); err != nil {
         t.Fatalf("play failed: %v", err)
     }
 
-    select {
-    case b, ok := <-ch:
-        if !ok { t.Fatalf("channel closed unexpectedly") }
-        if string(b) != "moves=1" {
-            t.Fatalf("unexpected broadcast payload: %q", string(b))
+    for {
+        select {
+        case ev, ok := <-ch:
+            if !ok { t.Fatalf("channel closed unexpectedly") }
+            if ev.Name != EventBoard {
+                continue
+            }
+            if string(ev.Data) != "moves=1" {
+                t.Fatalf("unexpected broadcast payload: %q", string(ev.Data))
+            }
+            return
+        case <-ctx.Done():
+            t.Fatalf("timed out waiting for broadcast")
         }
-    case <-ctx.Done():
-        t.Fatalf("timed out waiting for broadcast")
     }
 }
 
 func TestDropSlowSubscriber(t *testing.T) {
     s := NewServiceWithRenderer(testRenderer)
-    gs, _ := s.CreateGame()
+    gs, _ := s.CreateGame(context.Background())
     p1, p2 := "p1", "p2"
-    s.Join(gs.ID, p1)
-    s.Join(gs.ID, p2)
+    s.Join(context.Background(), gs.ID, p1)
+    s.Join(context.Background(), gs.ID, p2)
 
     // Slow subscriber: never read
     ctxSlow, cancelSlow := context.WithCancel(context.Background())
-    slowCh, _ := s.Subscribe(ctxSlow, gs.ID)
-    _ = slowCh // intentionally not read
+    defer cancelSlow()
+    slowCh, _ := s.Subscribe(ctxSlow, gs.ID, "")
 
-    // Fast subscriber: will read
+    // Fast subscriber: drained after every publish
     ctxFast, cancelFast := context.WithTimeout(context.Background(), time.Second*2)
     defer cancelFast()
-    fastCh, unsubFast := s.Subscribe(ctxFast, gs.ID)
+    fastCh, unsubFast := s.Subscribe(ctxFast, gs.ID, "")
     defer unsubFast()
+    boards := 0
+    drain := func() {
+        for {
+            select {
+            case ev, ok := <-fastCh:
+                if !ok {
+                    t.Fatalf("fast subscriber was dropped")
+                }
+                if ev.Name == EventBoard {
+                    boards++
+                }
+            default:
+                return
+            }
+        }
+    }
 
-    // Two quick updates; slow should be dropped to avoid blocking fast
-    if _, err := s.Play(gs.ID, p1, 0, 0); err != nil { t.Fatalf("play1: %v", err) }
-    if _, err := s.Play(gs.ID, p2, 1, 1); err != nil { t.Fatalf("play2: %v", err) }
+    // More events than the slow subscriber can queue: presence changes from
+    // short-lived connections, then two moves.
+    for i := 0; i < subscriberBuffer; i++ {
+        ctx, cancel := context.WithCancel(context.Background())
+        _, unsub := s.Subscribe(ctx, gs.ID, "")
+        drain()
+        unsub()
+        cancel()
+        drain()
+    }
+    if _, err := s.Play(context.Background(), gs.ID, p1, 0, 0); err != nil { t.Fatalf("play1: %v", err) }
+    drain()
+    if _, err := s.Play(context.Background(), gs.ID, p2, 1, 1); err != nil { t.Fatalf("play2: %v", err) }
+    drain()
+    if boards != 2 {
+        t.Fatalf("fast subscriber got %d board updates, want 2", boards)
+    }
 
-    // Fast still receives the latest
-    got := 0
-    for got < 2 {
+    // The slow subscriber was dropped: after its queued events its channel is closed.
+    queued := 0
+    for {
         select {
-        case <-fastCh:
-            got++
-        case <-ctxFast.Done():
-            t.Fatalf("fast subscriber did not receive updates in time")
+        case _, ok := <-slowCh:
+            if !ok {
+                if queued > subscriberBuffer {
+                    t.Fatalf("slow subscriber received %d events, buffer is %d", queued, subscriberBuffer)
+                }
+                return
+            }
+            queued++
+        case <-time.After(time.Second):
+            t.Fatalf("slow subscriber was not dropped")
+        }
+    }
+}
+
+
+func TestPresenceTracksConnections(t *testing.T) {
+    s := NewServiceWithRenderer(testRenderer)
+    s.SetPresenceRenderer(func(p Presence) []byte {
+        return []byte(fmt.Sprintf("x=%v o=%v spectators=%d", p.XOnline, p.OOnline, p.Spectators))
+    })
+    gs, _ := s.CreateGame(context.Background())
+    s.Join(context.Background(), gs.ID, "p1") // X
+    s.Join(context.Background(), gs.ID, "p2") // O
+
+    ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
+    defer cancel()
+    ch, unsub := s.Subscribe(ctx, gs.ID, "p1")
+    defer unsub()
+    expectPresence := func(want string) {
+        t.Helper()
+        for {
+            select {
+            case ev := <-ch:
+                if ev.Name != EventPresence {
+                    continue
+                }
+                if string(ev.Data) != want {
+                    t.Fatalf("presence payload = %q, want %q", ev.Data, want)
+                }
+                return
+            case <-ctx.Done():
+                t.Fatalf("timed out waiting for presence %q", want)
+            }
         }
     }
+    expectPresence("x=true o=false spectators=0")
 
-    // Slow subscriber should be dropped; cancel context and ensure channel is closed promptly
-    cancelSlow()
+    _, unsubSpec := s.Subscribe(ctx, gs.ID, "p3")
+    expectPresence("x=true o=false spectators=1")
+
+    p, ok := s.Presence(gs.ID)
+    if !ok || !p.XOnline || p.OOnline || p.Spectators != 1 {
+        t.Fatalf("unexpected presence: %+v", p)
+    }
+
+    unsubSpec()
+    expectPresence("x=true o=false spectators=0")
+}
+
+func TestJoinPromotesConnectedSpectator(t *testing.T) {
+    s := NewServiceWithRenderer(testRenderer)
+    gs, _ := s.CreateGame(context.Background())
+    s.Join(context.Background(), gs.ID, "p1")
+
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+    _, unsub := s.Subscribe(ctx, gs.ID, "p2")
+    defer unsub()
+    if p, _ := s.Presence(gs.ID); p.OOnline || p.Spectators != 1 {
+        t.Fatalf("expected p2 to watch as spectator, got %+v", p)
+    }
+    s.Join(context.Background(), gs.ID, "p2")
+    if p, _ := s.Presence(gs.ID); !p.OOnline || p.Spectators != 0 {
+        t.Fatalf("expected p2 online as O, got %+v", p)
+    }
+}
+
+func TestGameStateReportsOutcomeAndLine(t *testing.T) {
+    s := NewServiceWithRenderer(testRenderer)
+    gs, _ := s.CreateGame(context.Background())
+    s.Join(context.Background(), gs.ID, "p1")
+    s.Join(context.Background(), gs.ID, "p2")
+    if gs.Outcome() != domain.InProgress || gs.WinningLine() != nil {
+        t.Fatalf("new game should be in progress without a line")
+    }
+    var st *GameState
+    for i, m := range [][2]int{{1, 0}, {0, 0}, {1, 1}, {0, 1}, {2, 2}, {0, 2}} {
+        pid := "p1"
+        if i%2 == 1 {
+            pid = "p2"
+        }
+        var err error
+        if st, err = s.Play(context.Background(), gs.ID, pid, m[0], m[1]); err != nil {
+            t.Fatalf("move %d: %v", i, err)
+        }
+    }
+    if st.Outcome() != domain.OWon {
+        t.Fatalf("expected O to win, got %v", st.Outcome())
+    }
+    if ln := st.WinningLine(); len(ln) != 3 || ln[0] != 0 || ln[1] != 1 || ln[2] != 2 {
+        t.Fatalf("expected top row as winning line, got %v", ln)
+    }
 }
 
+func TestCreateGameWithVariant(t *testing.T) {
+    s := NewServiceWithRenderer(testRenderer)
+    gs, err := s.CreateGameWithOptions(context.Background(), GameOptions{Variant: domain.Misere})
+    if err != nil {
+        t.Fatalf("CreateGameWithOptions error: %v", err)
+    }
+    s.Join(context.Background(), gs.ID, "x")
+    s.Join(context.Background(), gs.ID, "o")
+    for i, m := range [][2]int{{0, 0}, {1, 0}, {0, 1}, {1, 1}, {0, 2}} {
+        pid := "x"
+        if i%2 == 1 {
+            pid = "o"
+        }
+        if _, err := s.Play(context.Background(), gs.ID, pid, m[0], m[1]); err != nil {
+            t.Fatalf("move %d: %v", i, err)
+        }
+    }
+    got, _ := s.Get(gs.ID)
+    if got.Game.Variant != domain.Misere || got.Outcome() != domain.OWon {
+        t.Fatalf("expected misère loss for X, got %+v", got.Game)
+    }
+}
+
+func TestNumericalGameCarriesRemainingNumbers(t *testing.T) {
+    s := NewServiceWithRenderer(testRenderer)
+    gs, _ := s.CreateGameWithOptions(context.Background(), GameOptions{Variant: domain.Numerical})
+    s.Join(context.Background(), gs.ID, "odd")
+    s.Join(context.Background(), gs.ID, "even")
+    if _, err := s.Play(context.Background(), gs.ID, "odd", 1, 1); !errors.Is(err, domain.ErrInvalidMark) {
+        t.Fatalf("expected a number to be required, got %v", err)
+    }
+    got, err := s.PlayMark(context.Background(), gs.ID, "odd", 1, 1, domain.NumberCell(5))
+    if err != nil {
+        t.Fatalf("PlayMark: %v", err)
+    }
+    if odd := got.RemainingNumbers(domain.X); len(odd) != 4 || odd[1] != 3 || odd[2] != 7 {
+        t.Fatalf("unexpected odd numbers left %v", odd)
+    }
+    if even := got.RemainingNumbers(domain.O); len(even) != 4 {
+        t.Fatalf("unexpected even numbers left %v", even)
+    }
+    std, _ := s.CreateGame(context.Background())
+    if std.RemainingNumbers(domain.X) != nil {
+        t.Fatalf("standard games have no numbers")
+    }
+}
+
+func TestNumericalGameWithOFirstPlaysOut(t *testing.T) {
+    s := NewServiceWithRenderer(testRenderer)
+    ctx := context.Background()
+    gs, _ := s.CreateGameWithOptions(ctx, GameOptions{Variant: domain.Numerical, First: domain.O})
+    s.Join(ctx, gs.ID, "x")
+    s.Join(ctx, gs.ID, "o")
+    if odd := gs.RemainingNumbers(domain.O); len(odd) != 5 {
+        t.Fatalf("expected the first mover to hold the five odd numbers, got %v", odd)
+    }
+    // 1-9 in turn, laid out so that no line sums to 15.
+    for n, idx := range []int{0, 1, 2, 3, 4, 6, 5, 8, 7} {
+        pid := "o"
+        if n%2 == 1 {
+            pid = "x"
+        }
+        got, err := s.PlayMark(ctx, gs.ID, pid, idx/3, idx%3, domain.NumberCell(n+1))
+        if err != nil {
+            t.Fatalf("move %d: %v", n+1, err)
+        }
+        gs = got
+    }
+    if !gs.Game.Over || gs.Outcome() != domain.Draw {
+        t.Fatalf("expected a drawn game, got %+v", gs.Game)
+    }
+    if gs.RemainingNumbers(domain.O) != nil || gs.RemainingNumbers(domain.X) != nil {
+        t.Fatalf("expected every number placed")
+    }
+}
+
+func TestOrderChaosSeatsAreRoles(t *testing.T) {
+    s := NewServiceWithRenderer(testRenderer)
+    gs, _ := s.CreateGameWithOptions(context.Background(), GameOptions{Variant: domain.OrderChaos})
+    s.Join(context.Background(), gs.ID, "order")
+    s.Join(context.Background(), gs.ID, "chaos")
+    got, _ := s.Get(gs.ID)
+    if side := got.SeatOf("chaos"); side != domain.Chaos || got.SeatName(side) != "Chaos" {
+        t.Fatalf("expected the second seat to be Chaos, got %v %q", side, got.SeatName(side))
+    }
+    if got.Seats != [2]Seat{{RoleOrder, "order"}, {RoleChaos, "chaos"}} {
+        t.Fatalf("expected seats stored by role, got %+v", got.Seats)
+    }
+    if got.Player(RoleChaos) != "chaos" || got.Player(RoleX) != "" || got.RoleOf(domain.X) != RoleOrder || got.SideOf(RoleChaos) != domain.O {
+        t.Fatalf("expected seats to be found by role, got %+v", got.Seats)
+    }
+    if got.SeatOf("watcher") != domain.Empty || got.SeatName(domain.Empty) != "spectator" {
+        t.Fatalf("expected spectators to have no seat")
+    }
+    // Chaos may place either mark too.
+    if _, err := s.PlayMark(context.Background(), gs.ID, "order", 0, 0, domain.O); err != nil {
+        t.Fatalf("Order placing O: %v", err)
+    }
+    if _, err := s.PlayMark(context.Background(), gs.ID, "chaos", 0, 1, domain.X); err != nil {
+        t.Fatalf("Chaos placing X: %v", err)
+    }
+    msg, err := s.PostChat(context.Background(), gs.ID, "chaos", "no lines for you")
+    if err != nil || msg.SeatName != "Chaos" {
+        t.Fatalf("expected the chat to name the Chaos seat, got %+v, %v", msg, err)
+    }
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+    _, unsub := s.Subscribe(ctx, gs.ID, "order")
+    defer unsub()
+    if p, _ := s.Presence(gs.ID); p.XSeat != "Order" || p.OSeat != "Chaos" || !p.XOnline {
+        t.Fatalf("expected role names in presence, got %+v", p)
+    }
+}
+
+func TestQuantumGameSpookyMovesAndCollapse(t *testing.T) {
+    s := NewServiceWithRenderer(testRenderer)
+    gs, _ := s.CreateGameWithOptions(context.Background(), GameOptions{Variant: domain.Quantum})
+    s.Join(context.Background(), gs.ID, "x")
+    s.Join(context.Background(), gs.ID, "o")
+    if _, err := s.Play(context.Background(), gs.ID, "x", 0, 0); !errors.Is(err, domain.ErrInvalidMark) {
+        t.Fatalf("expected classical moves to be refused, got %v", err)
+    }
+    if _, err := s.PlaceSpooky(context.Background(), gs.ID, "x", 0, 4); err != nil {
+        t.Fatalf("PlaceSpooky: %v", err)
+    }
+    if _, err := s.PlaceSpooky(context.Background(), gs.ID, "x", 1, 2); !errors.Is(err, ErrNotYourTurn) {
+        t.Fatalf("expected ErrNotYourTurn, got %v", err)
+    }
+    got, err := s.PlaceSpooky(context.Background(), gs.ID, "o", 4, 0)
+    if err != nil {
+        t.Fatalf("PlaceSpooky: %v", err)
+    }
+    if got.Quantum.Pending != 2 || got.Game.Turn != domain.X {
+        t.Fatalf("expected X to choose the collapse, got %+v", got.Quantum)
+    }
+    // O closed the cycle, so O cannot choose.
+    if _, err := s.Collapse(context.Background(), gs.ID, "o", 4); !errors.Is(err, ErrNotYourTurn) {
+        t.Fatalf("expected ErrNotYourTurn, got %v", err)
+    }
+    got, err = s.Collapse(context.Background(), gs.ID, "x", 4)
+    if err != nil {
+        t.Fatalf("Collapse: %v", err)
+    }
+    if got.Game.Board[4] != domain.O || got.Game.Board[0] != domain.X || got.Game.Moves != 2 {
+        t.Fatalf("expected the classical board to follow the collapse, got %+v", got.Game)
+    }
+    std, _ := s.CreateGame(context.Background())
+    s.Join(context.Background(), std.ID, "x")
+    if _, err := s.PlaceSpooky(context.Background(), std.ID, "x", 0, 1); !errors.Is(err, ErrNotQuantum) {
+        t.Fatalf("expected ErrNotQuantum, got %v", err)
+    }
+    if _, err := s.EndGame(context.Background(), gs.ID); err != nil {
+        t.Fatalf("EndGame: %v", err)
+    }
+    if _, err := s.PlaceSpooky(context.Background(), gs.ID, "x", 1, 2); !errors.Is(err, domain.ErrGameOver) {
+        t.Fatalf("expected ErrGameOver after the game was ended, got %v", err)
+    }
+}
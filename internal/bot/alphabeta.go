@@ -0,0 +1,127 @@
+package bot
+
+import (
+    "sort"
+
+    "github.com/jaminalder/codex-tic-tac-toe/internal/domain"
+)
+
+// DefaultDepth is the search depth AlphaBeta uses when Depth is not set.
+const DefaultDepth = 3
+
+// winScore is the value of a won position before the quicker-win bonus; it
+// exceeds any heuristic score.
+const winScore = 1 << 20
+
+// lineWeights[k] is the heuristic value of a line holding k marks of one
+// side and none of the other.
+var lineWeights = [...]int{0, 1, 8, 64, 512, 4096}
+
+// AlphaBeta searches Depth plies ahead with alpha-beta pruning and scores the
+// positions it stops at by counting the lines of the board each side can
+// still complete, weighted by how many of their marks each already holds.
+// Unlike Solver it never needs to reach the end of the game, so it plays the
+// large boards such as Qubic. The heuristic assumes a side wins by
+// completing a line with its own mark. The zero value is ready to use.
+type AlphaBeta struct {
+    Depth int
+}
+
+// BestMove returns the move with the best search score, preferring moves on
+// cells that lie on more lines when scores tie.
+func (a AlphaBeta) BestMove(g domain.Game) (domain.Move, error) {
+    if g.Over {
+        return domain.Move{}, ErrGameOver
+    }
+    depth := a.Depth
+    if depth <= 0 {
+        depth = DefaultDepth
+    }
+    g.History = nil // the search never needs it; skip the per-move copy
+    alpha, beta := -2*winScore, 2*winScore
+    var best domain.Move
+    for i, m := range orderMoves(g) {
+        v := -a.search(playCopy(g, m), depth-1, -beta, -alpha)
+        if i == 0 || v > alpha {
+            best, alpha = m, v
+        }
+    }
+    return best, nil
+}
+
+// search returns the negamax value of g for the side to move.
+func (a AlphaBeta) search(g domain.Game, depth, alpha, beta int) int {
+    if g.Over {
+        if g.Winner == domain.Empty {
+            return 0
+        }
+        // Only the last mover can have completed a line, so a won game is
+        // lost for the side whose turn it would be; sooner losses score lower.
+        return -winScore - depth
+    }
+    if depth == 0 {
+        return evaluate(g)
+    }
+    for _, m := range orderMoves(g) {
+        v := -a.search(playCopy(g, m), depth-1, -beta, -alpha)
+        if v > alpha {
+            alpha = v
+        }
+        if alpha >= beta {
+            break
+        }
+    }
+    return alpha
+}
+
+// playCopy returns g after m.
+func playCopy(g domain.Game, m domain.Move) domain.Game {
+    _ = g.PlayAt(m.Index, m.Mark)
+    g.History = nil
+    return g
+}
+
+// evaluate scores an unfinished g for the side to move.
+func evaluate(g domain.Game) int {
+    score := 0
+    for _, ln := range g.Geometry().Lines {
+        own, other := 0, 0
+        for _, idx := range ln {
+            switch g.Board[idx] {
+            case g.Turn:
+                own++
+            case domain.Opponent(g.Turn):
+                other++
+            }
+        }
+        switch {
+        case other == 0:
+            score += lineWeights[own]
+        case own == 0:
+            score -= lineWeights[other]
+        }
+    }
+    return score
+}
+
+// orderMoves returns g's legal moves with the cells on the most lines first,
+// which lets alpha-beta prune earlier.
+func orderMoves(g domain.Game) []domain.Move {
+    moves := g.LegalMoves()
+    weight := lineCounts(g.Geometry())
+    sort.SliceStable(moves, func(i, j int) bool {
+        return weight[moves[i].Index] > weight[moves[j].Index]
+    })
+    return moves
+}
+
+// lineCounts returns how many lines of geo pass through each cell.
+func lineCounts(geo *domain.Geometry) []int {
+    counts := make([]int, geo.Cells())
+    for _, ln := range geo.Lines {
+        for _, idx := range ln {
+            counts[idx]++
+        }
+    }
+    return counts
+}
@@ -0,0 +1,74 @@
+package bot
+
+import (
+    "math/rand"
+    "testing"
+
+    "github.com/jaminalder/codex-tic-tac-toe/internal/domain"
+)
+
+func TestAlphaBetaTakesWinAndBlocksInQubic(t *testing.T) {
+    a := AlphaBeta{}
+    // X holds three of the first layer's top row and wins at d1.
+    g := position(t, "XXX./OO../..../....|..../..O./..../....|..../..../..../....|..../..../..../.... x", domain.Qubic)
+    if m, err := a.BestMove(g); err != nil || m.Index != 3 {
+        t.Fatalf("expected X to win at index 3, got %+v, %v", m, err)
+    }
+    // O must stop X's pillar through b2 on the first three layers.
+    g = position(t, "O.../.X../..../....|..../.X../..../....|..../.X../..../....|..../..../..../...O o", domain.Qubic)
+    if m, err := a.BestMove(g); err != nil || m.Index != domain.Cube4.Index(3, 1, 1) {
+        t.Fatalf("expected O to block at layer 4 b2, got %+v, %v", m, err)
+    }
+}
+
+func TestAlphaBetaPlaysStandard(t *testing.T) {
+    g := position(t, "XX./OO./... x", domain.Standard)
+    if m, err := (AlphaBeta{}).BestMove(g); err != nil || m.Index != 2 {
+        t.Fatalf("expected X to win at c1, got %+v, %v", m, err)
+    }
+    g.Over = true
+    if _, err := (AlphaBeta{}).BestMove(g); err != ErrGameOver {
+        t.Fatalf("expected ErrGameOver, got %v", err)
+    }
+}
+
+func TestAlphaBetaBeatsRandomPlayInQubic(t *testing.T) {
+    a := AlphaBeta{Depth: 2}
+    rng := rand.New(rand.NewSource(1))
+    for i := 0; i < 6; i++ {
+        botSide := domain.X
+        if i%2 == 1 {
+            botSide = domain.O
+        }
+        g := domain.NewVariant(domain.Qubic)
+        for !g.Over {
+            var m domain.Move
+            if g.Turn == botSide {
+                var err error
+                if m, err = a.BestMove(g); err != nil {
+                    t.Fatalf("BestMove: %v", err)
+                }
+            } else {
+                legal := g.LegalMoves()
+                m = legal[rng.Intn(len(legal))]
+            }
+            if err := g.PlayAt(m.Index, m.Mark); err != nil {
+                t.Fatalf("play: %v", err)
+            }
+        }
+        if g.Winner != botSide {
+            t.Fatalf("bot as %v did not win: %v after %d moves", botSide, g.Outcome, g.Moves)
+        }
+    }
+}
+
+func BenchmarkAlphaBetaQubicOpening(b *testing.B) {
+    g := domain.NewVariant(domain.Qubic)
+    for _, idx := range []int{0, 21, 42, 5} {
+        _ = g.PlayAt(idx, g.Turn)
+    }
+    a := AlphaBeta{}
+    for i := 0; i < b.N; i++ {
+        _, _ = a.BestMove(g)
+    }
+}
@@ -0,0 +1,36 @@
+package bot
+
+import (
+    "errors"
+
+    "github.com/jaminalder/codex-tic-tac-toe/internal/domain"
+)
+
+// ErrUnsupported is returned by For for variants no bot can play.
+var ErrUnsupported = errors.New("no bot plays this variant")
+
+// Mover picks a move for the side to move; Solver and AlphaBeta are Movers,
+// and app.Service.PlayBot plays any Mover's moves in a game.
+type Mover interface {
+    BestMove(g domain.Game) (domain.Move, error)
+}
+
+// shared is the Solver handed out by For. Its cache only grows, so sharing
+// it spares every game the cost of the first searches, which on an empty
+// Numerical board take seconds.
+var shared = NewSolver()
+
+// For returns the strongest bot for a game of v: the shared Solver on the
+// 3x3 board, where it plays perfectly under every variant's rules, and
+// AlphaBeta on the larger boards. Quantum games are not supported, nor is
+// Order and Chaos, whose roles AlphaBeta's line counting does not fit.
+func For(v domain.Variant) (Mover, error) {
+    switch {
+    case v == domain.Quantum, v == domain.OrderChaos:
+        return nil, ErrUnsupported
+    case v.Geometry().Cells() == 9:
+        return shared, nil
+    default:
+        return AlphaBeta{}, nil
+    }
+}
@@ -0,0 +1,118 @@
+// Package bot provides computer opponents for the domain game.
+package bot
+
+import (
+    "errors"
+    "sync"
+
+    "github.com/jaminalder/codex-tic-tac-toe/internal/domain"
+)
+
+// ErrGameOver is returned when asked to move in a finished game.
+var ErrGameOver = errors.New("game is over")
+
+// MoveScore is the value of a legal move for the side to move.
+type MoveScore struct {
+    domain.Move
+    Score int
+}
+
+// Solver plays perfectly by searching the whole game tree with negamax,
+// under whatever rules the game's variant uses. Scores are from the side to
+// move's point of view: positive for a forced win (larger when quicker),
+// negative for a forced loss, zero for a draw. Results are cached per
+// symmetry class, so one Solver can be shared; it is safe for concurrent use.
+// Searching to the end is only feasible on the 3x3 board; use AlphaBeta for
+// Qubic.
+type Solver struct {
+    mu    sync.Mutex
+    table map[key]int
+}
+
+// key identifies a position up to symmetry.
+type key struct {
+    board   domain.Board
+    turn    domain.Cell
+    variant domain.Variant
+}
+
+// NewSolver returns a Solver with an empty cache.
+func NewSolver() *Solver {
+    return &Solver{table: make(map[key]int)}
+}
+
+// Evaluate scores every legal move of g, in the order of g.LegalMoves.
+func (s *Solver) Evaluate(g domain.Game) ([]MoveScore, error) {
+    if g.Over {
+        return nil, ErrGameOver
+    }
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    var out []MoveScore
+    for _, m := range g.LegalMoves() {
+        out = append(out, MoveScore{Move: m, Score: s.play(g, m)})
+    }
+    return out, nil
+}
+
+// BestMove returns the first legal move with the best score.
+func (s *Solver) BestMove(g domain.Game) (domain.Move, error) {
+    moves, err := s.Evaluate(g)
+    if err != nil {
+        return domain.Move{}, err
+    }
+    best := moves[0]
+    for _, m := range moves[1:] {
+        if m.Score > best.Score {
+            best = m
+        }
+    }
+    return best.Move, nil
+}
+
+// Score returns the value of g for the side to move; finished games score 0.
+func (s *Solver) Score(g domain.Game) int {
+    if g.Over {
+        return 0
+    }
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return s.negamax(g)
+}
+
+// negamax returns the value of an unfinished g for the side to move.
+func (s *Solver) negamax(g domain.Game) int {
+    canon, _ := domain.Canonical(g.Board)
+    k := key{board: canon, turn: g.Turn, variant: g.Variant}
+    if v, ok := s.table[k]; ok {
+        return v
+    }
+    best := -100
+    for _, m := range g.LegalMoves() {
+        if v := s.play(g, m); v > best {
+            best = v
+        }
+    }
+    s.table[k] = best
+    return best
+}
+
+// play returns the value of m for the side to move in g.
+func (s *Solver) play(g domain.Game, m domain.Move) int {
+    mover := g.Turn
+    g.History = nil // the search never needs it; skip the per-move copy
+    _ = g.PlayAt(m.Index, m.Mark)
+    if !g.Over {
+        return -s.negamax(g)
+    }
+    // Quicker results weigh more: a win with more empty squares left scores higher.
+    v := 10 - g.Moves
+    switch g.Winner {
+    case mover:
+        return v
+    case domain.Empty:
+        return 0
+    default:
+        return -v
+    }
+}
@@ -0,0 +1,149 @@
+package bot
+
+import (
+    "math/rand"
+    "testing"
+
+    "github.com/jaminalder/codex-tic-tac-toe/internal/domain"
+)
+
+func position(t *testing.T, s string, v domain.Variant) domain.Game {
+    t.Helper()
+    g, err := domain.ParsePositionVariant(s, v)
+    if err != nil {
+        t.Fatalf("ParsePositionVariant(%q): %v", s, err)
+    }
+    return g
+}
+
+func scoreOf(t *testing.T, moves []MoveScore, idx int) int {
+    t.Helper()
+    for _, m := range moves {
+        if m.Index == idx {
+            return m.Score
+        }
+    }
+    t.Fatalf("move %d not evaluated", idx)
+    return 0
+}
+
+func TestEmptyBoardValues(t *testing.T) {
+    s := NewSolver()
+    for _, v := range []domain.Variant{domain.Standard, domain.Misere} {
+        if got := s.Score(domain.NewVariant(v)); got != 0 {
+            t.Fatalf("%v: expected draw with perfect play, got %d", v, got)
+        }
+    }
+    // Wild tic-tac-toe is a first-player win.
+    if got := s.Score(domain.NewVariant(domain.Wild)); got <= 0 {
+        t.Fatalf("wild: expected a forced win for the first player, got %d", got)
+    }
+}
+
+func TestWildTakesWinWithEitherMark(t *testing.T) {
+    g := position(t, "OO./XX./... x", domain.Wild)
+    m, err := NewSolver().BestMove(g)
+    if err != nil {
+        t.Fatalf("BestMove: %v", err)
+    }
+    if m.Index != 2 && m.Index != 5 {
+        t.Fatalf("expected an immediate win at 2 or 5, got %+v", m)
+    }
+}
+
+func TestStandardTakesWinAndBlocks(t *testing.T) {
+    s := NewSolver()
+    if m, _ := s.BestMove(position(t, "XX./OO./... x", domain.Standard)); m.Index != 2 {
+        t.Fatalf("expected X to win at 2, got %d", m.Index)
+    }
+    if m, _ := s.BestMove(position(t, "XX./.O./... o", domain.Standard)); m.Index != 2 {
+        t.Fatalf("expected O to block at 2, got %d", m.Index)
+    }
+}
+
+func TestMisereAvoidsCompletingALine(t *testing.T) {
+    s := NewSolver()
+    g := position(t, "XX./OO./... x", domain.Misere)
+    moves, err := s.Evaluate(g)
+    if err != nil {
+        t.Fatalf("Evaluate: %v", err)
+    }
+    if scoreOf(t, moves, 2) >= 0 {
+        t.Fatalf("completing a line must lose in misère, got %v", moves)
+    }
+    if m, _ := s.BestMove(g); m.Index == 2 {
+        t.Fatalf("solver completed its own line")
+    }
+}
+
+func TestBestMoveOnFinishedGame(t *testing.T) {
+    if _, err := NewSolver().BestMove(position(t, "XXX/OO./... -", domain.Standard)); err != ErrGameOver {
+        t.Fatalf("expected ErrGameOver, got %v", err)
+    }
+}
+
+func TestSolverNeverLosesToRandomPlay(t *testing.T) {
+    s := NewSolver()
+    rng := rand.New(rand.NewSource(1))
+    for _, v := range []domain.Variant{domain.Standard, domain.Misere, domain.Wild} {
+        for i := 0; i < 100; i++ {
+            if v == domain.Wild && i%2 == 1 {
+                continue // wild is a forced win for the first player
+            }
+            solverSide := domain.X
+            if i%2 == 1 {
+                solverSide = domain.O
+            }
+            g := domain.NewVariant(v)
+            for !g.Over {
+                var m domain.Move
+                if g.Turn == solverSide {
+                    m, _ = s.BestMove(g)
+                } else {
+                    legal := g.LegalMoves()
+                    m = legal[rng.Intn(len(legal))]
+                }
+                if err := g.PlayAt(m.Index, m.Mark); err != nil {
+                    t.Fatalf("play: %v", err)
+                }
+            }
+            if g.Winner == domain.Opponent(solverSide) {
+                t.Fatalf("%v: solver as %v lost: %v", v, solverSide, g.Board)
+            }
+            if v == domain.Wild && g.Winner != solverSide {
+                t.Fatalf("wild: solver moving first should always win, got %v", g.Outcome)
+            }
+        }
+    }
+}
+
+func TestNumericalFindsFifteen(t *testing.T) {
+    g := position(t, "53./.2./..4 x", domain.Numerical)
+    m, err := NewSolver().BestMove(g)
+    if err != nil {
+        t.Fatalf("BestMove: %v", err)
+    }
+    if m.Index != 2 || m.Mark != domain.NumberCell(7) {
+        t.Fatalf("expected 7 at c1 to complete 15, got %+v", m)
+    }
+}
+
+func TestForPicksBotByBoard(t *testing.T) {
+    if m, err := For(domain.Misere); err != nil {
+        t.Fatalf("For(misere): %v", err)
+    } else if _, ok := m.(*Solver); !ok {
+        t.Fatalf("expected the solver on the 3x3 board, got %T", m)
+    } else if again, _ := For(domain.Numerical); again != m {
+        t.Fatalf("expected one solver shared by all games")
+    }
+    if m, _ := For(domain.Qubic); m == nil {
+        t.Fatalf("expected a bot for Qubic")
+    } else if _, ok := m.(AlphaBeta); !ok {
+        t.Fatalf("expected alpha-beta on the Qubic board, got %T", m)
+    }
+    for _, v := range []domain.Variant{domain.Quantum, domain.OrderChaos} {
+        if _, err := For(v); err != ErrUnsupported {
+            t.Fatalf("expected ErrUnsupported for %v, got %v", v, err)
+        }
+    }
+}
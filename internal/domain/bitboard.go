@@ -0,0 +1,128 @@
+package domain
+
+import "math/bits"
+
+// Bitboard is a compact board for search: bit i of X (or O) is set when
+// board index i holds that mark. It is a plain value, cheap to copy, and
+// never checks legality — callers are expected to only Make empty squares.
+// It represents the 3x3 board only.
+type Bitboard struct {
+    X, O uint16
+}
+
+// fullMask has a bit set for each of the nine squares.
+const fullMask uint16 = 1<<9 - 1
+
+// winMasks holds one mask per entry of lines.
+var winMasks = func() (m [8]uint16) {
+    for i, ln := range lines {
+        m[i] = 1<<ln[0] | 1<<ln[1] | 1<<ln[2]
+    }
+    return m
+}()
+
+// BitboardOf converts b to a Bitboard.
+func BitboardOf(b Board) Bitboard {
+    var bb Bitboard
+    for i, c := range b[:9] {
+        switch c {
+        case X:
+            bb.X |= 1 << i
+        case O:
+            bb.O |= 1 << i
+        }
+    }
+    return bb
+}
+
+// Board converts bb back to a Board.
+func (bb Bitboard) Board() Board {
+    var b Board
+    for i := 0; i < 9; i++ {
+        b[i] = bb.At(i)
+    }
+    return b
+}
+
+// At returns the mark on square idx.
+func (bb Bitboard) At(idx int) Cell {
+    switch {
+    case bb.X&(1<<idx) != 0:
+        return X
+    case bb.O&(1<<idx) != 0:
+        return O
+    default:
+        return Empty
+    }
+}
+
+// side returns a pointer to the mask of side.
+func (bb *Bitboard) side(side Cell) *uint16 {
+    if side == O {
+        return &bb.O
+    }
+    return &bb.X
+}
+
+// Make places side's mark on square idx.
+func (bb *Bitboard) Make(idx int, side Cell) {
+    *bb.side(side) |= 1 << idx
+}
+
+// Unmake removes side's mark from square idx, undoing Make.
+func (bb *Bitboard) Unmake(idx int, side Cell) {
+    *bb.side(side) &^= 1 << idx
+}
+
+// Moves returns the mask of empty squares; iterate it with NextMove.
+func (bb Bitboard) Moves() uint16 {
+    return fullMask &^ (bb.X | bb.O)
+}
+
+// NextMove pops the lowest square from a move mask returned by Moves and
+// returns its index, or -1 once the mask is empty.
+//
+//  for m := bb.Moves(); ; {
+//      idx := NextMove(&m)
+//      if idx < 0 {
+//          break
+//      }
+//      ...
+//  }
+func NextMove(m *uint16) int {
+    if *m == 0 {
+        return -1
+    }
+    idx := bits.TrailingZeros16(*m)
+    *m &= *m - 1
+    return idx
+}
+
+// AppendMoves appends the indexes of all empty squares to dst in ascending order.
+func (bb Bitboard) AppendMoves(dst []int) []int {
+    for m := bb.Moves(); m != 0; m &= m - 1 {
+        dst = append(dst, bits.TrailingZeros16(m))
+    }
+    return dst
+}
+
+// Wins reports whether side holds a complete line.
+func (bb Bitboard) Wins(side Cell) bool {
+    s := *bb.side(side)
+    for _, w := range winMasks {
+        if s&w == w {
+            return true
+        }
+    }
+    return false
+}
+
+// Full reports whether no square is empty.
+func (bb Bitboard) Full() bool {
+    return bb.X|bb.O == fullMask
+}
+
+// Count returns the number of marks on the board.
+func (bb Bitboard) Count() int {
+    return bits.OnesCount16(bb.X | bb.O)
+}
@@ -0,0 +1,158 @@
+package domain
+
+import (
+    "math/rand"
+    "testing"
+)
+
+// allBoards enumerates every assignment of Empty/X/O to the nine squares.
+func allBoards() []Board {
+    boards := make([]Board, 0, 19683)
+    for n := 0; n < 19683; n++ {
+        var b Board
+        for i, v := 0, n; i < 9; i, v = i+1, v/3 {
+            b[i] = Cell(v % 3)
+        }
+        boards = append(boards, b)
+    }
+    return boards
+}
+
+func TestBitboardRoundTripAndWinsMatchHasWin(t *testing.T) {
+    for _, b := range allBoards() {
+        bb := BitboardOf(b)
+        if bb.Board() != b {
+            t.Fatalf("round trip of %v gave %v", b, bb.Board())
+        }
+        for _, side := range []Cell{X, O} {
+            if bb.Wins(side) != hasWin(b, side) {
+                t.Fatalf("Wins(%v) disagrees with hasWin on %v", side, b)
+            }
+        }
+    }
+}
+
+func TestBitboardMakeUnmakeAndMoves(t *testing.T) {
+    var bb Bitboard
+    bb.Make(4, X)
+    bb.Make(0, O)
+    if bb.At(4) != X || bb.At(0) != O || bb.Count() != 2 {
+        t.Fatalf("unexpected board after Make: %+v", bb)
+    }
+    got := bb.AppendMoves(nil)
+    want := []int{1, 2, 3, 5, 6, 7, 8}
+    if len(got) != len(want) {
+        t.Fatalf("moves = %v, want %v", got, want)
+    }
+    for i := range want {
+        if got[i] != want[i] {
+            t.Fatalf("moves = %v, want %v", got, want)
+        }
+    }
+    m := bb.Moves()
+    for _, w := range want {
+        if idx := NextMove(&m); idx != w {
+            t.Fatalf("NextMove = %d, want %d", idx, w)
+        }
+    }
+    if NextMove(&m) != -1 {
+        t.Fatalf("expected exhausted move mask")
+    }
+    bb.Unmake(4, X)
+    bb.Unmake(0, O)
+    if bb != (Bitboard{}) {
+        t.Fatalf("Unmake did not restore the empty board: %+v", bb)
+    }
+}
+
+// countGames walks the full game tree and counts finished games.
+func countGames(bb *Bitboard, side Cell) int {
+    if bb.Wins(X) || bb.Wins(O) || bb.Full() {
+        return 1
+    }
+    n := 0
+    for m := bb.Moves(); ; {
+        idx := NextMove(&m)
+        if idx < 0 {
+            break
+        }
+        bb.Make(idx, side)
+        n += countGames(bb, Opponent(side))
+        bb.Unmake(idx, side)
+    }
+    return n
+}
+
+func TestBitboardGameTreeSize(t *testing.T) {
+    var bb Bitboard
+    // The number of distinct complete tic-tac-toe games is well known.
+    if n := countGames(&bb, X); n != 255168 {
+        t.Fatalf("expected 255168 games, got %d", n)
+    }
+}
+
+func benchBoards() []Board {
+    rng := rand.New(rand.NewSource(1))
+    boards := allBoards()
+    rng.Shuffle(len(boards), func(i, j int) { boards[i], boards[j] = boards[j], boards[i] })
+    return boards[:1024]
+}
+
+func BenchmarkHasWin(b *testing.B) {
+    boards := benchBoards()
+    b.ResetTimer()
+    n := 0
+    for i := 0; i < b.N; i++ {
+        if hasWin(boards[i%len(boards)], X) {
+            n++
+        }
+    }
+    _ = n
+}
+
+func BenchmarkBitboardWins(b *testing.B) {
+    boards := benchBoards()
+    bbs := make([]Bitboard, len(boards))
+    for i, bd := range boards {
+        bbs[i] = BitboardOf(bd)
+    }
+    b.ResetTimer()
+    n := 0
+    for i := 0; i < b.N; i++ {
+        if bbs[i%len(bbs)].Wins(X) {
+            n++
+        }
+    }
+    _ = n
+}
+
+// countGamesBoard is countGames on the array board, for comparison.
+func countGamesBoard(b *Board, side Cell, empty int) int {
+    if hasWin(*b, X) || hasWin(*b, O) || empty == 0 {
+        return 1
+    }
+    n := 0
+    for i := range b {
+        if b[i] != Empty {
+            continue
+        }
+        b[i] = side
+        n += countGamesBoard(b, Opponent(side), empty-1)
+        b[i] = Empty
+    }
+    return n
+}
+
+func BenchmarkGameTreeBoard(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        var bd Board
+        countGamesBoard(&bd, X, 9)
+    }
+}
+
+func BenchmarkGameTreeBitboard(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        var bb Bitboard
+        countGames(&bb, X)
+    }
+}
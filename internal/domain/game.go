@@ -2,7 +2,8 @@ package domain
 
 import "errors"
 
-// Cell represents a board cell state.
+// Cell represents a board cell state. Beyond Empty, X and O, a cell can hold
+// a number in the Numerical variant (see NumberCell).
 type Cell uint8
 
 const (
@@ -11,8 +12,53 @@ const (
     O
 )
 
-// Board is a fixed 3x3 board stored row-major.
-type Board [9]Cell
+// String returns "X", "O", the number held, or "" for Empty.
+func (c Cell) String() string {
+    if c == Empty {
+        return ""
+    }
+    return cellLetter(c)
+}
+
+// Outcome is the result of a game.
+type Outcome uint8
+
+const (
+    InProgress Outcome = iota
+    XWon
+    OWon
+    Draw
+    // Aborted: stopped before the rules decided it (see End).
+    Aborted
+)
+
+func (o Outcome) String() string {
+    switch o {
+    case XWon:
+        return "X won"
+    case OWon:
+        return "O won"
+    case Draw:
+        return "draw"
+    case Aborted:
+        return "aborted"
+    default:
+        return "in progress"
+    }
+}
+
+// Board holds the cells of any variant's board; the variant's Geometry says
+// how many are in use and how they are laid out (the classic game uses the
+// first 9, row-major). Cells beyond the geometry stay Empty.
+type Board [MaxCells]Cell
+
+// Move is one placement: the board index, the mark placed there and the
+// side that placed it (the two differ only in variants like Wild).
+type Move struct {
+    Index int
+    Mark  Cell
+    Side  Cell
+}
 
 // Game holds the current state of a Tic-Tac-Toe match.
 type Game struct {
@@ -21,6 +67,15 @@ type Game struct {
     Winner Cell
     Over   bool
     Moves  int
+    // Outcome records how the game ended; InProgress until Over.
+    Outcome Outcome
+    // Line holds the board indexes of the winning line; nil unless a side won.
+    Line []int
+    // History lists the moves played on this Game value, oldest first. Games
+    // started from a parsed position do not include the moves before it.
+    History []Move
+    // Variant selects the rules; the zero value is Standard.
+    Variant Variant
 }
 
 // Errors returned by domain operations.
@@ -28,6 +83,7 @@ var (
     ErrOutOfBounds = errors.New("out of bounds")
     ErrOccupied    = errors.New("cell occupied")
     ErrGameOver    = errors.New("game over")
+    ErrInvalidMark = errors.New("mark not allowed")
 )
 
 // New returns a new game with X to move.
@@ -35,34 +91,58 @@ func New() Game {
     return Game{Turn: X}
 }
 
-// Play attempts to play the current turn at row r, column c (0..2).
+// Geometry returns the shape of g's board.
+func (g Game) Geometry() *Geometry {
+    return g.Variant.Geometry()
+}
+
+// Play attempts to play the current turn at row r, column c (0..2 on the
+// classic board), placing the mover's own mark.
 func (g *Game) Play(r, c int) error {
+    return g.PlayMark(r, c, g.Turn)
+}
+
+// PlayMark is Play with the mark chosen by the mover; the variant's rules
+// decide which marks are allowed (ErrInvalidMark otherwise). On boards with
+// several layers the rows of all layers are numbered consecutively.
+func (g *Game) PlayMark(r, c int, mark Cell) error {
+    geo := g.Geometry()
+    if r < 0 || r >= geo.Layers*geo.Rows || c < 0 || c >= geo.Cols {
+        if g.Over {
+            return ErrGameOver
+        }
+        return ErrOutOfBounds
+    }
+    return g.PlayAt(r*geo.Cols+c, mark)
+}
+
+// PlayAt places mark on board index idx for the side to move.
+func (g *Game) PlayAt(idx int, mark Cell) error {
     if g.Over {
         return ErrGameOver
     }
-    if r < 0 || r > 2 || c < 0 || c > 2 {
+    if idx < 0 || idx >= g.Geometry().Cells() {
         return ErrOutOfBounds
     }
-    idx := r*3 + c
     if g.Board[idx] != Empty {
         return ErrOccupied
     }
+    if !allows(g.Variant.Rules(), g.Board, g.Turn, mark) {
+        return ErrInvalidMark
+    }
 
-    // Place the mark
-    g.Board[idx] = g.Turn
+    // Place the mark; History is re-sliced to full capacity so copies of a
+    // Game never append into each other's backing array.
+    g.Board[idx] = mark
     g.Moves++
+    g.History = append(g.History[:len(g.History):len(g.History)], Move{Index: idx, Mark: mark, Side: g.Turn})
 
-    // Check for a win
-    if hasWin(g.Board, g.Turn) {
-        g.Winner = g.Turn
-        g.Over = true
-        return nil
-    }
-
-    // Check for draw
-    if g.Moves == 9 {
-        g.Winner = Empty
+    // Let the variant's rules decide whether the game is over
+    if outcome, ln := g.Variant.Rules().Result(g.Board, g.Turn, g.Moves); outcome != InProgress {
+        g.Winner = outcome.Winner()
         g.Over = true
+        g.Line = ln
+        g.Outcome = outcome
         return nil
     }
 
@@ -75,17 +155,68 @@ func (g *Game) Play(r, c int) error {
     return nil
 }
 
-func hasWin(b Board, side Cell) bool {
-    lines := [8][3]int{
-        // rows
-        {0, 1, 2}, {3, 4, 5}, {6, 7, 8},
-        // cols
-        {0, 3, 6}, {1, 4, 7}, {2, 5, 8},
-        // diags
-        {0, 4, 8}, {2, 4, 6},
+// Start returns the position g started from: the board with History undone
+// and the first recorded mover to play. Without history it returns g itself.
+func (g Game) Start() Game {
+    if len(g.History) == 0 {
+        g.History = nil
+        return g
+    }
+    s := Game{Board: g.Board, Turn: g.History[0].Side, Moves: g.Moves - len(g.History), Variant: g.Variant}
+    for _, m := range g.History {
+        s.Board[m.Index] = Empty
+    }
+    return s
+}
+
+// wonBy maps a winning side to its outcome.
+func wonBy(side Cell) Outcome {
+    if side == X {
+        return XWon
     }
+    return OWon
+}
+
+// End stops a game in progress without a result, e.g. when it is abandoned.
+// Its outcome is Aborted, which is neither a win nor a draw.
+func (g *Game) End() error {
+    if g.Over {
+        return ErrGameOver
+    }
+    g.Over = true
+    g.Winner = Empty
+    g.Outcome = Aborted
+    return nil
+}
+
+// lines lists every winning line of the 3x3 board.
+var lines = [8][3]int{
+    // rows
+    {0, 1, 2}, {3, 4, 5}, {6, 7, 8},
+    // cols
+    {0, 3, 6}, {1, 4, 7}, {2, 5, 8},
+    // diags
+    {0, 4, 8}, {2, 4, 6},
+}
+
+func hasWin(b Board, side Cell) bool {
+    return winLine(b, side) != nil
+}
+
+// winLine returns the first line fully held by side, or nil.
+func winLine(b Board, side Cell) []int {
     for _, ln := range lines {
         if b[ln[0]] == side && b[ln[1]] == side && b[ln[2]] == side {
+            return []int{ln[0], ln[1], ln[2]}
+        }
+    }
+    return nil
+}
+
+// OnLine reports whether board index idx is part of the winning line.
+func (g Game) OnLine(idx int) bool {
+    for _, i := range g.Line {
+        if i == idx {
             return true
         }
     }
@@ -28,6 +28,9 @@ func TestNewGameInitialState(t *testing.T) {
     if g.Winner != Empty {
         t.Fatalf("expected no winner, got %v", g.Winner)
     }
+    if g.Outcome != InProgress {
+        t.Fatalf("expected outcome InProgress, got %v", g.Outcome)
+    }
     for i, c := range g.Board {
         if c != Empty {
             t.Fatalf("expected empty board, cell %d = %v", i, c)
@@ -112,6 +115,17 @@ func TestWinConditionsForX(t *testing.T) {
         if g.Moves != 5 {
             t.Fatalf("expected 5 moves to win, got %d", g.Moves)
         }
+        for _, cell := range line {
+            if !g.OnLine(cell[0]*3 + cell[1]) {
+                t.Fatalf("expected %v on winning line %v, got Line=%v", cell, line, g.Line)
+            }
+        }
+        if len(g.Line) != 3 {
+            t.Fatalf("expected 3 winning cells, got %v", g.Line)
+        }
+        if g.Outcome != XWon {
+            t.Fatalf("expected outcome XWon, got %v", g.Outcome)
+        }
     }
 }
 
@@ -165,6 +179,9 @@ func TestWinConditionsForO(t *testing.T) {
         if g.Moves != 6 {
             t.Fatalf("expected 6 moves to win for O, got %d", g.Moves)
         }
+        if g.Outcome != OWon || len(g.Line) != 3 {
+            t.Fatalf("expected outcome OWon with a line, got %v %v", g.Outcome, g.Line)
+        }
     }
 }
 
@@ -186,6 +203,12 @@ func TestDrawNoWinner(t *testing.T) {
     if g.Moves != 9 {
         t.Fatalf("expected 9 moves on draw, got %d", g.Moves)
     }
+    if g.Line != nil {
+        t.Fatalf("expected no winning line on draw, got %v", g.Line)
+    }
+    if g.Outcome != Draw {
+        t.Fatalf("expected outcome Draw, got %v", g.Outcome)
+    }
 }
 
 func TestGameOverBlocksFurtherMoves(t *testing.T) {
@@ -202,3 +225,49 @@ func TestGameOverBlocksFurtherMoves(t *testing.T) {
     }
 }
 
+
+func TestEndStopsGameWithoutWinner(t *testing.T) {
+    g := New()
+    playMoves(t, &g, [][2]int{{0, 0}})
+    if err := g.End(); err != nil {
+        t.Fatalf("End failed: %v", err)
+    }
+    if !g.Over || g.Outcome != Aborted || g.Winner != Empty {
+        t.Fatalf("unexpected state after End: %+v", g)
+    }
+    if err := g.End(); err != ErrGameOver {
+        t.Fatalf("expected ErrGameOver on second End, got %v", err)
+    }
+}
+
+func TestHistoryRecordsMovesAndCopiesStayIndependent(t *testing.T) {
+    g := New()
+    playMoves(t, &g, [][2]int{{1, 1}, {0, 0}})
+    if len(g.History) != 2 || g.History[0] != (Move{Index: 4, Mark: X, Side: X}) || g.History[1] != (Move{Index: 0, Mark: O, Side: O}) {
+        t.Fatalf("unexpected history %v", g.History)
+    }
+    a, b := g, g
+    playMoves(t, &a, [][2]int{{2, 2}})
+    playMoves(t, &b, [][2]int{{0, 2}})
+    if a.History[2].Index != 8 || b.History[2].Index != 2 {
+        t.Fatalf("copies share history: a=%v b=%v", a.History, b.History)
+    }
+}
+
+func TestStartUndoesHistory(t *testing.T) {
+    g, err := ParsePosition("X.O/.X./... o")
+    if err != nil {
+        t.Fatalf("ParsePosition: %v", err)
+    }
+    if got := FormatPosition(g.Start()); got != "X.O/.X./... o" {
+        t.Fatalf("start of a fresh game should be itself, got %q", got)
+    }
+    playMoves(t, &g, [][2]int{{2, 2}, {1, 0}})
+    start := g.Start()
+    if got := FormatPosition(start); got != "X.O/.X./... o" {
+        t.Fatalf("expected original position, got %q", got)
+    }
+    if start.Moves != 3 || start.History != nil {
+        t.Fatalf("unexpected start game %+v", start)
+    }
+}
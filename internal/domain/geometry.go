@@ -0,0 +1,91 @@
+package domain
+
+// MaxCells is the capacity of a Board; a variant's Geometry uses a prefix of it.
+const MaxCells = 64
+
+// Geometry describes the shape of a variant's board: Layers stacked grids of
+// Rows x Cols cells, stored layer by layer and row-major, and the lines that
+// decide the game.
+type Geometry struct {
+    Layers, Rows, Cols int
+    Lines              [][]int
+}
+
+// Grid3 is the classic 3x3 board.
+var Grid3 = &Geometry{Layers: 1, Rows: 3, Cols: 3, Lines: func() [][]int {
+    out := make([][]int, len(lines))
+    for i, ln := range lines {
+        out[i] = []int{ln[0], ln[1], ln[2]}
+    }
+    return out
+}()}
+
+// Cube4 is the 4x4x4 Qubic cube with its 76 lines of four.
+var Cube4 = &Geometry{Layers: 4, Rows: 4, Cols: 4, Lines: straightLines(4, 4, 4, 4)}
+
+// Cells returns the number of cells in use.
+func (geo *Geometry) Cells() int {
+    return geo.Layers * geo.Rows * geo.Cols
+}
+
+// Index returns the board index of a cell.
+func (geo *Geometry) Index(layer, r, c int) int {
+    return (layer*geo.Rows+r)*geo.Cols + c
+}
+
+// Coords returns the layer, row and column of a board index.
+func (geo *Geometry) Coords(idx int) (layer, r, c int) {
+    return idx / (geo.Rows * geo.Cols), idx / geo.Cols % geo.Rows, idx % geo.Cols
+}
+
+// LineOf returns the first line fully held by side, or nil.
+func (geo *Geometry) LineOf(b Board, side Cell) []int {
+    for _, ln := range geo.Lines {
+        held := true
+        for _, idx := range ln {
+            if b[idx] != side {
+                held = false
+                break
+            }
+        }
+        if held {
+            return append([]int(nil), ln...)
+        }
+    }
+    return nil
+}
+
+// straightLines lists every run of n cells along a straight line (rows,
+// columns, pillars and all diagonals) in a layers x rows x cols grid.
+func straightLines(layers, rows, cols, n int) [][]int {
+    geo := Geometry{Layers: layers, Rows: rows, Cols: cols}
+    in := func(l, r, c int) bool {
+        return l >= 0 && l < layers && r >= 0 && r < rows && c >= 0 && c < cols
+    }
+    var out [][]int
+    for dl := -1; dl <= 1; dl++ {
+        for dr := -1; dr <= 1; dr++ {
+            for dc := -1; dc <= 1; dc++ {
+                // Keep one of each pair of opposite directions.
+                if dl < 0 || dl == 0 && (dr < 0 || dr == 0 && dc <= 0) {
+                    continue
+                }
+                for l := 0; l < layers; l++ {
+                    for r := 0; r < rows; r++ {
+                        for c := 0; c < cols; c++ {
+                            if !in(l+(n-1)*dl, r+(n-1)*dr, c+(n-1)*dc) {
+                                continue
+                            }
+                            ln := make([]int, n)
+                            for k := range ln {
+                                ln[k] = geo.Index(l+k*dl, r+k*dr, c+k*dc)
+                            }
+                            out = append(out, ln)
+                        }
+                    }
+                }
+            }
+        }
+    }
+    return out
+}
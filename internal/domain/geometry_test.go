@@ -0,0 +1,115 @@
+package domain
+
+import (
+    "errors"
+    "fmt"
+    "testing"
+)
+
+func TestCube4HasAll76Lines(t *testing.T) {
+    if got := len(Cube4.Lines); got != 76 {
+        t.Fatalf("expected 76 lines, got %d", got)
+    }
+    seen := map[string]bool{}
+    for _, ln := range Cube4.Lines {
+        if len(ln) != 4 {
+            t.Fatalf("line %v should have 4 cells", ln)
+        }
+        key := fmt.Sprint(ln)
+        if seen[key] {
+            t.Fatalf("line %v listed twice", ln)
+        }
+        seen[key] = true
+    }
+    // The 3x3 board keeps its 8 lines.
+    if got := len(Grid3.Lines); got != 8 {
+        t.Fatalf("expected 8 lines on the 3x3 board, got %d", got)
+    }
+    if got := straightLines(1, 3, 3, 3); len(got) != 8 {
+        t.Fatalf("straightLines(1,3,3,3) found %d lines, want 8", len(got))
+    }
+}
+
+func TestGeometryIndexAndCoords(t *testing.T) {
+    for idx := 0; idx < Cube4.Cells(); idx++ {
+        l, r, c := Cube4.Coords(idx)
+        if got := Cube4.Index(l, r, c); got != idx {
+            t.Fatalf("Index(Coords(%d)) = %d", idx, got)
+        }
+    }
+    if l, r, c := Cube4.Coords(37); l != 2 || r != 1 || c != 1 {
+        t.Fatalf("Coords(37) = %d,%d,%d", l, r, c)
+    }
+}
+
+func TestQubicSpaceDiagonalWins(t *testing.T) {
+    g := NewVariant(Qubic)
+    // X takes the space diagonal from the first layer's a1 to the last
+    // layer's d4; O plays along the first layer's last row.
+    for i := 0; i < 4; i++ {
+        if err := g.PlayAt(Cube4.Index(i, i, i), X); err != nil {
+            t.Fatalf("X move %d: %v", i, err)
+        }
+        if i == 3 {
+            break
+        }
+        if err := g.PlayAt(Cube4.Index(0, 3, i), O); err != nil {
+            t.Fatalf("O move %d: %v", i, err)
+        }
+    }
+    if !g.Over || g.Winner != X || len(g.Line) != 4 {
+        t.Fatalf("expected X to win on a line of four, got over=%v winner=%v line=%v", g.Over, g.Winner, g.Line)
+    }
+    if !g.OnLine(Cube4.Index(2, 2, 2)) {
+        t.Fatalf("expected the centre of the diagonal on the winning line")
+    }
+}
+
+func TestQubicPlayMarkStacksLayers(t *testing.T) {
+    g := NewVariant(Qubic)
+    // Row 5 is the second row of the second layer.
+    if err := g.PlayMark(5, 3, X); err != nil {
+        t.Fatalf("PlayMark: %v", err)
+    }
+    if g.Board[Cube4.Index(1, 1, 3)] != X {
+        t.Fatalf("mark not on layer 2, row 2, column 4")
+    }
+    if err := g.PlayMark(16, 0, O); !errors.Is(err, ErrOutOfBounds) {
+        t.Fatalf("expected ErrOutOfBounds past the last layer, got %v", err)
+    }
+    if err := g.PlayMark(0, 4, O); !errors.Is(err, ErrOutOfBounds) {
+        t.Fatalf("expected ErrOutOfBounds past the last column, got %v", err)
+    }
+    if moves := g.LegalMoves(); len(moves) != 63 {
+        t.Fatalf("expected 63 legal moves, got %d", len(moves))
+    }
+}
+
+func TestQubicPositionRoundTrip(t *testing.T) {
+    g := NewVariant(Qubic)
+    for _, idx := range []int{0, 21, 42, 63, 5} {
+        if err := g.PlayAt(idx, g.Turn); err != nil {
+            t.Fatalf("PlayAt(%d): %v", idx, err)
+        }
+    }
+    pos := FormatPosition(g)
+    want := "X.../.X../..../....|..../.O../..../....|..../..../..X./....|..../..../..../...O o"
+    if pos != want {
+        t.Fatalf("FormatPosition = %q, want %q", pos, want)
+    }
+    back, err := ParsePositionVariant(pos, Qubic)
+    if err != nil {
+        t.Fatalf("ParsePositionVariant: %v", err)
+    }
+    if back.Board != g.Board || back.Turn != g.Turn || back.Moves != 5 || back.Variant != Qubic {
+        t.Fatalf("round trip mismatch: %+v", back)
+    }
+    for _, bad := range []string{
+        "X.O/.X./..O x", // a 3x3 board
+        "..../..../..../....|..../..../..../.... x", // two layers
+    } {
+        if _, err := ParsePositionVariant(bad, Qubic); !errors.Is(err, ErrInvalidPosition) {
+            t.Fatalf("ParsePositionVariant(%q) = %v, want ErrInvalidPosition", bad, err)
+        }
+    }
+}
@@ -0,0 +1,266 @@
+package domain
+
+import (
+    "errors"
+    "fmt"
+    "strings"
+)
+
+// ErrInvalidPosition is returned (wrapped) when a position string is malformed or illegal.
+var ErrInvalidPosition = errors.New("invalid position")
+
+// FormatPosition encodes g row by row, rows separated by '/' and the layers
+// of a multi-layer board by '|', using 'X', 'O', the digits of Numerical
+// games and '.' for cells, followed by a space and the side to move ("x",
+// "o", or "-" once the game is over), e.g. "X.O/.X./..O x".
+func FormatPosition(g Game) string {
+    geo := g.Geometry()
+    layer := geo.Rows * geo.Cols
+    var b strings.Builder
+    for i := 0; i < geo.Cells(); i++ {
+        switch {
+        case i == 0:
+        case i%layer == 0:
+            b.WriteByte('|')
+        case i%geo.Cols == 0:
+            b.WriteByte('/')
+        }
+        b.WriteString(cellLetter(g.Board[i]))
+    }
+    b.WriteByte(' ')
+    switch {
+    case g.Over:
+        b.WriteByte('-')
+    case g.Turn == O:
+        b.WriteByte('o')
+    default:
+        b.WriteByte('x')
+    }
+    return b.String()
+}
+
+// ParsePosition decodes a string produced by FormatPosition. Either side may
+// have moved first. It rejects positions that cannot arise in play:
+// impossible mark counts, both sides holding a line, a win by the side that
+// did not move last, and a side to move that disagrees with the board.
+func ParsePosition(s string) (Game, error) {
+    return parsePosition(s, Grid3)
+}
+
+// parsePosition implements ParsePosition for any variant where each side
+// places its own mark and lines of geo win.
+func parsePosition(s string, geo *Geometry) (Game, error) {
+    board, side, err := parseBoard(s, geo)
+    if err != nil {
+        return Game{}, err
+    }
+    g := Game{Board: board}
+    var xs, os int
+    for _, c := range board {
+        switch c {
+        case X:
+            xs++
+        case O:
+            os++
+        case Empty:
+        default:
+            return Game{}, fmt.Errorf("%w: numbers are only valid in numerical positions", ErrInvalidPosition)
+        }
+    }
+    g.Moves = xs + os
+
+    xLine, oLine := geo.LineOf(g.Board, X), geo.LineOf(g.Board, O)
+    if xLine != nil && oLine != nil {
+        return Game{}, fmt.Errorf("%w: both sides have a line", ErrInvalidPosition)
+    }
+    won := Empty
+    switch {
+    case xLine != nil:
+        won, g.Line = X, xLine
+    case oLine != nil:
+        won, g.Line = O, oLine
+    }
+    if g.Turn, g.Over, err = settleTurn(xs, os, won, g.Moves == geo.Cells(), side); err != nil {
+        return Game{}, err
+    }
+    if g.Over {
+        g.Winner = won
+        g.Outcome = Draw
+        if won != Empty {
+            g.Outcome = wonBy(won)
+        }
+    }
+
+    want := "-"
+    if !g.Over {
+        want = strings.ToLower(cellLetter(g.Turn))
+    }
+    if side != want {
+        return Game{}, fmt.Errorf("%w: side to move is %q but the board implies %q", ErrInvalidPosition, side, want)
+    }
+    return g, nil
+}
+
+// settleTurn works out the side to move of a position in which the side
+// moving as X made xs moves and the side moving as O made os moves, either
+// of them first. won is the side holding a line (Empty for none), after
+// which the game is over with the winner's turn; a full board ends it with
+// X's turn. When equal counts leave the first mover open, the position's
+// side field decides; the caller still checks the field against the result.
+func settleTurn(xs, os int, won Cell, full bool, side string) (Cell, bool, error) {
+    var last Cell // Empty when either side may have moved last
+    switch {
+    case xs == os+1:
+        last = X
+    case os == xs+1:
+        last = O
+    case xs != os:
+        return Empty, false, fmt.Errorf("%w: %d X and %d O moves cannot occur in play", ErrInvalidPosition, xs, os)
+    }
+    switch {
+    case won != Empty && last != Empty && won != last:
+        return Empty, false, fmt.Errorf("%w: %s has a line but %s moved last", ErrInvalidPosition, cellLetter(won), cellLetter(last))
+    case won != Empty:
+        return won, true, nil
+    case full:
+        return X, true, nil
+    case last != Empty:
+        return Opponent(last), false, nil
+    case side == "o":
+        return O, false, nil
+    default:
+        return X, false, nil
+    }
+}
+
+// parseBoard splits a position into its board, laid out by geo, and its
+// side-to-move field.
+func parseBoard(s string, geo *Geometry) (Board, string, error) {
+    var b Board
+    fields := strings.Fields(s)
+    if len(fields) != 2 {
+        return b, "", fmt.Errorf("%w: want \"<rows> <side>\", got %q", ErrInvalidPosition, s)
+    }
+    layers := strings.Split(fields[0], "|")
+    if len(layers) != geo.Layers {
+        return b, "", fmt.Errorf("%w: want %d layers, got %d", ErrInvalidPosition, geo.Layers, len(layers))
+    }
+    for l, layer := range layers {
+        rows := strings.Split(layer, "/")
+        if len(rows) != geo.Rows {
+            return b, "", fmt.Errorf("%w: want %d rows, got %d", ErrInvalidPosition, geo.Rows, len(rows))
+        }
+        for r, row := range rows {
+            if len(row) != geo.Cols {
+                return b, "", fmt.Errorf("%w: row %d must have %d cells", ErrInvalidPosition, r+1, geo.Cols)
+            }
+            for c := 0; c < geo.Cols; c++ {
+                idx := geo.Index(l, r, c)
+                switch row[c] {
+                case 'X':
+                    b[idx] = X
+                case 'O':
+                    b[idx] = O
+                case '1', '2', '3', '4', '5', '6', '7', '8', '9':
+                    b[idx] = NumberCell(int(row[c] - '0'))
+                case '.':
+                default:
+                    return b, "", fmt.Errorf("%w: unexpected %q in row %d", ErrInvalidPosition, row[c], r+1)
+                }
+            }
+        }
+    }
+    return b, fields[1], nil
+}
+
+// parseWildPosition decodes a position of v, Wild or Order and Chaos. Either
+// mark may appear any number of times, so mark counts say nothing about the
+// side to move: the position's side field is taken as given while the game
+// is on. A completed line (at most one) was made by the side that moved
+// last, which for finished positions is worked out assuming X moved first.
+func parseWildPosition(s string, v Variant) (Game, error) {
+    geo := v.Geometry()
+    board, side, err := parseBoard(s, geo)
+    if err != nil {
+        return Game{}, err
+    }
+    g := Game{Board: board, Variant: v}
+    for _, c := range board {
+        if _, ok := c.Number(); ok {
+            return Game{}, fmt.Errorf("%w: numbers are only valid in numerical positions", ErrInvalidPosition)
+        }
+        if c != Empty {
+            g.Moves++
+        }
+    }
+    g.Turn = X
+    if g.Moves%2 == 1 {
+        g.Turn = O
+    }
+    switch side {
+    case "x":
+        g.Turn = X
+    case "o":
+        g.Turn = O
+    }
+    xLine, oLine := geo.LineOf(board, X), geo.LineOf(board, O)
+    if xLine != nil && oLine != nil {
+        return Game{}, fmt.Errorf("%w: both marks have a line", ErrInvalidPosition)
+    }
+    if xLine != nil || oLine != nil || g.Moves == geo.Cells() {
+        // The last mover is the side before Turn.
+        g.Turn = Opponent(g.Turn)
+        g.Over = true
+        g.Outcome, g.Line = v.Rules().Result(board, g.Turn, g.Moves)
+        g.Winner = g.Outcome.Winner()
+    }
+    want := "-"
+    if !g.Over {
+        want = strings.ToLower(cellLetter(g.Turn))
+    }
+    if side != want {
+        return Game{}, fmt.Errorf("%w: side to move is %q but the board implies %q", ErrInvalidPosition, side, want)
+    }
+    return g, nil
+}
+
+// ParsePositionVariant is ParsePosition for a game played under v: the
+// position is validated as usual and a completed line is scored by v's rules.
+// Wild and Order and Chaos positions, where mark counts say nothing about the
+// side to move, are validated by move count instead.
+func ParsePositionVariant(s string, v Variant) (Game, error) {
+    switch v {
+    case Wild, OrderChaos:
+        return parseWildPosition(s, v)
+    case Numerical:
+        return parseNumericalPosition(s)
+    case Quantum:
+        // Positions carry no spooky marks.
+        return Game{}, fmt.Errorf("%w: quantum games cannot start from a position", ErrInvalidPosition)
+    }
+    g, err := parsePosition(s, v.Geometry())
+    if err != nil {
+        return Game{}, err
+    }
+    g.Variant = v
+    if g.Line != nil {
+        g.Outcome, _ = v.Rules().Result(g.Board, g.Turn, g.Moves)
+        g.Winner = g.Outcome.Winner()
+    }
+    return g, nil
+}
+
+// cellLetter returns "X", "O", a digit or "." for a cell.
+func cellLetter(c Cell) string {
+    if n, ok := c.Number(); ok {
+        return string(rune('0' + n))
+    }
+    switch c {
+    case X:
+        return "X"
+    case O:
+        return "O"
+    default:
+        return "."
+    }
+}
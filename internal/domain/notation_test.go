@@ -0,0 +1,113 @@
+package domain
+
+import (
+    "errors"
+    "testing"
+)
+
+func TestFormatPosition(t *testing.T) {
+    g := New()
+    if got := FormatPosition(g); got != ".../.../... x" {
+        t.Fatalf("unexpected empty position %q", got)
+    }
+    playMoves(t, &g, [][2]int{{0, 0}, {0, 2}, {1, 1}})
+    if got := FormatPosition(g); got != "X.O/.X./... o" {
+        t.Fatalf("unexpected position %q", got)
+    }
+    playMoves(t, &g, [][2]int{{1, 0}, {2, 2}})
+    if got := FormatPosition(g); got != "X.O/OX./..X -" {
+        t.Fatalf("unexpected finished position %q", got)
+    }
+}
+
+func TestParsePositionRoundTrip(t *testing.T) {
+    for _, s := range []string{
+        ".../.../... x",
+        "X.O/.X./... o",
+        "X.O/.X./..O x",
+        "X.O/OX./..X -",
+        "XXO/OOX/XOX -",
+    } {
+        g, err := ParsePosition(s)
+        if err != nil {
+            t.Fatalf("ParsePosition(%q): %v", s, err)
+        }
+        if got := FormatPosition(g); got != s {
+            t.Fatalf("round trip %q -> %q", s, got)
+        }
+    }
+}
+
+func TestParsePositionDerivesState(t *testing.T) {
+    g, err := ParsePosition("OOO/XX./X.. -")
+    if err != nil {
+        t.Fatalf("ParsePosition: %v", err)
+    }
+    if !g.Over || g.Winner != O || g.Outcome != OWon || len(g.Line) != 3 || g.Moves != 6 {
+        t.Fatalf("unexpected parsed game: %+v", g)
+    }
+
+    g, err = ParsePosition("X.O/.X./..O x")
+    if err != nil {
+        t.Fatalf("ParsePosition: %v", err)
+    }
+    if g.Over || g.Turn != X || g.Moves != 4 {
+        t.Fatalf("unexpected parsed game: %+v", g)
+    }
+    // The parsed game continues like a played one.
+    if err := g.Play(2, 0); err != nil || g.Turn != O {
+        t.Fatalf("expected play to continue, err=%v turn=%v", err, g.Turn)
+    }
+}
+
+func TestParsePositionRejectsIllegal(t *testing.T) {
+    cases := map[string]string{
+        "missing side":       "X.O/.X./..O",
+        "two rows":           "X.O/.X. x",
+        "short row":          "X.O/.X/..O x",
+        "bad cell":           "X.O/.Y./..O x",
+        "too many O":         "OO./.../... x",
+        "too many X":         "XX./X../... o",
+        "double winner":      "XXX/OOO/X.. -",
+        "X won but O last":   "XXX/OO./OO. -",
+        "O won but X last":   "OOO/XX./XX. -",
+        "wrong side to move": "X../.../... x",
+        "moves after win":    "XXX/OO./... o",
+        "unknown side":       ".../.../... z",
+    }
+    for name, s := range cases {
+        if _, err := ParsePosition(s); !errors.Is(err, ErrInvalidPosition) {
+            t.Fatalf("%s: expected ErrInvalidPosition for %q, got %v", name, s, err)
+        }
+    }
+}
+
+func TestParsePositionWithOMovingFirst(t *testing.T) {
+    g, err := ParsePosition(".../.../... o")
+    if err != nil || g.Turn != O || g.Moves != 0 {
+        t.Fatalf("expected an empty board with O to move, got %+v, %v", g, err)
+    }
+    // One more O than X: O moved first and X is to move.
+    g, err = ParsePosition("O../.O./..X x")
+    if err != nil || g.Turn != X {
+        t.Fatalf("expected X to move, got %+v, %v", g, err)
+    }
+    if _, err := ParsePosition("O../.O./..X o"); !errors.Is(err, ErrInvalidPosition) {
+        t.Fatalf("expected the side to move to be checked, got %v", err)
+    }
+    // With O first, X can complete a line with equal counts.
+    g, err = ParsePosition("XXX/OO./.O. -")
+    if err != nil || g.Winner != X {
+        t.Fatalf("expected X to have won, got %+v, %v", g, err)
+    }
+
+    start := New()
+    start.Turn = O
+    played := start
+    if err := played.Play(1, 1); err != nil {
+        t.Fatalf("Play: %v", err)
+    }
+    if s := played.Start(); s.Turn != O || s.Moves != 0 || FormatPosition(s) != ".../.../... o" {
+        t.Fatalf("expected Start to restore O to move, got %+v", s)
+    }
+}
@@ -0,0 +1,177 @@
+package domain
+
+import "fmt"
+
+// numberBase is the Cell value just below the number 1; numbers 1-9 occupy
+// the Cell values after X and O.
+const numberBase = O
+
+// NumberCell returns the cell holding number n (1-9) in the Numerical variant.
+func NumberCell(n int) Cell {
+    return numberBase + Cell(n)
+}
+
+// Number returns the number held by c and whether c holds one.
+func (c Cell) Number() (int, bool) {
+    if c > numberBase && c <= numberBase+9 {
+        return int(c - numberBase), true
+    }
+    return 0, false
+}
+
+// numericalRules: the side that moves first places odd numbers and the other
+// side even ones, each at most once; completing a line that sums to 15 wins
+// for the mover. Which numbers are due follows from the board alone: the
+// first mover is to move whenever both sides have placed equally many.
+type numericalRules struct{}
+
+func (numericalRules) Marks(b Board, player Cell) []Cell {
+    odds, evens := numberCounts(b)
+    var marks []Cell
+    for _, n := range RemainingNumbers(b, odds == evens) {
+        marks = append(marks, NumberCell(n))
+    }
+    return marks
+}
+
+func (numericalRules) Result(b Board, mover Cell, moves int) (Outcome, []int) {
+    if ln := sumLine(b); ln != nil {
+        return wonBy(mover), ln
+    }
+    if moves == 9 {
+        return Draw, nil
+    }
+    return InProgress, nil
+}
+
+// sumLine returns the first full line of numbers summing to 15, or nil.
+func sumLine(b Board) []int {
+    for _, ln := range lines {
+        sum, full := 0, true
+        for _, idx := range ln {
+            n, ok := b[idx].Number()
+            if !ok {
+                full = false
+                break
+            }
+            sum += n
+        }
+        if full && sum == 15 {
+            return []int{ln[0], ln[1], ln[2]}
+        }
+    }
+    return nil
+}
+
+// numberCounts returns how many odd and even numbers b holds.
+func numberCounts(b Board) (odds, evens int) {
+    for _, c := range b {
+        if n, ok := c.Number(); ok {
+            if n%2 == 1 {
+                odds++
+            } else {
+                evens++
+            }
+        }
+    }
+    return odds, evens
+}
+
+// RemainingNumbers returns the odd (or, when odd is false, the even) numbers
+// not yet placed on b in the Numerical variant.
+func RemainingNumbers(b Board, odd bool) []int {
+    var used [10]bool
+    for _, c := range b {
+        if n, ok := c.Number(); ok {
+            used[n] = true
+        }
+    }
+    first := 1
+    if !odd {
+        first = 2
+    }
+    var out []int
+    for n := first; n <= 9; n += 2 {
+        if !used[n] {
+            out = append(out, n)
+        }
+    }
+    return out
+}
+
+// OddSide returns the side placing odd numbers in a Numerical game: the side
+// that moved first, whichever it was.
+func (g Game) OddSide() Cell {
+    odds, evens := numberCounts(g.Board)
+    if g.Over && g.Outcome != Aborted {
+        // A finished game leaves Turn with the last mover.
+        if odds > evens {
+            return g.Turn
+        }
+        return Opponent(g.Turn)
+    }
+    if odds == evens {
+        return g.Turn
+    }
+    return Opponent(g.Turn)
+}
+
+// parseNumericalPosition decodes a Numerical position, with digits for the
+// placed numbers. Each number appears at most once, and as the first mover
+// places odd numbers there are as many odd as even ones or one more. Either
+// seat may have moved first, so the side field names the side to move; a
+// finished position cannot tell and is read as X having moved first. At most
+// the last mover can have completed a line summing to 15.
+func parseNumericalPosition(s string) (Game, error) {
+    board, side, err := parseBoard(s, Grid3)
+    if err != nil {
+        return Game{}, err
+    }
+    g := Game{Board: board, Variant: Numerical}
+    var seen [10]bool
+    var odds, evens int
+    for _, c := range board {
+        if c == Empty {
+            continue
+        }
+        n, ok := c.Number()
+        if !ok {
+            return Game{}, fmt.Errorf("%w: numerical positions hold only the digits 1-9", ErrInvalidPosition)
+        }
+        if seen[n] {
+            return Game{}, fmt.Errorf("%w: %d placed twice", ErrInvalidPosition, n)
+        }
+        seen[n] = true
+        if n%2 == 1 {
+            odds++
+        } else {
+            evens++
+        }
+    }
+    if odds != evens && odds != evens+1 {
+        return Game{}, fmt.Errorf("%w: %d odd and %d even numbers cannot occur in play", ErrInvalidPosition, odds, evens)
+    }
+    g.Moves = odds + evens
+    if ln := sumLine(board); ln != nil || g.Moves == 9 {
+        if side != "-" {
+            return Game{}, fmt.Errorf("%w: side to move is %q but the game is over", ErrInvalidPosition, side)
+        }
+        g.Turn = O // the last mover, with X having moved first
+        if odds > evens {
+            g.Turn = X
+        }
+        g.Over = true
+        g.Outcome, g.Line = numericalRules{}.Result(board, g.Turn, g.Moves)
+        g.Winner = g.Outcome.Winner()
+        return g, nil
+    }
+    switch side {
+    case "x":
+        g.Turn = X
+    case "o":
+        g.Turn = O
+    default:
+        return Game{}, fmt.Errorf("%w: side to move is %q but the game is in progress", ErrInvalidPosition, side)
+    }
+    return g, nil
+}
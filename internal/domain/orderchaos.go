@@ -0,0 +1,31 @@
+package domain
+
+// Grid6 is the 6x6 Order and Chaos board with its 32 lines of five.
+var Grid6 = &Geometry{Layers: 1, Rows: 6, Cols: 6, Lines: straightLines(1, 6, 6, 5)}
+
+// In Order and Chaos the side moving as X plays Order and the side moving
+// as O plays Chaos.
+const (
+    Order = X
+    Chaos = O
+)
+
+// orderChaosRules: either side places X or O; five of one mark in a row
+// wins for Order whoever placed it, a full board without one wins for Chaos.
+type orderChaosRules struct{}
+
+func (orderChaosRules) Marks(b Board, player Cell) []Cell {
+    return []Cell{X, O}
+}
+
+func (orderChaosRules) Result(b Board, mover Cell, moves int) (Outcome, []int) {
+    for _, mark := range []Cell{X, O} {
+        if ln := Grid6.LineOf(b, mark); ln != nil {
+            return wonBy(Order), ln
+        }
+    }
+    if moves == Grid6.Cells() {
+        return wonBy(Chaos), nil
+    }
+    return InProgress, nil
+}
@@ -0,0 +1,274 @@
+package domain
+
+import "errors"
+
+// Errors returned by QuantumGame.
+var (
+    ErrSameSquare      = errors.New("spooky marks need two different squares")
+    ErrCollapsePending = errors.New("a collapse must be chosen first")
+    ErrNoCollapse      = errors.New("no collapse to choose")
+    ErrCollapseSquare  = errors.New("the mark cannot collapse there")
+)
+
+// SpookyMark is the mark of one move in quantum tic-tac-toe: Side's mark
+// with subscript Move, in superposition between squares A and B until it
+// collapses into one of them. The last mark of a game may be placed in a
+// single square, in which case A == B.
+type SpookyMark struct {
+    Side Cell
+    Move int // 1-based; the subscript shown next to the mark
+    A, B int
+}
+
+// QuantumGame is a game of quantum tic-tac-toe on the 3x3 board. Each move
+// places a spooky mark in two squares, entangling them. When a move closes a
+// cycle of entangled squares, the other player chooses which of its two
+// squares that mark collapses into; every mark in the cycle and everything
+// attached to it then collapses into a classical mark. Lines of classical
+// marks decide the game; if both sides get one in the same collapse, the
+// side whose line has the lower highest subscript scores a point and the
+// other half a point.
+//
+// The zero value is not ready; use NewQuantumGame.
+type QuantumGame struct {
+    // Marks lists the spooky marks in move order; Marks[i].Move == i+1.
+    Marks []SpookyMark
+    // Classical[i] is the move whose mark collapsed into square i, or 0.
+    Classical [9]int
+    // Turn is the side to move, which is also the side choosing a pending
+    // collapse.
+    Turn Cell
+    // Pending is the move whose mark closed a cycle and awaits collapse, or 0.
+    Pending int
+    Over    bool
+    Outcome Outcome
+    Winner  Cell
+    // XScore and OScore count half points.
+    XScore, OScore int
+    // Lines lists the completed lines, each as board indexes.
+    Lines [][]int
+}
+
+// NewQuantumGame returns an empty quantum game with X to move.
+func NewQuantumGame() QuantumGame {
+    return QuantumGame{Turn: X}
+}
+
+// Mark returns the spooky mark of move n (1-based).
+func (q QuantumGame) Mark(n int) SpookyMark {
+    return q.Marks[n-1]
+}
+
+// Collapsed reports whether the mark of move n is classical.
+func (q QuantumGame) Collapsed(n int) bool {
+    m := q.Mark(n)
+    return q.Classical[m.A] == n || q.Classical[m.B] == n
+}
+
+// Spooky lists the uncollapsed marks in square idx, in move order.
+func (q QuantumGame) Spooky(idx int) []SpookyMark {
+    var out []SpookyMark
+    for _, m := range q.Marks {
+        if (m.A == idx || m.B == idx) && !q.Collapsed(m.Move) {
+            out = append(out, m)
+        }
+    }
+    return out
+}
+
+// free counts the squares without a classical mark.
+func (q QuantumGame) free() int {
+    n := 0
+    for _, c := range q.Classical {
+        if c == 0 {
+            n++
+        }
+    }
+    return n
+}
+
+// Place puts the side to move's spooky mark in squares a and b. When only one
+// square is left the mark is placed there classically, with a == b.
+func (q *QuantumGame) Place(a, b int) error {
+    switch {
+    case q.Over:
+        return ErrGameOver
+    case q.Pending != 0:
+        return ErrCollapsePending
+    case a < 0 || a >= 9 || b < 0 || b >= 9:
+        return ErrOutOfBounds
+    case q.Classical[a] != 0 || q.Classical[b] != 0:
+        return ErrOccupied
+    case a == b && q.free() > 1:
+        return ErrSameSquare
+    }
+    m := SpookyMark{Side: q.Turn, Move: len(q.Marks) + 1, A: a, B: b}
+    cycle := a != b && q.connected(a, b)
+    // Re-slice to full capacity so copies never append into each other.
+    q.Marks = append(q.Marks[:len(q.Marks):len(q.Marks)], m)
+    switch {
+    case a == b:
+        q.Classical[a] = m.Move
+        q.settle()
+        if q.Over {
+            return nil
+        }
+    case cycle:
+        q.Pending = m.Move
+    }
+    q.Turn = Opponent(q.Turn)
+    return nil
+}
+
+// connected reports whether squares a and b are linked by a chain of
+// uncollapsed marks.
+func (q QuantumGame) connected(a, b int) bool {
+    var seen [9]bool
+    seen[a] = true
+    queue := []int{a}
+    for len(queue) > 0 {
+        sq := queue[0]
+        queue = queue[1:]
+        if sq == b {
+            return true
+        }
+        for _, m := range q.Spooky(sq) {
+            next := m.A
+            if next == sq {
+                next = m.B
+            }
+            if !seen[next] {
+                seen[next] = true
+                queue = append(queue, next)
+            }
+        }
+    }
+    return false
+}
+
+// Collapse resolves the pending cycle by putting the mark that closed it in
+// square idx, one of its two squares. Only the side to move may choose, and
+// it then goes on to place its own mark unless the collapse ended the game.
+func (q *QuantumGame) Collapse(idx int) error {
+    if q.Over {
+        return ErrGameOver
+    }
+    if q.Pending == 0 {
+        return ErrNoCollapse
+    }
+    if m := q.Mark(q.Pending); idx != m.A && idx != m.B {
+        return ErrCollapseSquare
+    }
+    // Each mark landing in a square pushes the other marks there into their
+    // other square.
+    type landing struct{ move, square int }
+    queue := []landing{{q.Pending, idx}}
+    for len(queue) > 0 {
+        l := queue[0]
+        queue = queue[1:]
+        if q.Classical[l.square] != 0 || q.Collapsed(l.move) {
+            continue
+        }
+        others := q.Spooky(l.square)
+        q.Classical[l.square] = l.move
+        for _, m := range others {
+            if m.Move == l.move {
+                continue
+            }
+            other := m.A
+            if other == l.square {
+                other = m.B
+            }
+            queue = append(queue, landing{m.Move, other})
+        }
+    }
+    q.Pending = 0
+    q.settle()
+    return nil
+}
+
+// settle scores the classical lines and ends the game when there are any or
+// the board is full.
+func (q *QuantumGame) settle() {
+    q.Lines = nil
+    var high [3]int // lowest highest-subscript among each side's lines; 0 if none
+    var count [3]int
+    for _, ln := range lines {
+        side, top := Empty, 0
+        for k, idx := range ln {
+            n := q.Classical[idx]
+            if n == 0 {
+                side = Empty
+                break
+            }
+            s := q.Mark(n).Side
+            if k > 0 && s != side {
+                side = Empty
+                break
+            }
+            side = s
+            if n > top {
+                top = n
+            }
+        }
+        if side == Empty {
+            continue
+        }
+        q.Lines = append(q.Lines, []int{ln[0], ln[1], ln[2]})
+        count[side]++
+        if high[side] == 0 || top < high[side] {
+            high[side] = top
+        }
+    }
+    switch {
+    case count[X] > 0 && count[O] > 0:
+        // Simultaneous lines: the earlier one scores a full point.
+        if high[X] < high[O] {
+            q.XScore, q.OScore = 2, 1
+        } else {
+            q.XScore, q.OScore = 1, 2
+        }
+    case count[X] > 0:
+        q.XScore = 2 * count[X]
+    case count[O] > 0:
+        q.OScore = 2 * count[O]
+    case q.free() > 0:
+        return
+    }
+    q.Over = true
+    switch {
+    case q.XScore > q.OScore:
+        q.Outcome, q.Winner = XWon, X
+    case q.OScore > q.XScore:
+        q.Outcome, q.Winner = OWon, O
+    default:
+        q.Outcome = Draw
+    }
+}
+
+// Game returns the classical view of q as a Game of the Quantum variant:
+// its board holds the collapsed marks, Line every square of a completed line.
+func (q QuantumGame) Game() Game {
+    g := Game{Turn: q.Turn, Over: q.Over, Winner: q.Winner, Outcome: q.Outcome, Moves: len(q.Marks), Variant: Quantum}
+    for idx, n := range q.Classical {
+        if n != 0 {
+            g.Board[idx] = q.Mark(n).Side
+        }
+    }
+    for _, ln := range q.Lines {
+        g.Line = append(g.Line, ln...)
+    }
+    return g
+}
+
+// quantumRules stand in for the Rules of the Quantum variant, whose moves go
+// through QuantumGame instead: no classical mark may be placed directly.
+type quantumRules struct{}
+
+func (quantumRules) Marks(b Board, player Cell) []Cell {
+    return nil
+}
+
+func (quantumRules) Result(b Board, mover Cell, moves int) (Outcome, []int) {
+    return InProgress, nil
+}
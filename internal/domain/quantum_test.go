@@ -0,0 +1,104 @@
+package domain
+
+import (
+    "errors"
+    "testing"
+)
+
+func placeAll(t *testing.T, q *QuantumGame, pairs [][2]int) {
+    t.Helper()
+    for _, p := range pairs {
+        if err := q.Place(p[0], p[1]); err != nil {
+            t.Fatalf("Place(%d, %d): %v", p[0], p[1], err)
+        }
+    }
+}
+
+func TestQuantumCycleWaitsForTheOtherPlayer(t *testing.T) {
+    q := NewQuantumGame()
+    placeAll(t, &q, [][2]int{{0, 1}, {0, 1}})
+    if q.Pending != 2 || q.Turn != X {
+        t.Fatalf("expected O's cycle to await X's collapse, got pending=%d turn=%v", q.Pending, q.Turn)
+    }
+    if got := q.Spooky(0); len(got) != 2 || got[0].Side != X || got[1].Move != 2 {
+        t.Fatalf("unexpected spooky marks in a1: %+v", got)
+    }
+    if err := q.Place(3, 4); !errors.Is(err, ErrCollapsePending) {
+        t.Fatalf("expected ErrCollapsePending, got %v", err)
+    }
+    if err := q.Collapse(5); !errors.Is(err, ErrCollapseSquare) {
+        t.Fatalf("expected ErrCollapseSquare, got %v", err)
+    }
+    if err := q.Collapse(0); err != nil {
+        t.Fatalf("Collapse: %v", err)
+    }
+    if q.Classical[0] != 2 || q.Classical[1] != 1 || q.Pending != 0 || q.Turn != X {
+        t.Fatalf("expected O in a1 and X pushed to b1 with X to move, got %+v", q)
+    }
+    if len(q.Spooky(0)) != 0 {
+        t.Fatalf("collapsed squares hold no spooky marks")
+    }
+    if err := q.Collapse(0); !errors.Is(err, ErrNoCollapse) {
+        t.Fatalf("expected ErrNoCollapse, got %v", err)
+    }
+    if err := q.Place(0, 4); !errors.Is(err, ErrOccupied) {
+        t.Fatalf("expected ErrOccupied, got %v", err)
+    }
+    if err := q.Place(4, 4); !errors.Is(err, ErrSameSquare) {
+        t.Fatalf("expected ErrSameSquare, got %v", err)
+    }
+    g := q.Game()
+    if g.Variant != Quantum || g.Board[0] != O || g.Board[1] != X || g.Moves != 2 || g.Over {
+        t.Fatalf("unexpected classical view %+v", g)
+    }
+}
+
+func TestQuantumSimultaneousLinesScoreByEarliestMark(t *testing.T) {
+    q := NewQuantumGame()
+    // A chain a1-a2-b2-c2-a1 closed by O's sixth mark, with b1 and c1 hanging
+    // off it.
+    placeAll(t, &q, [][2]int{{0, 3}, {3, 4}, {1, 4}, {4, 5}, {2, 5}, {5, 0}})
+    copied := q
+    if q.Pending != 6 {
+        t.Fatalf("expected move 6 to close a cycle, got pending=%d", q.Pending)
+    }
+    // Putting O6 in c2 pushes every X to the top row and every O to the middle.
+    if err := q.Collapse(5); err != nil {
+        t.Fatalf("Collapse: %v", err)
+    }
+    if !q.Over || len(q.Lines) != 2 {
+        t.Fatalf("expected two lines to end the game, got %+v", q)
+    }
+    // X's line was complete at move 5, O's only at move 6.
+    if q.XScore != 2 || q.OScore != 1 || q.Outcome != XWon || q.Winner != X {
+        t.Fatalf("expected X 1 to O ½, got X=%d/2 O=%d/2 %v", q.XScore, q.OScore, q.Outcome)
+    }
+    if g := q.Game(); !g.Over || g.Winner != X || len(g.Line) != 6 {
+        t.Fatalf("unexpected classical view %+v", g)
+    }
+    if copied.Over || copied.Classical != [9]int{} {
+        t.Fatalf("copies must not see each other's collapse")
+    }
+}
+
+func TestQuantumLastMoveIsClassical(t *testing.T) {
+    q := NewQuantumGame()
+    // Pairs of marks on the same two squares close a cycle at once; X puts
+    // O's mark in the second square, its own lands in the first. The last
+    // square stays open for X's ninth mark.
+    for _, p := range [][2]int{{0, 1}, {2, 4}, {3, 5}, {7, 6}} {
+        placeAll(t, &q, [][2]int{p, p})
+        if err := q.Collapse(p[1]); err != nil {
+            t.Fatalf("Collapse: %v", err)
+        }
+        if q.Over {
+            t.Fatalf("game ended early: %+v", q.Game())
+        }
+    }
+    if err := q.Place(8, 8); err != nil {
+        t.Fatalf("single-square last move: %v", err)
+    }
+    if !q.Over || q.Classical[8] != 9 || q.Outcome != Draw || q.Lines != nil {
+        t.Fatalf("expected a full board without lines, got %+v", q)
+    }
+}
@@ -0,0 +1,207 @@
+package domain
+
+// Symmetry is one of the 8 rotations and reflections of the board (the
+// dihedral group of the square). Transforming a position by a symmetry never
+// changes its game-theoretic value, so search tables and statistics can store
+// one entry per equivalence class. Symmetries act on the 3x3 board only.
+type Symmetry int
+
+const (
+    Identity Symmetry = iota
+    Rotate90          // clockwise
+    Rotate180
+    Rotate270
+    FlipHorizontal // mirror left-right
+    FlipVertical   // mirror top-bottom
+    FlipDiagonal   // transpose across a1-c3
+    FlipAntiDiagonal
+)
+
+// Symmetries lists all 8 symmetries, Identity first.
+var Symmetries = [8]Symmetry{Identity, Rotate90, Rotate180, Rotate270, FlipHorizontal, FlipVertical, FlipDiagonal, FlipAntiDiagonal}
+
+// symmetryMaps[s][i] is the index square i moves to under s.
+var symmetryMaps = func() (m [8][9]int) {
+    for s := range m {
+        for i := 0; i < 9; i++ {
+            r, c := i/3, i%3
+            switch Symmetry(s) {
+            case Identity:
+            case Rotate90:
+                r, c = c, 2-r
+            case Rotate180:
+                r, c = 2-r, 2-c
+            case Rotate270:
+                r, c = 2-c, r
+            case FlipHorizontal:
+                c = 2 - c
+            case FlipVertical:
+                r = 2 - r
+            case FlipDiagonal:
+                r, c = c, r
+            case FlipAntiDiagonal:
+                r, c = 2-c, 2-r
+            }
+            m[s][i] = r*3 + c
+        }
+    }
+    return m
+}()
+
+// Apply returns the index square idx moves to under s.
+func (s Symmetry) Apply(idx int) int {
+    return symmetryMaps[s][idx]
+}
+
+// Inverse returns the symmetry that undoes s.
+func (s Symmetry) Inverse() Symmetry {
+    switch s {
+    case Rotate90:
+        return Rotate270
+    case Rotate270:
+        return Rotate90
+    default:
+        // Every other element of the group is its own inverse.
+        return s
+    }
+}
+
+// Transform returns b with every square moved by s.
+func (b Board) Transform(s Symmetry) Board {
+    var out Board
+    for i, c := range b[:9] {
+        out[symmetryMaps[s][i]] = c
+    }
+    return out
+}
+
+// Transform returns bb with every square moved by s.
+func (bb Bitboard) Transform(s Symmetry) Bitboard {
+    var out Bitboard
+    for i := 0; i < 9; i++ {
+        to := symmetryMaps[s][i]
+        out.X |= bb.X >> i & 1 << to
+        out.O |= bb.O >> i & 1 << to
+    }
+    return out
+}
+
+// boardKey orders boards by reading the squares as base-16 digits, which
+// covers every Cell value including numbers.
+func boardKey(b Board) int64 {
+    var k int64
+    for _, c := range b[:9] {
+        k = k<<4 | int64(c)
+    }
+    return k
+}
+
+// Canonical returns the representative of b's symmetry class — the
+// transform with the smallest key — and the symmetry that produces it, so
+// that moves found on the canonical board can be mapped back with
+// s.Inverse().Apply.
+func Canonical(b Board) (Board, Symmetry) {
+    best, bestSym, bestKey := b, Identity, boardKey(b)
+    for _, s := range Symmetries[1:] {
+        t := b.Transform(s)
+        if k := boardKey(t); k < bestKey {
+            best, bestSym, bestKey = t, s, k
+        }
+    }
+    return best, bestSym
+}
+
+// Equivalent reports whether a and b are the same position up to rotation
+// and reflection.
+func Equivalent(a, b Board) bool {
+    for _, s := range Symmetries {
+        if a.Transform(s) == b {
+            return true
+        }
+    }
+    return false
+}
+
+// cellKinds is the number of non-empty Cell values: X, O and the numbers 1-9.
+const cellKinds = 11
+
+// zobrist holds a fixed random key per square and non-empty cell value; the
+// empty board hashes to zero. Keys come from a seeded splitmix64 so hashes are
+// stable across runs and processes.
+var zobrist = func() (z [MaxCells][cellKinds]uint64) {
+    seed := uint64(0x7474745f7a6f6272) // "ttt_zobr"
+    for i := range z {
+        for j := range z[i] {
+            seed += 0x9e3779b97f4a7c15
+            x := seed
+            x = (x ^ x>>30) * 0xbf58476d1ce4e5b9
+            x = (x ^ x>>27) * 0x94d049bb133111eb
+            z[i][j] = x ^ x>>31
+        }
+    }
+    return z
+}()
+
+// ZobristKey returns the key toggled by placing or removing mark on idx;
+// XOR it into a hash on every Make and Unmake. Empty has no key.
+func ZobristKey(idx int, mark Cell) uint64 {
+    if mark == Empty || mark > cellKinds {
+        return 0
+    }
+    return zobrist[idx][mark-1]
+}
+
+// Hash returns the Zobrist hash of b.
+func Hash(b Board) uint64 {
+    var h uint64
+    for i, c := range b {
+        h ^= ZobristKey(i, c)
+    }
+    return h
+}
+
+// SymmetricHash tracks the Zobrist hash of a position under all 8
+// symmetries at once, so the canonical hash stays available in O(1) while a
+// search makes and unmakes moves. The zero value is the empty board. Like
+// the symmetries themselves it covers 3x3 boards only.
+type SymmetricHash struct {
+    h [8]uint64
+}
+
+// NewSymmetricHash returns the hash state of b.
+func NewSymmetricHash(b Board) SymmetricHash {
+    var sh SymmetricHash
+    for i, c := range b[:9] {
+        sh.Toggle(i, c)
+    }
+    return sh
+}
+
+// Toggle places or removes mark on idx.
+func (sh *SymmetricHash) Toggle(idx int, mark Cell) {
+    for s := range sh.h {
+        sh.h[s] ^= ZobristKey(symmetryMaps[s][idx], mark)
+    }
+}
+
+// Hash returns the plain Zobrist hash of the tracked position.
+func (sh SymmetricHash) Hash() uint64 {
+    return sh.h[Identity]
+}
+
+// Canonical returns a hash shared by all positions equivalent under
+// symmetry: the smallest of the 8 transformed hashes.
+func (sh SymmetricHash) Canonical() uint64 {
+    m := sh.h[0]
+    for _, h := range sh.h[1:] {
+        if h < m {
+            m = h
+        }
+    }
+    return m
+}
+
+// CanonicalHash returns the symmetry-independent hash of b.
+func CanonicalHash(b Board) uint64 {
+    return NewSymmetricHash(b).Canonical()
+}
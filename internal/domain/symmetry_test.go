@@ -0,0 +1,127 @@
+package domain
+
+import "testing"
+
+func mustPosition(t *testing.T, s string) Board {
+    t.Helper()
+    g, err := ParsePosition(s)
+    if err != nil {
+        t.Fatalf("ParsePosition(%q): %v", s, err)
+    }
+    return g.Board
+}
+
+func TestTransformsKnownPositions(t *testing.T) {
+    b := mustPosition(t, "XO./.../... x")
+    cases := map[Symmetry]string{
+        Identity:         "XO./.../... x",
+        Rotate90:         "..X/..O/... x",
+        Rotate180:        ".../.../.OX x",
+        Rotate270:        ".../O../X.. x",
+        FlipHorizontal:   ".OX/.../... x",
+        FlipVertical:     ".../.../XO. x",
+        FlipDiagonal:     "X../O../... x",
+        FlipAntiDiagonal: ".../..O/..X x",
+    }
+    for s, want := range cases {
+        if got := b.Transform(s); got != mustPosition(t, want) {
+            t.Fatalf("symmetry %d: got %v, want %s", s, got, want)
+        }
+    }
+}
+
+func TestSymmetryGroupProperties(t *testing.T) {
+    for _, b := range allBoards()[:2000] {
+        for _, s := range Symmetries {
+            if b.Transform(s).Transform(s.Inverse()) != b {
+                t.Fatalf("inverse of %d does not restore %v", s, b)
+            }
+            if BitboardOf(b).Transform(s) != BitboardOf(b.Transform(s)) {
+                t.Fatalf("bitboard transform %d disagrees on %v", s, b)
+            }
+            if hasWin(b, X) != hasWin(b.Transform(s), X) {
+                t.Fatalf("symmetry %d changed the winner of %v", s, b)
+            }
+        }
+    }
+}
+
+func TestCanonicalAndEquivalence(t *testing.T) {
+    corners := []string{"X../.../... o", "..X/.../... o", ".../.../X.. o", ".../.../..X o"}
+    want, _ := Canonical(mustPosition(t, corners[0]))
+    for _, p := range corners {
+        b := mustPosition(t, p)
+        got, s := Canonical(b)
+        if got != want {
+            t.Fatalf("canonical form of %s = %v, want %v", p, got, want)
+        }
+        if b.Transform(s) != got {
+            t.Fatalf("returned symmetry does not produce the canonical board")
+        }
+        if !Equivalent(b, mustPosition(t, corners[0])) {
+            t.Fatalf("%s should be equivalent to %s", p, corners[0])
+        }
+        if CanonicalHash(b) != CanonicalHash(mustPosition(t, corners[0])) {
+            t.Fatalf("canonical hash differs for %s", p)
+        }
+    }
+    edge := mustPosition(t, ".X./.../... o")
+    if Equivalent(edge, mustPosition(t, corners[0])) || CanonicalHash(edge) == CanonicalHash(mustPosition(t, corners[0])) {
+        t.Fatalf("edge and corner openings must not be equivalent")
+    }
+}
+
+func TestSymmetryClassCount(t *testing.T) {
+    // Reachable positions of the standard game: 5478 in total, 765 up to symmetry.
+    seen := map[Board]bool{}
+    classes := map[Board]bool{}
+    hashes := map[uint64]bool{}
+    var walk func(g Game)
+    walk = func(g Game) {
+        if seen[g.Board] {
+            return
+        }
+        seen[g.Board] = true
+        c, _ := Canonical(g.Board)
+        classes[c] = true
+        hashes[CanonicalHash(g.Board)] = true
+        if g.Over {
+            return
+        }
+        for i := range g.Board {
+            if g.Board[i] == Empty {
+                next := g
+                _ = next.Play(i/3, i%3)
+                walk(next)
+            }
+        }
+    }
+    walk(New())
+    if len(seen) != 5478 || len(classes) != 765 || len(hashes) != 765 {
+        t.Fatalf("got %d positions, %d classes, %d canonical hashes", len(seen), len(classes), len(hashes))
+    }
+}
+
+func TestSymmetricHashIsIncremental(t *testing.T) {
+    var sh SymmetricHash
+    var b Board
+    for i, idx := range []int{4, 0, 8, 2, 6} {
+        mark := X
+        if i%2 == 1 {
+            mark = O
+        }
+        b[idx] = mark
+        sh.Toggle(idx, mark)
+        if sh.Hash() != Hash(b) || sh != NewSymmetricHash(b) {
+            t.Fatalf("incremental hash diverged after move %d", i)
+        }
+    }
+    sh.Toggle(6, X)
+    b[6] = Empty
+    if sh.Hash() != Hash(b) {
+        t.Fatalf("toggling a mark off did not restore the hash")
+    }
+    if (SymmetricHash{}).Hash() != 0 || Hash(Board{}) != 0 {
+        t.Fatalf("empty board should hash to zero")
+    }
+}
@@ -0,0 +1,297 @@
+package domain
+
+import (
+    "errors"
+    "fmt"
+    "strings"
+)
+
+// ErrUnknownVariant is returned (wrapped) by ParseVariant.
+var ErrUnknownVariant = errors.New("unknown variant")
+
+// Variant selects the rules a Game is played under. The zero value is Standard.
+type Variant int
+
+const (
+    // Standard: three in a row wins.
+    Standard Variant = iota
+    // Misere: three in a row loses.
+    Misere
+    // Wild: either player places X or O; completing any line wins.
+    Wild
+    // Numerical: odd against even numbers, odd moving first; a line summing
+    // to 15 wins.
+    Numerical
+    // Qubic: four in a row anywhere in a 4x4x4 cube wins.
+    Qubic
+    // OrderChaos: on a 6x6 board Order wants five of either mark in a row,
+    // Chaos wants to prevent it.
+    OrderChaos
+    // Quantum: marks go in two squares at once until a cycle collapses them
+    // (see QuantumGame).
+    Quantum
+)
+
+// Variants lists the selectable variants in display order.
+var Variants = []Variant{Standard, Misere, Wild, Numerical, Qubic, OrderChaos, Quantum}
+
+// String returns the identifier used in forms and game records.
+func (v Variant) String() string {
+    switch v {
+    case Misere:
+        return "misere"
+    case Wild:
+        return "wild"
+    case Numerical:
+        return "numerical"
+    case Qubic:
+        return "qubic"
+    case OrderChaos:
+        return "orderchaos"
+    case Quantum:
+        return "quantum"
+    default:
+        return "standard"
+    }
+}
+
+// Title returns the human-readable name of v.
+func (v Variant) Title() string {
+    switch v {
+    case Misere:
+        return "Misère"
+    case Wild:
+        return "Wild"
+    case Numerical:
+        return "Numerical"
+    case Qubic:
+        return "Qubic"
+    case OrderChaos:
+        return "Order and Chaos"
+    case Quantum:
+        return "Quantum"
+    default:
+        return "Standard"
+    }
+}
+
+// Description summarizes how v is won.
+func (v Variant) Description() string {
+    switch v {
+    case Misere:
+        return "three in a row loses"
+    case Wild:
+        return "place X or O, any three in a row wins"
+    case Numerical:
+        return "the first mover places odd and the other side even numbers 1-9, a line summing to 15 wins"
+    case Qubic:
+        return "4x4x4 cube, four in a row in any direction wins"
+    case OrderChaos:
+        return "6x6, both place X or O; five in a row wins for Order, a full board for Chaos"
+    case Quantum:
+        return "each mark goes in two squares until a cycle collapses them; the earlier line scores more"
+    default:
+        return "three in a row wins"
+    }
+}
+
+// ParseVariant parses an identifier produced by String. It is
+// case-insensitive, accepts "misère" and "order-and-chaos", and maps "" to
+// Standard.
+func ParseVariant(s string) (Variant, error) {
+    switch strings.ToLower(strings.TrimSpace(s)) {
+    case "", "standard":
+        return Standard, nil
+    case "misere", "misère":
+        return Misere, nil
+    case "wild":
+        return Wild, nil
+    case "numerical":
+        return Numerical, nil
+    case "qubic":
+        return Qubic, nil
+    case "orderchaos", "order-and-chaos", "order and chaos":
+        return OrderChaos, nil
+    case "quantum":
+        return Quantum, nil
+    }
+    return Standard, fmt.Errorf("%w: %q", ErrUnknownVariant, s)
+}
+
+// Rules decide which marks may be placed, when a game ends and who won.
+type Rules interface {
+    // Marks lists the marks player may place on b.
+    Marks(b Board, player Cell) []Cell
+    // Result is called after mover placed a mark on b, which now holds moves
+    // marks. It returns InProgress while the game continues, otherwise the
+    // outcome and the line that decided it (nil for a draw).
+    Result(b Board, mover Cell, moves int) (Outcome, []int)
+}
+
+// ChoosesMark reports whether the mover picks what to place (Wild,
+// Numerical, Order and Chaos) rather than always placing its own mark.
+func (v Variant) ChoosesMark() bool {
+    return v == Wild || v == Numerical || v == OrderChaos
+}
+
+// SideName returns what the side moving as side is called in v: "X" or
+// "O", or its role in Order and Chaos. Empty maps to "".
+func (v Variant) SideName(side Cell) string {
+    if v == OrderChaos {
+        switch side {
+        case Order:
+            return "Order"
+        case Chaos:
+            return "Chaos"
+        }
+    }
+    if side == Empty {
+        return ""
+    }
+    return cellLetter(side)
+}
+
+// Geometry returns the board shape of v.
+func (v Variant) Geometry() *Geometry {
+    switch v {
+    case Qubic:
+        return Cube4
+    case OrderChaos:
+        return Grid6
+    default:
+        return Grid3
+    }
+}
+
+// Rules returns the rules of v.
+func (v Variant) Rules() Rules {
+    switch v {
+    case Misere:
+        return misereRules{}
+    case Wild:
+        return wildRules{}
+    case Numerical:
+        return numericalRules{}
+    case Qubic:
+        return standardRules{geo: Cube4}
+    case OrderChaos:
+        return orderChaosRules{}
+    case Quantum:
+        return quantumRules{}
+    default:
+        return standardRules{geo: Grid3}
+    }
+}
+
+// ownMark is embedded by rules where each side places only its own mark.
+type ownMark struct{}
+
+func (ownMark) Marks(b Board, player Cell) []Cell {
+    return []Cell{player}
+}
+
+// standardRules: completing a line of geo with your own mark wins.
+type standardRules struct {
+    ownMark
+    geo *Geometry
+}
+
+func (r standardRules) Result(b Board, mover Cell, moves int) (Outcome, []int) {
+    if ln := r.geo.LineOf(b, mover); ln != nil {
+        return wonBy(mover), ln
+    }
+    if moves == r.geo.Cells() {
+        return Draw, nil
+    }
+    return InProgress, nil
+}
+
+type misereRules struct{ ownMark }
+
+func (misereRules) Result(b Board, mover Cell, moves int) (Outcome, []int) {
+    if ln := winLine(b, mover); ln != nil {
+        return wonBy(Opponent(mover)), ln
+    }
+    if moves == 9 {
+        return Draw, nil
+    }
+    return InProgress, nil
+}
+
+type wildRules struct{}
+
+func (wildRules) Marks(b Board, player Cell) []Cell {
+    return []Cell{X, O}
+}
+
+func (wildRules) Result(b Board, mover Cell, moves int) (Outcome, []int) {
+    for _, mark := range []Cell{X, O} {
+        if ln := winLine(b, mark); ln != nil {
+            return wonBy(mover), ln
+        }
+    }
+    if moves == 9 {
+        return Draw, nil
+    }
+    return InProgress, nil
+}
+
+// allows reports whether rules let player place mark on b.
+func allows(rules Rules, b Board, player, mark Cell) bool {
+    for _, m := range rules.Marks(b, player) {
+        if m == mark {
+            return true
+        }
+    }
+    return false
+}
+
+// LegalMoves lists every move the side to move may make, by ascending index
+// and then in the order the rules list marks. It is empty once the game is over.
+func (g Game) LegalMoves() []Move {
+    if g.Over {
+        return nil
+    }
+    rules := g.Variant.Rules()
+    marks := rules.Marks(g.Board, g.Turn)
+    var out []Move
+    for idx := 0; idx < g.Geometry().Cells(); idx++ {
+        if g.Board[idx] == Empty {
+            for _, m := range marks {
+                out = append(out, Move{Index: idx, Mark: m, Side: g.Turn})
+            }
+        }
+    }
+    return out
+}
+
+// Opponent returns the other side; Empty maps to Empty.
+func Opponent(side Cell) Cell {
+    switch side {
+    case X:
+        return O
+    case O:
+        return X
+    default:
+        return Empty
+    }
+}
+
+// NewVariant starts an empty game played under v.
+func NewVariant(v Variant) Game {
+    g := New()
+    g.Variant = v
+    return g
+}
+
+// Winner returns the winning side of o, or Empty.
+func (o Outcome) Winner() Cell {
+    switch o {
+    case XWon:
+        return X
+    case OWon:
+        return O
+    default:
+        return Empty
+    }
+}
@@ -0,0 +1,287 @@
+package domain
+
+import (
+    "errors"
+    "testing"
+)
+
+func TestMisereLineLoses(t *testing.T) {
+    g := NewVariant(Misere)
+    // X completes the top row on move 5 and therefore loses.
+    playMoves(t, &g, [][2]int{{0, 0}, {1, 0}, {0, 1}, {1, 1}, {0, 2}})
+    if !g.Over || g.Winner != O || g.Outcome != OWon {
+        t.Fatalf("expected O to win when X makes a line, got %+v", g)
+    }
+    if len(g.Line) != 3 || !g.OnLine(0) {
+        t.Fatalf("expected the losing line to be recorded, got %v", g.Line)
+    }
+    if err := g.Play(2, 2); err != ErrGameOver {
+        t.Fatalf("expected ErrGameOver, got %v", err)
+    }
+}
+
+func TestMisereDraw(t *testing.T) {
+    g := NewVariant(Misere)
+    playMoves(t, &g, [][2]int{
+        {0, 0}, {0, 1}, {0, 2},
+        {1, 1}, {1, 0}, {1, 2},
+        {2, 1}, {2, 0}, {2, 2},
+    })
+    if !g.Over || g.Outcome != Draw || g.Winner != Empty {
+        t.Fatalf("expected draw, got %+v", g)
+    }
+}
+
+func TestParseVariant(t *testing.T) {
+    for s, want := range map[string]Variant{"": Standard, "standard": Standard, "Misere": Misere, "misère": Misere} {
+        if v, err := ParseVariant(s); err != nil || v != want {
+            t.Fatalf("ParseVariant(%q) = %v, %v", s, v, err)
+        }
+    }
+    if _, err := ParseVariant("go"); !errors.Is(err, ErrUnknownVariant) {
+        t.Fatalf("expected ErrUnknownVariant, got %v", err)
+    }
+    for _, v := range Variants {
+        if got, _ := ParseVariant(v.String()); got != v {
+            t.Fatalf("round trip of %v gave %v", v, got)
+        }
+    }
+}
+
+func TestParsePositionVariantScoresLine(t *testing.T) {
+    g, err := ParsePositionVariant("XXX/OO./... -", Misere)
+    if err != nil {
+        t.Fatalf("ParsePositionVariant: %v", err)
+    }
+    if g.Variant != Misere || g.Outcome != OWon || g.Winner != O {
+        t.Fatalf("expected misère loss for X, got %+v", g)
+    }
+}
+
+func TestWildEitherMarkAndLineWinsForMover(t *testing.T) {
+    g := NewVariant(Wild)
+    // X's seat places an O, O's seat places an O, then X's seat completes OOO.
+    if err := g.PlayMark(0, 0, O); err != nil {
+        t.Fatalf("PlayMark: %v", err)
+    }
+    if err := g.PlayMark(0, 1, O); err != nil {
+        t.Fatalf("PlayMark: %v", err)
+    }
+    if g.Turn != X {
+        t.Fatalf("expected turn to pass back to X, got %v", g.Turn)
+    }
+    if err := g.PlayMark(0, 2, O); err != nil {
+        t.Fatalf("PlayMark: %v", err)
+    }
+    if !g.Over || g.Winner != X || g.Outcome != XWon || len(g.Line) != 3 {
+        t.Fatalf("expected X's seat to win with a line of O, got %+v", g)
+    }
+    if g.History[2] != (Move{Index: 2, Mark: O, Side: X}) {
+        t.Fatalf("unexpected history entry %+v", g.History[2])
+    }
+    if start := g.Start(); start.Turn != X || start.Moves != 0 || start.Variant != Wild {
+        t.Fatalf("unexpected start %+v", start)
+    }
+}
+
+func TestPlayMarkRejectsOtherMarksOutsideWild(t *testing.T) {
+    g := New()
+    if err := g.PlayMark(0, 0, O); err != ErrInvalidMark {
+        t.Fatalf("expected ErrInvalidMark, got %v", err)
+    }
+    w := NewVariant(Wild)
+    if err := w.PlayMark(0, 0, Empty); err != ErrInvalidMark {
+        t.Fatalf("expected ErrInvalidMark for an empty mark, got %v", err)
+    }
+}
+
+func TestLegalMoves(t *testing.T) {
+    if n := len(New().LegalMoves()); n != 9 {
+        t.Fatalf("expected 9 standard moves, got %d", n)
+    }
+    if n := len(NewVariant(Wild).LegalMoves()); n != 18 {
+        t.Fatalf("expected 18 wild moves, got %d", n)
+    }
+    g, _ := ParsePosition("XXX/OO./... -")
+    if g.LegalMoves() != nil {
+        t.Fatalf("expected no moves in a finished game")
+    }
+}
+
+func TestParseWildPosition(t *testing.T) {
+    g, err := ParsePositionVariant("OO./.../... x", Wild)
+    if err != nil {
+        t.Fatalf("ParsePositionVariant: %v", err)
+    }
+    if g.Turn != X || g.Moves != 2 || g.Over {
+        t.Fatalf("unexpected wild position %+v", g)
+    }
+    g, err = ParsePositionVariant("OOO/.../... -", Wild)
+    if err != nil {
+        t.Fatalf("ParsePositionVariant: %v", err)
+    }
+    if g.Winner != X || g.Outcome != XWon {
+        t.Fatalf("expected X's seat (third mover) to have won, got %+v", g)
+    }
+    // Either side may have moved first, so an unfinished position takes its
+    // side to move as given.
+    if g, err := ParsePositionVariant("OO./.../... o", Wild); err != nil || g.Turn != O {
+        t.Fatalf("expected O to move, got %+v, %v", g, err)
+    }
+    if _, err := ParsePositionVariant("OO./.../... -", Wild); !errors.Is(err, ErrInvalidPosition) {
+        t.Fatalf("expected side-to-move mismatch, got %v", err)
+    }
+}
+
+func TestNumericalPlaysNumbersAndWinsOnFifteen(t *testing.T) {
+    g := NewVariant(Numerical)
+    if err := g.PlayMark(0, 0, X); err != ErrInvalidMark {
+        t.Fatalf("expected ErrInvalidMark for a plain X, got %v", err)
+    }
+    if err := g.PlayMark(0, 0, NumberCell(2)); err != ErrInvalidMark {
+        t.Fatalf("expected ErrInvalidMark for an even number from X's seat, got %v", err)
+    }
+    steps := []struct{ r, c, n int }{{0, 0, 5}, {1, 1, 2}, {0, 1, 3}, {2, 2, 4}}
+    for _, s := range steps {
+        if err := g.PlayMark(s.r, s.c, NumberCell(s.n)); err != nil {
+            t.Fatalf("PlayMark %d: %v", s.n, err)
+        }
+    }
+    if got := RemainingNumbers(g.Board, true); len(got) != 3 || got[0] != 1 || got[1] != 7 || got[2] != 9 {
+        t.Fatalf("unexpected odd numbers left %v", got)
+    }
+    if err := g.PlayMark(2, 0, NumberCell(5)); err != ErrInvalidMark {
+        t.Fatalf("expected a used number to be rejected, got %v", err)
+    }
+    // 5 + 3 + 7 = 15 along the top row.
+    if err := g.PlayMark(0, 2, NumberCell(7)); err != nil {
+        t.Fatalf("PlayMark: %v", err)
+    }
+    if !g.Over || g.Winner != X || len(g.Line) != 3 || !g.OnLine(2) {
+        t.Fatalf("expected X's seat to win on the top row, got %+v", g)
+    }
+    if n, ok := g.Board[2].Number(); !ok || n != 7 {
+        t.Fatalf("expected cell to hold 7, got %v", g.Board[2])
+    }
+}
+
+func TestNumericalFirstMoverPlacesOddNumbers(t *testing.T) {
+    g := NewVariant(Numerical)
+    g.Turn = O
+    if err := g.PlayMark(0, 0, NumberCell(2)); err != ErrInvalidMark {
+        t.Fatalf("expected ErrInvalidMark for an even number from the first mover, got %v", err)
+    }
+    // A full game with O first and no line summing to 15: O places the five
+    // odd numbers and X the four even ones.
+    for n, idx := range []int{0, 1, 2, 3, 4, 6, 5, 8, 7} {
+        if side := g.OddSide(); side != O {
+            t.Fatalf("ply %d: expected O to hold the odd numbers, got %v", n, side)
+        }
+        if err := g.PlayAt(idx, NumberCell(n+1)); err != nil {
+            t.Fatalf("ply %d: %v", n, err)
+        }
+    }
+    if !g.Over || g.Outcome != Draw || g.OddSide() != O {
+        t.Fatalf("expected a drawn game with O odd, got %+v", g)
+    }
+    if got := RemainingNumbers(g.Board, true); got != nil {
+        t.Fatalf("expected no odd numbers left, got %v", got)
+    }
+}
+
+func TestNumericalPositionRoundTrip(t *testing.T) {
+    g := NewVariant(Numerical)
+    for _, s := range []struct{ r, c, n int }{{0, 0, 5}, {1, 1, 2}, {0, 1, 9}} {
+        if err := g.PlayMark(s.r, s.c, NumberCell(s.n)); err != nil {
+            t.Fatalf("PlayMark: %v", err)
+        }
+    }
+    pos := FormatPosition(g)
+    if pos != "59./.2./... o" {
+        t.Fatalf("unexpected position %q", pos)
+    }
+    parsed, err := ParsePositionVariant(pos, Numerical)
+    if err != nil {
+        t.Fatalf("ParsePositionVariant: %v", err)
+    }
+    if parsed.Board != g.Board || parsed.Turn != O || parsed.Variant != Numerical {
+        t.Fatalf("round trip differs: %+v", parsed)
+    }
+    // With O to move after one odd number, X must have moved first.
+    if odd := parsed.OddSide(); odd != X {
+        t.Fatalf("expected X to hold the odd numbers, got %v", odd)
+    }
+    if o, err := ParsePositionVariant("59./.2./... x", Numerical); err != nil || o.OddSide() != O {
+        t.Fatalf("expected O to have moved first, got %+v, %v", o, err)
+    }
+    for _, bad := range []string{"55./.2./... o", "13./.../... x", "2../.../... x", "X../.../... o", "59./.2./... -"} {
+        if _, err := ParsePositionVariant(bad, Numerical); !errors.Is(err, ErrInvalidPosition) {
+            t.Fatalf("expected %q to be rejected, got %v", bad, err)
+        }
+    }
+    if _, err := ParsePosition(pos); !errors.Is(err, ErrInvalidPosition) {
+        t.Fatalf("standard positions must reject numbers, got %v", err)
+    }
+}
+
+func TestOrderWinsWithFiveOfEitherMark(t *testing.T) {
+    if got := len(Grid6.Lines); got != 32 {
+        t.Fatalf("expected 32 lines of five on the 6x6 board, got %d", got)
+    }
+    g := NewVariant(OrderChaos)
+    // Order and Chaos take turns filling column b with O's; whoever places
+    // the fifth, the line belongs to Order.
+    for r := 0; r < 5; r++ {
+        if g.Over {
+            t.Fatalf("game ended early after %d moves", g.Moves)
+        }
+        if err := g.PlayMark(r, 1, O); err != nil {
+            t.Fatalf("PlayMark(%d, 1): %v", r, err)
+        }
+    }
+    if !g.Over || g.Winner != Order || g.Outcome != XWon || len(g.Line) != 5 {
+        t.Fatalf("expected Order to win on five O's, got %+v", g)
+    }
+    if got := OrderChaos.SideName(g.Winner); got != "Order" {
+        t.Fatalf("SideName = %q, want Order", got)
+    }
+}
+
+func TestChaosWinsOnFullBoard(t *testing.T) {
+    g := NewVariant(OrderChaos)
+    // Pairs of marks alternating by row never make five in a row.
+    for idx := 0; idx < 36; idx++ {
+        r, c := idx/6, idx%6
+        mark := X
+        if (r+c/2)%2 == 1 {
+            mark = O
+        }
+        if err := g.PlayAt(idx, mark); err != nil {
+            t.Fatalf("PlayAt(%d): %v", idx, err)
+        }
+    }
+    if !g.Over || g.Winner != Chaos || g.Outcome != OWon || g.Line != nil {
+        t.Fatalf("expected Chaos to win on a full board, got %+v", g)
+    }
+    pos := FormatPosition(g)
+    parsed, err := ParsePositionVariant(pos, OrderChaos)
+    if err != nil {
+        t.Fatalf("ParsePositionVariant(%q): %v", pos, err)
+    }
+    if parsed.Board != g.Board || parsed.Outcome != OWon {
+        t.Fatalf("round trip differs: %+v", parsed)
+    }
+}
+
+func TestOrderChaosPosition(t *testing.T) {
+    g, err := ParsePositionVariant("XO..../....../....../....../....../...... x", OrderChaos)
+    if err != nil {
+        t.Fatalf("ParsePositionVariant: %v", err)
+    }
+    if g.Turn != Order || g.Moves != 2 || len(g.LegalMoves()) != 68 {
+        t.Fatalf("expected Order to move with 68 choices, got %+v", g)
+    }
+    if _, err := ParsePositionVariant("XO..../....../....../....../....../...... -", OrderChaos); !errors.Is(err, ErrInvalidPosition) {
+        t.Fatalf("expected the side to move to be checked, got %v", err)
+    }
+}
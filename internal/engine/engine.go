@@ -0,0 +1,308 @@
+// Package engine plugs external bot programs into the server. An engine is
+// any program, in any language, that speaks a line-based protocol on its
+// standard input and output, modelled on UCI:
+//
+//	server → engine            engine → server
+//	ttt                        id name <name>      (optional)
+//	                           tttok
+//	isready                    readyok
+//	newgame <variant>
+//	position <board> <side>
+//	go movetime <ms>           bestmove <move>
+//	stop                       bestmove <move>     (at once)
+//	quit
+//
+// ttt opens the session; the engine names itself and answers tttok. newgame
+// announces the variant of the next game (standard, misere, wild,
+// numerical, qubic or orderchaos) and isready waits until the engine has
+// taken it in. position gives the board and side to move in domain notation
+// (see domain.FormatPosition), e.g. "position X.O/.X./..O x". go asks for a
+// move within the given time, and the engine answers with bestmove and the
+// move in record notation (see record.MoveText): a coordinate such as b2,
+// prefixed with the mark or number in variants where the mover picks it
+// ("Ob2", "5b2"). stop asks for the best move found so far. Lines the engine
+// sends that the server does not expect are ignored, so engines may report
+// progress freely.
+//
+// An engine that does not answer bestmove by the time limit is sent stop and
+// is killed if it stays silent for the grace period after; one that exits or
+// answers with an illegal move fails the request. Player restarts failed
+// engines.
+package engine
+
+import (
+    "bufio"
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "os"
+    "os/exec"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/jaminalder/codex-tic-tac-toe/internal/domain"
+    "github.com/jaminalder/codex-tic-tac-toe/internal/record"
+)
+
+// Errors returned by Engine.
+var (
+    ErrTimeout     = errors.New("engine timed out")
+    ErrExited      = errors.New("engine exited")
+    ErrProtocol    = errors.New("engine protocol error")
+    ErrUnsupported = errors.New("variant not supported by engines")
+)
+
+// DefaultGrace is how long an engine may take to answer the handshake,
+// isready and stop when Options.Grace is not set.
+const DefaultGrace = 2 * time.Second
+
+// Options configures how an engine program is started.
+type Options struct {
+    // Args are passed to the program.
+    Args []string
+    // Env is added to the server's environment.
+    Env []string
+    // Dir is the working directory; empty uses the server's.
+    Dir string
+    // Grace bounds the handshake, isready and the answer to stop;
+    // DefaultGrace when zero.
+    Grace time.Duration
+    // Stderr receives the engine's standard error; discarded when nil.
+    Stderr io.Writer
+}
+
+// Engine is a running engine program. Its methods are safe for concurrent
+// use but handle one request at a time.
+type Engine struct {
+    mu    sync.Mutex
+    name  string
+    grace time.Duration
+    cmd   *exec.Cmd
+    stdin io.WriteCloser
+    // lines carries the engine's output; it is closed when the output ends.
+    lines chan string
+    // exited is closed once the program has exited, with the reason in err.
+    exited chan struct{}
+    err    error
+}
+
+// Start runs the program at path and completes the handshake.
+func Start(path string, opts Options) (*Engine, error) {
+    cmd := exec.Command(path, opts.Args...)
+    cmd.Dir = opts.Dir
+    cmd.Stderr = opts.Stderr
+    if len(opts.Env) > 0 {
+        cmd.Env = append(os.Environ(), opts.Env...)
+    }
+    stdin, err := cmd.StdinPipe()
+    if err != nil {
+        return nil, err
+    }
+    stdout, err := cmd.StdoutPipe()
+    if err != nil {
+        return nil, err
+    }
+    if err := cmd.Start(); err != nil {
+        return nil, err
+    }
+    e := &Engine{
+        grace:  opts.Grace,
+        cmd:    cmd,
+        stdin:  stdin,
+        lines:  make(chan string, 64),
+        exited: make(chan struct{}),
+    }
+    if e.grace <= 0 {
+        e.grace = DefaultGrace
+    }
+    go e.read(stdout)
+    e.mu.Lock()
+    defer e.mu.Unlock()
+    err = e.send("ttt")
+    if err == nil {
+        _, err = e.await(context.Background(), e.grace, "tttok")
+    }
+    if err != nil {
+        e.kill()
+        return nil, fmt.Errorf("engine handshake: %w", err)
+    }
+    return e, nil
+}
+
+// read forwards the engine's output line by line, then reaps the process.
+func (e *Engine) read(stdout io.Reader) {
+    sc := bufio.NewScanner(stdout)
+    for sc.Scan() {
+        e.lines <- sc.Text()
+    }
+    close(e.lines)
+    e.err = e.cmd.Wait()
+    close(e.exited)
+}
+
+// Name returns the name the engine gave in the handshake, if any.
+func (e *Engine) Name() string {
+    e.mu.Lock()
+    defer e.mu.Unlock()
+    return e.name
+}
+
+// NewGame announces a game of variant v and waits until the engine is ready.
+func (e *Engine) NewGame(ctx context.Context, v domain.Variant) error {
+    if v == domain.Quantum {
+        return ErrUnsupported
+    }
+    e.mu.Lock()
+    defer e.mu.Unlock()
+    if err := e.send("newgame " + v.String()); err != nil {
+        return err
+    }
+    if err := e.send("isready"); err != nil {
+        return err
+    }
+    _, err := e.await(ctx, e.grace, "readyok")
+    return err
+}
+
+// BestMove asks the engine for its move in g, allowing it moveTime to think.
+// A move whose Mark is Empty places the mover's own mark.
+func (e *Engine) BestMove(ctx context.Context, g domain.Game, moveTime time.Duration) (domain.Move, error) {
+    if g.Variant == domain.Quantum {
+        return domain.Move{}, ErrUnsupported
+    }
+    if g.Over {
+        return domain.Move{}, domain.ErrGameOver
+    }
+    e.mu.Lock()
+    defer e.mu.Unlock()
+    if err := e.send("position " + domain.FormatPosition(g)); err != nil {
+        return domain.Move{}, err
+    }
+    if err := e.send(fmt.Sprintf("go movetime %d", moveTime.Milliseconds())); err != nil {
+        return domain.Move{}, err
+    }
+    answer, err := e.await(ctx, moveTime, "bestmove")
+    if err != nil && (errors.Is(err, ErrTimeout) || ctx.Err() != nil) {
+        // Ask for the move found so far; an engine that ignores stop is
+        // stuck and would answer this request late, during the next one.
+        if e.send("stop") == nil {
+            answer, err = e.await(context.Background(), e.grace, "bestmove")
+        }
+        if err != nil {
+            e.kill()
+            if ctx.Err() != nil {
+                return domain.Move{}, ctx.Err()
+            }
+            return domain.Move{}, ErrTimeout
+        }
+    }
+    if err != nil {
+        return domain.Move{}, err
+    }
+    tok, _, _ := strings.Cut(answer, " ")
+    m, err := record.ParseMove(tok, g.Geometry())
+    if err != nil {
+        return domain.Move{}, fmt.Errorf("%w: bestmove %q: %v", ErrProtocol, answer, err)
+    }
+    m.Side = g.Turn
+    mark := m.Mark
+    if mark == domain.Empty {
+        mark = g.Turn
+    }
+    if err := g.PlayAt(m.Index, mark); err != nil {
+        return domain.Move{}, fmt.Errorf("%w: illegal bestmove %q: %v", ErrProtocol, answer, err)
+    }
+    return m, nil
+}
+
+// Close asks the engine to quit and kills it if it has not exited within
+// the grace period.
+func (e *Engine) Close() error {
+    e.mu.Lock()
+    defer e.mu.Unlock()
+    _ = e.send("quit")
+    _ = e.stdin.Close()
+    select {
+    case <-e.exited:
+    case <-time.After(e.grace):
+        e.kill()
+    }
+    return nil
+}
+
+// send writes one line to the engine.
+func (e *Engine) send(line string) error {
+    if _, err := io.WriteString(e.stdin, line+"\n"); err != nil {
+        return e.exitError()
+    }
+    return nil
+}
+
+// await reads the engine's output until a line starting with the word want
+// and returns the rest of that line. It notes the engine's name on the way.
+func (e *Engine) await(ctx context.Context, timeout time.Duration, want string) (string, error) {
+    timer := time.NewTimer(timeout)
+    defer timer.Stop()
+    for {
+        select {
+        case line, ok := <-e.lines:
+            if !ok {
+                return "", e.exitError()
+            }
+            word, rest, _ := strings.Cut(strings.TrimSpace(line), " ")
+            rest = strings.TrimSpace(rest)
+            if word == want {
+                return rest, nil
+            }
+            if name, ok := strings.CutPrefix(rest, "name "); word == "id" && ok {
+                e.name = strings.TrimSpace(name)
+            }
+        case <-timer.C:
+            return "", ErrTimeout
+        case <-ctx.Done():
+            return "", ctx.Err()
+        }
+    }
+}
+
+// exitError waits briefly for the program to exit and reports why it did.
+func (e *Engine) exitError() error {
+    select {
+    case <-e.exited:
+    case <-time.After(e.grace):
+        e.kill()
+    }
+    select {
+    case <-e.exited:
+        if e.err != nil {
+            return fmt.Errorf("%w: %v", ErrExited, e.err)
+        }
+    default:
+    }
+    return ErrExited
+}
+
+// kill stops the program and waits, for at most the grace period, until it
+// has been reaped. A process it started may keep the output open longer.
+func (e *Engine) kill() {
+    _ = e.cmd.Process.Kill()
+    deadline := time.After(e.grace)
+    // Drain the output so the reader reaches its end and reaps the process.
+    for {
+        select {
+        case _, ok := <-e.lines:
+            if ok {
+                continue
+            }
+            select {
+            case <-e.exited:
+            case <-deadline:
+            }
+            return
+        case <-deadline:
+            return
+        }
+    }
+}
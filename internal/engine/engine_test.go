@@ -0,0 +1,186 @@
+package engine
+
+import (
+    "bufio"
+    "context"
+    "errors"
+    "fmt"
+    "os"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/jaminalder/codex-tic-tac-toe/internal/domain"
+    "github.com/jaminalder/codex-tic-tac-toe/internal/record"
+)
+
+// helperEnv selects how the test binary behaves when run as an engine.
+const helperEnv = "TTT_ENGINE_HELPER"
+
+// helperEngine returns the path and options that start this test binary as
+// an engine behaving as mode (see TestHelperProcess).
+func helperEngine(mode string) (string, Options) {
+    return os.Args[0], Options{
+        Args:  []string{"-test.run=^TestHelperProcess$"},
+        Env:   []string{helperEnv + "=" + mode},
+        Grace: 500 * time.Millisecond,
+    }
+}
+
+func startHelper(t *testing.T, mode string) *Engine {
+    t.Helper()
+    path, opts := helperEngine(mode)
+    e, err := Start(path, opts)
+    if err != nil {
+        t.Fatalf("start %s engine: %v", mode, err)
+    }
+    t.Cleanup(func() { e.Close() })
+    return e
+}
+
+// TestHelperProcess is not a real test: it is the engine program the other
+// tests start. It plays the first legal move, except that mode changes how
+// it answers go:
+//
+//	first         answers at once
+//	slow          answers only when told to stop
+//	silent        never answers
+//	crash         exits
+//	illegal       answers a1 whether or not it is free
+//	crash-once:F  exits unless file F exists, creating it
+func TestHelperProcess(t *testing.T) {
+    mode := os.Getenv(helperEnv)
+    if mode == "" {
+        return
+    }
+    mode, marker, _ := strings.Cut(mode, ":")
+    variant := domain.Standard
+    var best string
+    in := bufio.NewScanner(os.Stdin)
+    for in.Scan() {
+        cmd, arg, _ := strings.Cut(in.Text(), " ")
+        switch cmd {
+        case "ttt":
+            fmt.Println("id name helper")
+            fmt.Println("tttok")
+        case "isready":
+            fmt.Println("readyok")
+        case "newgame":
+            variant, _ = domain.ParseVariant(arg)
+        case "position":
+            g, err := domain.ParsePositionVariant(arg, variant)
+            if err != nil {
+                fmt.Fprintln(os.Stderr, err)
+                os.Exit(3)
+            }
+            best = record.MoveText(g.LegalMoves()[0], g.Geometry())
+        case "go":
+            fmt.Println("info thinking")
+            switch mode {
+            case "first":
+                fmt.Println("bestmove " + best)
+            case "crash":
+                os.Exit(1)
+            case "illegal":
+                fmt.Println("bestmove a1")
+            case "crash-once":
+                if _, err := os.Stat(marker); err != nil {
+                    os.WriteFile(marker, nil, 0o600)
+                    os.Exit(1)
+                }
+                fmt.Println("bestmove " + best)
+            }
+        case "stop":
+            if mode == "slow" {
+                fmt.Println("bestmove " + best)
+            }
+        case "quit":
+            os.Exit(0)
+        }
+    }
+    os.Exit(0)
+}
+
+func TestEngineHandshakeAndBestMove(t *testing.T) {
+    e := startHelper(t, "first")
+    if e.Name() != "helper" {
+        t.Fatalf("expected engine name helper, got %q", e.Name())
+    }
+    ctx := context.Background()
+    if err := e.NewGame(ctx, domain.Standard); err != nil {
+        t.Fatalf("new game: %v", err)
+    }
+    g, _ := domain.ParsePosition("X../.../... o")
+    m, err := e.BestMove(ctx, g, time.Second)
+    if err != nil {
+        t.Fatalf("best move: %v", err)
+    }
+    if m.Index != 1 || m.Side != domain.O {
+        t.Fatalf("expected O to play b1, got %+v", m)
+    }
+
+    if err := e.NewGame(ctx, domain.Qubic); err != nil {
+        t.Fatalf("new game: %v", err)
+    }
+    q := domain.NewVariant(domain.Qubic)
+    for i := 0; i < 5; i++ {
+        q.PlayAt(i, q.Turn)
+    }
+    m, err = e.BestMove(ctx, q, time.Second)
+    if err != nil || m.Index != 5 {
+        t.Fatalf("expected the first free Qubic cell, got %+v, err=%v", m, err)
+    }
+}
+
+func TestEngineStoppedAtTimeLimit(t *testing.T) {
+    e := startHelper(t, "slow")
+    g := domain.New()
+    m, err := e.BestMove(context.Background(), g, 20*time.Millisecond)
+    if err != nil || m.Index != 0 {
+        t.Fatalf("expected the move found when stopped, got %+v, err=%v", m, err)
+    }
+}
+
+func TestEngineKilledWhenStuck(t *testing.T) {
+    e := startHelper(t, "silent")
+    _, err := e.BestMove(context.Background(), domain.New(), 20*time.Millisecond)
+    if !errors.Is(err, ErrTimeout) {
+        t.Fatalf("expected ErrTimeout, got %v", err)
+    }
+    if _, err := e.BestMove(context.Background(), domain.New(), 20*time.Millisecond); !errors.Is(err, ErrExited) {
+        t.Fatalf("expected the killed engine to be gone, got %v", err)
+    }
+}
+
+func TestEngineCrash(t *testing.T) {
+    e := startHelper(t, "crash")
+    _, err := e.BestMove(context.Background(), domain.New(), time.Second)
+    if !errors.Is(err, ErrExited) {
+        t.Fatalf("expected ErrExited, got %v", err)
+    }
+}
+
+func TestEngineIllegalMove(t *testing.T) {
+    e := startHelper(t, "illegal")
+    g, _ := domain.ParsePosition("X../.../... o")
+    _, err := e.BestMove(context.Background(), g, time.Second)
+    if !errors.Is(err, ErrProtocol) {
+        t.Fatalf("expected ErrProtocol, got %v", err)
+    }
+}
+
+func TestEngineRefusesQuantum(t *testing.T) {
+    e := startHelper(t, "first")
+    if err := e.NewGame(context.Background(), domain.Quantum); !errors.Is(err, ErrUnsupported) {
+        t.Fatalf("expected ErrUnsupported, got %v", err)
+    }
+}
+
+func TestStartFailsWithoutHandshake(t *testing.T) {
+    path, opts := helperEngine("")
+    opts.Env = nil // the test binary then runs no tests and exits
+    opts.Args = []string{"-test.run=^$"}
+    if _, err := Start(path, opts); err == nil {
+        t.Fatal("expected a program that is not an engine to fail the handshake")
+    }
+}
@@ -0,0 +1,124 @@
+package engine
+
+import (
+    "context"
+    "errors"
+    "log/slog"
+    "time"
+
+    "github.com/jaminalder/codex-tic-tac-toe/internal/app"
+    "github.com/jaminalder/codex-tic-tac-toe/internal/domain"
+)
+
+// ErrNoSeat is returned by Player.Play when both seats are taken.
+var ErrNoSeat = errors.New("no free seat for the engine")
+
+// DefaultMoveTime is the thinking time per move when Player.MoveTime is not set.
+const DefaultMoveTime = time.Second
+
+// Player seats an engine program in a game and plays its moves through the
+// service, with the same seat and turn checks as any other player.
+type Player struct {
+    Service *app.Service
+    // ID is the player ID the engine joins as.
+    ID string
+    // Path and Options start the engine program.
+    Path    string
+    Options Options
+    // MoveTime is the time allowed per move; DefaultMoveTime when zero.
+    MoveTime time.Duration
+    // Restarts is how many times an engine that crashed, timed out or
+    // played an illegal move is restarted before Play gives up.
+    Restarts int
+    // Log receives the player's log lines; slog.Default() when nil.
+    Log *slog.Logger
+}
+
+// Play joins game id and plays the engine's moves, through
+// app.Service.Drive, until the game is over, ctx is done, or the engine has
+// failed more often than Restarts allows. It returns nil once the game is
+// over.
+func (p *Player) Play(ctx context.Context, id string) error {
+    log := p.Log
+    if log == nil {
+        log = slog.Default()
+    }
+    seat, gs, err := p.Service.Join(ctx, id, p.ID)
+    if err != nil {
+        return err
+    }
+    if seat == domain.Empty {
+        return ErrNoSeat
+    }
+    if gs.Game.Variant == domain.Quantum {
+        return ErrUnsupported
+    }
+    m := &mover{p: p, ctx: ctx, log: log.With("game_id", id, "player_id", p.ID), seat: gs.SeatName(seat)}
+    defer m.close()
+    return p.Service.Drive(ctx, id, p.ID, m)
+}
+
+// mover picks a Player's moves with its engine program, starting it on the
+// first move and restarting it after a failure while Restarts allows.
+type mover struct {
+    p        *Player
+    ctx      context.Context
+    log      *slog.Logger
+    seat     string
+    eng      *Engine
+    failures int
+}
+
+func (m *mover) BestMove(g domain.Game) (domain.Move, error) {
+    moveTime := m.p.MoveTime
+    if moveTime <= 0 {
+        moveTime = DefaultMoveTime
+    }
+    for {
+        if m.eng == nil {
+            eng, err := Start(m.p.Path, m.p.Options)
+            if err != nil {
+                return domain.Move{}, err
+            }
+            m.eng = eng
+            if err := eng.NewGame(m.ctx, g.Variant); err != nil {
+                if m.ctx.Err() != nil {
+                    return domain.Move{}, m.ctx.Err()
+                }
+                if !m.fail(err) {
+                    return domain.Move{}, err
+                }
+                continue
+            }
+            m.log.Info("engine started", "engine", eng.Name(), "seat", m.seat)
+        }
+        // Engine.BestMove rejects illegal moves, so the service takes any
+        // move returned here unless the game moved on meanwhile.
+        mv, err := m.eng.BestMove(m.ctx, g, moveTime)
+        if m.ctx.Err() != nil {
+            return domain.Move{}, m.ctx.Err()
+        }
+        if err == nil {
+            return mv, nil
+        }
+        if !m.fail(err) {
+            return domain.Move{}, err
+        }
+    }
+}
+
+// fail shuts down the failed engine, reporting whether a fresh one may be
+// started.
+func (m *mover) fail(err error) bool {
+    m.log.Warn("engine failed", "err", err, "failures", m.failures+1)
+    m.close()
+    m.failures++
+    return m.failures <= m.p.Restarts
+}
+
+func (m *mover) close() {
+    if m.eng != nil {
+        m.eng.Close()
+        m.eng = nil
+    }
+}
@@ -0,0 +1,100 @@
+package engine
+
+import (
+    "context"
+    "errors"
+    "path/filepath"
+    "testing"
+    "time"
+
+    "github.com/jaminalder/codex-tic-tac-toe/internal/app"
+    "github.com/jaminalder/codex-tic-tac-toe/internal/domain"
+)
+
+func newPlayer(svc *app.Service, mode string) *Player {
+    path, opts := helperEngine(mode)
+    return &Player{Service: svc, ID: "engine", Path: path, Options: opts, MoveTime: time.Second}
+}
+
+// waitFor polls game id until ok accepts it.
+func waitFor(t *testing.T, svc *app.Service, id string, ok func(app.GameState) bool) app.GameState {
+    t.Helper()
+    deadline := time.Now().Add(5 * time.Second)
+    for time.Now().Before(deadline) {
+        if gs, found := svc.Get(id); found && ok(*gs) {
+            return *gs
+        }
+        time.Sleep(5 * time.Millisecond)
+    }
+    t.Fatal("timed out waiting for the engine")
+    return app.GameState{}
+}
+
+func TestPlayerPlaysAGame(t *testing.T) {
+    svc := app.NewService()
+    gs, _ := svc.CreateGame(context.Background())
+    p := newPlayer(svc, "first")
+    done := make(chan error, 1)
+    go func() { done <- p.Play(context.Background(), gs.ID) }()
+
+    waitFor(t, svc, gs.ID, func(gs app.GameState) bool { return gs.PlayerOf(domain.X) == "engine" })
+    if side, _, _ := svc.Join(context.Background(), gs.ID, "human"); side != domain.O {
+        t.Fatalf("expected the human to get O, got %v", side)
+    }
+    // The engine fills the top row while O plays the middle one.
+    for _, c := range []int{0, 1} {
+        waitFor(t, svc, gs.ID, func(gs app.GameState) bool { return gs.Game.Turn == domain.O })
+        if _, err := svc.Play(context.Background(), gs.ID, "human", 1, c); err != nil {
+            t.Fatalf("human move: %v", err)
+        }
+    }
+    select {
+    case err := <-done:
+        if err != nil {
+            t.Fatalf("expected Play to end cleanly, got %v", err)
+        }
+    case <-time.After(5 * time.Second):
+        t.Fatal("Play did not return after the game ended")
+    }
+    final, _ := svc.Get(gs.ID)
+    if final.Game.Winner != domain.X {
+        t.Fatalf("expected the engine to win, got %+v", final.Game)
+    }
+}
+
+func TestPlayerRestartsCrashedEngine(t *testing.T) {
+    svc := app.NewService()
+    gs, _ := svc.CreateGame(context.Background())
+    p := newPlayer(svc, "crash-once:"+filepath.Join(t.TempDir(), "crashed"))
+    p.Restarts = 1
+    ctx, cancel := context.WithCancel(context.Background())
+    done := make(chan error, 1)
+    go func() { done <- p.Play(ctx, gs.ID) }()
+
+    waitFor(t, svc, gs.ID, func(gs app.GameState) bool { return gs.Game.Moves == 1 })
+    cancel()
+    if err := <-done; !errors.Is(err, context.Canceled) {
+        t.Fatalf("expected Play to stop with the context, got %v", err)
+    }
+}
+
+func TestPlayerGivesUpOnCrashingEngine(t *testing.T) {
+    svc := app.NewService()
+    gs, _ := svc.CreateGame(context.Background())
+    p := newPlayer(svc, "crash")
+    p.Restarts = 1
+    err := p.Play(context.Background(), gs.ID)
+    if !errors.Is(err, ErrExited) {
+        t.Fatalf("expected ErrExited after the restarts ran out, got %v", err)
+    }
+}
+
+func TestPlayerNeedsASeat(t *testing.T) {
+    svc := app.NewService()
+    gs, _ := svc.CreateGame(context.Background())
+    svc.Join(context.Background(), gs.ID, "a")
+    svc.Join(context.Background(), gs.ID, "b")
+    if err := newPlayer(svc, "first").Play(context.Background(), gs.ID); !errors.Is(err, ErrNoSeat) {
+        t.Fatalf("expected ErrNoSeat, got %v", err)
+    }
+}
@@ -0,0 +1,27 @@
+// Package logging builds the structured loggers used by the binaries.
+package logging
+
+import (
+    "fmt"
+    "io"
+    "log/slog"
+    "strings"
+)
+
+// New returns a slog.Logger writing to w in the given format ("text" or "json")
+// at the given level ("debug", "info", "warn" or "error").
+func New(w io.Writer, format, level string) (*slog.Logger, error) {
+    var lvl slog.Level
+    if err := lvl.UnmarshalText([]byte(level)); err != nil {
+        return nil, fmt.Errorf("invalid log level %q", level)
+    }
+    opts := &slog.HandlerOptions{Level: lvl}
+    switch strings.ToLower(format) {
+    case "", "text":
+        return slog.New(slog.NewTextHandler(w, opts)), nil
+    case "json":
+        return slog.New(slog.NewJSONHandler(w, opts)), nil
+    default:
+        return nil, fmt.Errorf("invalid log format %q (want text or json)", format)
+    }
+}
@@ -0,0 +1,50 @@
+package logging
+
+import (
+    "bytes"
+    "encoding/json"
+    "strings"
+    "testing"
+)
+
+func TestNewJSONRespectsLevel(t *testing.T) {
+    var buf bytes.Buffer
+    log, err := New(&buf, "json", "warn")
+    if err != nil {
+        t.Fatalf("New: %v", err)
+    }
+    log.Info("hidden")
+    log.Warn("shown", "game_id", "g1")
+    lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+    if len(lines) != 1 {
+        t.Fatalf("expected one record, got %q", buf.String())
+    }
+    var rec map[string]any
+    if err := json.Unmarshal([]byte(lines[0]), &rec); err != nil {
+        t.Fatalf("expected JSON record: %v", err)
+    }
+    if rec["msg"] != "shown" || rec["game_id"] != "g1" {
+        t.Fatalf("unexpected record: %v", rec)
+    }
+}
+
+func TestNewText(t *testing.T) {
+    var buf bytes.Buffer
+    log, err := New(&buf, "text", "DEBUG")
+    if err != nil {
+        t.Fatalf("New: %v", err)
+    }
+    log.Debug("hello", "player_id", "p1")
+    if !strings.Contains(buf.String(), "msg=hello player_id=p1") {
+        t.Fatalf("unexpected text output: %q", buf.String())
+    }
+}
+
+func TestNewRejectsBadConfig(t *testing.T) {
+    if _, err := New(nil, "xml", "info"); err == nil {
+        t.Fatalf("expected error for unknown format")
+    }
+    if _, err := New(nil, "text", "loud"); err == nil {
+        t.Fatalf("expected error for unknown level")
+    }
+}
@@ -0,0 +1,103 @@
+package record
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+)
+
+// Parse decodes a record. It is lenient about layout: tag values may be
+// unquoted, tag names are case-insensitive, line endings and blank lines are
+// free-form, {brace} and ; line comments are skipped, move numbers ("1.",
+// "1...") are optional and may be glued to the move, marks prefixed to moves
+// may be either case, and the trailing result
+// token may be omitted. A result token in the move text fills in a missing
+// Result tag.
+func Parse(src string) (Record, error) {
+    src = strings.TrimPrefix(src, "\ufeff")
+    src = strings.ReplaceAll(src, "\r\n", "\n")
+    var r Record
+    var movetext strings.Builder
+    inComment := false
+    for n, line := range strings.Split(src, "\n") {
+        trimmed := strings.TrimSpace(line)
+        if !inComment && strings.HasPrefix(trimmed, "[") {
+            t, err := parseTag(trimmed)
+            if err != nil {
+                return Record{}, fmt.Errorf("%w: line %d: %v", ErrInvalidRecord, n+1, err)
+            }
+            r.SetTag(t.Name, t.Value)
+            continue
+        }
+        for i := 0; i < len(line); i++ {
+            c := line[i]
+            switch {
+            case inComment:
+                if c == '}' {
+                    inComment = false
+                    movetext.WriteByte(' ')
+                }
+            case c == '{':
+                inComment = true
+            case c == ';':
+                i = len(line)
+            default:
+                movetext.WriteByte(c)
+            }
+        }
+        movetext.WriteByte(' ')
+    }
+    if inComment {
+        return Record{}, fmt.Errorf("%w: unterminated comment", ErrInvalidRecord)
+    }
+    geo := r.geometry()
+    for _, tok := range strings.Fields(movetext.String()) {
+        // Strip a leading move number such as "1." or "1...".
+        if dot := strings.LastIndexByte(tok, '.'); dot >= 0 {
+            if _, err := strconv.Atoi(strings.TrimRight(tok[:dot+1], ".")); err == nil {
+                tok = tok[dot+1:]
+            }
+        }
+        if tok == "" {
+            continue
+        }
+        switch tok {
+        case ResultXWon, ResultOWon, ResultDraw, ResultInProgress, "½-½":
+            if tok == "½-½" {
+                tok = ResultDraw
+            }
+            if r.Tag("Result") == "" {
+                r.SetTag("Result", tok)
+            }
+            continue
+        }
+        m, err := ParseMove(tok, geo)
+        if err != nil {
+            return Record{}, err
+        }
+        r.Moves = append(r.Moves, m)
+    }
+    return r, nil
+}
+
+// parseTag parses `[Name "value"]`; the quotes around value are optional.
+func parseTag(s string) (Tag, error) {
+    if !strings.HasSuffix(s, "]") {
+        return Tag{}, fmt.Errorf("unterminated tag %q", s)
+    }
+    body := strings.TrimSpace(s[1 : len(s)-1])
+    name, value, _ := strings.Cut(body, " ")
+    if name == "" {
+        return Tag{}, fmt.Errorf("empty tag %q", s)
+    }
+    value = strings.TrimSpace(value)
+    if strings.HasPrefix(value, `"`) {
+        uq, err := strconv.Unquote(value)
+        if err != nil {
+            // Tolerate unescaped content between the outer quotes.
+            uq = strings.TrimSuffix(strings.TrimPrefix(value, `"`), `"`)
+        }
+        value = uq
+    }
+    return Tag{Name: name, Value: value}, nil
+}
@@ -0,0 +1,298 @@
+// Package record reads and writes portable game records: a block of
+// [Name "value"] tag lines followed by the numbered move list in coordinate
+// notation, similar in spirit to PGN.
+//
+//	[Event "Casual game"]
+//	[Date "2026.10.18"]
+//	[X "alice"]
+//	[O "bob"]
+//	[Variant "standard"]
+//	[TimeControl "-"]
+//	[Result "1-0"]
+//
+//	1. b2 a1 2. c3 a3 3. a2 c1 4. c2 1-0
+//
+// Columns are lettered from the left (a-c on the classic board) and rows
+// numbered from the top, so a1 is the top-left cell. On the layered Qubic
+// board the coordinate names the layer after a colon, "b2:3" being b2 on the
+// third layer from the top. In variants where the mover picks what to place
+// each move is prefixed with it: the mark in Wild and Order and Chaos
+// ("Ob2"), the number in Numerical ("5b2"). In Order and Chaos the X tag
+// names the Order player, the O tag the Chaos player, and 1-0 is a win for
+// Order. A record of a game that did not start from the empty board with X
+// to move carries the starting position in a Position tag. A game stopped
+// before its end, e.g. by an administrator, has the result * and a
+// Termination tag saying why.
+package record
+
+import (
+    "errors"
+    "fmt"
+    "io"
+    "strconv"
+    "strings"
+
+    "github.com/jaminalder/codex-tic-tac-toe/internal/domain"
+)
+
+// Result tokens, as written in the Result tag and at the end of the move list.
+const (
+    ResultXWon       = "1-0"
+    ResultOWon       = "0-1"
+    ResultDraw       = "1/2-1/2"
+    ResultInProgress = "*"
+)
+
+// ErrInvalidRecord is returned (wrapped) when a record cannot be parsed or replayed.
+var ErrInvalidRecord = errors.New("invalid game record")
+
+// Tag is one header line of a record.
+type Tag struct {
+    Name  string
+    Value string
+}
+
+// Record is a parsed or generated game record.
+type Record struct {
+    // Tags in the order they are written.
+    Tags []Tag
+    // Moves in the order they were played. Mark is Empty when the record
+    // does not name it, meaning the mover's own mark.
+    Moves []domain.Move
+}
+
+// standardTags are written first, in this order, when present.
+var standardTags = []string{"Event", "Site", "Date", "X", "O", "Variant", "TimeControl", "Result", "Position"}
+
+// Tag returns the value of the named tag, or "" if it is absent.
+func (r Record) Tag(name string) string {
+    for _, t := range r.Tags {
+        if strings.EqualFold(t.Name, name) {
+            return t.Value
+        }
+    }
+    return ""
+}
+
+// SetTag replaces the named tag or appends it.
+func (r *Record) SetTag(name, value string) {
+    for i, t := range r.Tags {
+        if strings.EqualFold(t.Name, name) {
+            r.Tags[i].Value = value
+            return
+        }
+    }
+    r.Tags = append(r.Tags, Tag{Name: name, Value: value})
+}
+
+// FromGame builds a record of g with the given tags. The Result, Variant and
+// (for games not started from the empty board with X to move) Position tags
+// are filled in from the game itself.
+func FromGame(g domain.Game, tags ...Tag) Record {
+    r := Record{Tags: append([]Tag(nil), tags...)}
+    for _, m := range g.History {
+        mv := domain.Move{Index: m.Index}
+        if g.Variant.ChoosesMark() {
+            mv.Mark = m.Mark
+        }
+        r.Moves = append(r.Moves, mv)
+    }
+    if r.Tag("Variant") == "" {
+        r.SetTag("Variant", g.Variant.String())
+    }
+    r.SetTag("Result", Result(g.Outcome))
+    if g.Outcome == domain.Aborted && r.Tag("Termination") == "" {
+        r.SetTag("Termination", "abandoned")
+    }
+    if start := g.Start(); start.Moves > 0 || start.Turn == domain.O {
+        r.SetTag("Position", domain.FormatPosition(start))
+    }
+    return r
+}
+
+// Result returns the result token for an outcome.
+func Result(o domain.Outcome) string {
+    switch o {
+    case domain.XWon:
+        return ResultXWon
+    case domain.OWon:
+        return ResultOWon
+    case domain.Draw:
+        return ResultDraw
+    default:
+        // in progress or aborted
+        return ResultInProgress
+    }
+}
+
+// Game replays the record from its starting position. The Result tag is
+// informational; the returned game's outcome comes from the moves, except
+// that a game the moves leave open is Aborted when the record is terminated
+// with the result *.
+func (r Record) Game() (domain.Game, error) {
+    v, err := domain.ParseVariant(r.Tag("Variant"))
+    if err != nil {
+        return domain.Game{}, fmt.Errorf("%w: %v", ErrInvalidRecord, err)
+    }
+    g := domain.NewVariant(v)
+    if pos := r.Tag("Position"); pos != "" {
+        if g, err = domain.ParsePositionVariant(pos, v); err != nil {
+            return domain.Game{}, fmt.Errorf("%w: %v", ErrInvalidRecord, err)
+        }
+    }
+    for i, m := range r.Moves {
+        mark := m.Mark
+        if mark == domain.Empty {
+            mark = g.Turn
+        }
+        if err := g.PlayAt(m.Index, mark); err != nil {
+            return domain.Game{}, fmt.Errorf("%w: move %d (%s): %v", ErrInvalidRecord, i+1, MoveText(m, g.Geometry()), err)
+        }
+    }
+    if !g.Over && r.Tag("Termination") != "" && r.Tag("Result") == ResultInProgress {
+        g.End()
+    }
+    return g, nil
+}
+
+// geometry returns the board shape named by the Variant tag, falling back to
+// the 3x3 board when the tag is missing or unknown.
+func (r Record) geometry() *domain.Geometry {
+    v, _ := domain.ParseVariant(r.Tag("Variant"))
+    return v.Geometry()
+}
+
+// Coord returns the coordinate notation of a 3x3 board index, e.g. 4 -> "b2".
+func Coord(idx int) string {
+    return CoordIn(domain.Grid3, idx)
+}
+
+// ParseCoord parses 3x3 coordinate notation into a board index.
+func ParseCoord(s string) (int, error) {
+    return ParseCoordIn(domain.Grid3, s)
+}
+
+// CoordIn returns the coordinate notation of a board index of geo, adding
+// the layer (":1" upwards) on boards with several layers.
+func CoordIn(geo *domain.Geometry, idx int) string {
+    layer, r, c := geo.Coords(idx)
+    s := string(rune('a'+c)) + strconv.Itoa(r+1)
+    if geo.Layers > 1 {
+        s += ":" + strconv.Itoa(layer+1)
+    }
+    return s
+}
+
+// ParseCoordIn parses coordinate notation for geo into a board index. Upper-case
+// column letters are accepted.
+func ParseCoordIn(geo *domain.Geometry, s string) (int, error) {
+    bad := fmt.Errorf("%w: bad coordinate %q", ErrInvalidRecord, s)
+    cell, layerText := strings.ToLower(s), ""
+    if geo.Layers > 1 {
+        var ok bool
+        if cell, layerText, ok = strings.Cut(cell, ":"); !ok {
+            return 0, bad
+        }
+    }
+    if len(cell) != 2 {
+        return 0, bad
+    }
+    c, r, layer := int(cell[0]-'a'), int(cell[1]-'1'), 0
+    if layerText != "" {
+        n, err := strconv.Atoi(layerText)
+        if err != nil {
+            return 0, bad
+        }
+        layer = n - 1
+    }
+    if c < 0 || c >= geo.Cols || r < 0 || r >= geo.Rows || layer < 0 || layer >= geo.Layers {
+        return 0, bad
+    }
+    return geo.Index(layer, r, c), nil
+}
+
+// MoveText returns the notation of m on geo: its coordinate, prefixed with
+// the mark or number when one is named.
+func MoveText(m domain.Move, geo *domain.Geometry) string {
+    coord := CoordIn(geo, m.Index)
+    if n, ok := m.Mark.Number(); ok {
+        return strconv.Itoa(n) + coord
+    }
+    switch m.Mark {
+    case domain.X:
+        return "X" + coord
+    case domain.O:
+        return "O" + coord
+    default:
+        return coord
+    }
+}
+
+// ParseMove parses a move token on geo: a coordinate, optionally prefixed
+// with X, O or a number 1-9.
+func ParseMove(tok string, geo *domain.Geometry) (domain.Move, error) {
+    var m domain.Move
+    if tok == "" {
+        return m, fmt.Errorf("%w: empty move", ErrInvalidRecord)
+    }
+    switch c := tok[0]; {
+    case c == 'X' || c == 'x':
+        m.Mark, tok = domain.X, tok[1:]
+    case c == 'O' || c == 'o':
+        m.Mark, tok = domain.O, tok[1:]
+    case c >= '1' && c <= '9':
+        m.Mark, tok = domain.NumberCell(int(c-'0')), tok[1:]
+    }
+    idx, err := ParseCoordIn(geo, tok)
+    if err != nil {
+        return domain.Move{}, err
+    }
+    m.Index = idx
+    return m, nil
+}
+
+// Write encodes r to w: standard tags first, then any others in order, a
+// blank line, and the numbered move list ending in the result token.
+func Write(w io.Writer, r Record) error {
+    _, err := io.WriteString(w, r.String())
+    return err
+}
+
+// String returns the encoded record.
+func (r Record) String() string {
+    var b strings.Builder
+    written := map[string]bool{}
+    writeTag := func(t Tag) {
+        fmt.Fprintf(&b, "[%s %s]\n", t.Name, strconv.Quote(t.Value))
+        written[strings.ToLower(t.Name)] = true
+    }
+    for _, name := range standardTags {
+        for _, t := range r.Tags {
+            if strings.EqualFold(t.Name, name) {
+                writeTag(t)
+                break
+            }
+        }
+    }
+    for _, t := range r.Tags {
+        if !written[strings.ToLower(t.Name)] {
+            writeTag(t)
+        }
+    }
+    b.WriteByte('\n')
+    geo := r.geometry()
+    for i, m := range r.Moves {
+        if i%2 == 0 {
+            fmt.Fprintf(&b, "%d. ", i/2+1)
+        }
+        b.WriteString(MoveText(m, geo))
+        b.WriteByte(' ')
+    }
+    result := r.Tag("Result")
+    if result == "" {
+        result = ResultInProgress
+    }
+    b.WriteString(result)
+    b.WriteByte('\n')
+    return b.String()
+}
@@ -0,0 +1,297 @@
+package record
+
+import (
+    "errors"
+    "strings"
+    "testing"
+
+    "github.com/jaminalder/codex-tic-tac-toe/internal/domain"
+)
+
+func playAll(t *testing.T, g *domain.Game, coords ...string) {
+    t.Helper()
+    for _, c := range coords {
+        idx, err := ParseCoord(c)
+        if err != nil {
+            t.Fatalf("ParseCoord(%q): %v", c, err)
+        }
+        if err := g.Play(idx/3, idx%3); err != nil {
+            t.Fatalf("play %s: %v", c, err)
+        }
+    }
+}
+
+func TestCoordRoundTrip(t *testing.T) {
+    for idx := 0; idx < 9; idx++ {
+        got, err := ParseCoord(Coord(idx))
+        if err != nil || got != idx {
+            t.Fatalf("round trip of %d via %q gave %d, %v", idx, Coord(idx), got, err)
+        }
+    }
+    if Coord(0) != "a1" || Coord(5) != "c2" || Coord(6) != "a3" {
+        t.Fatalf("unexpected coordinates %s %s %s", Coord(0), Coord(5), Coord(6))
+    }
+    for _, bad := range []string{"", "d1", "a4", "a", "a10", "11"} {
+        if _, err := ParseCoord(bad); !errors.Is(err, ErrInvalidRecord) {
+            t.Fatalf("expected ErrInvalidRecord for %q, got %v", bad, err)
+        }
+    }
+}
+
+func TestWriteFinishedGame(t *testing.T) {
+    g := domain.New()
+    playAll(t, &g, "b2", "a1", "c3", "a3", "a2", "c1", "c2")
+    r := FromGame(g, Tag{"Event", "Casual game"}, Tag{"X", "alice"}, Tag{"O", "bob"}, Tag{"Date", "2026.10.18"}, Tag{"TimeControl", "-"})
+    want := `[Event "Casual game"]
+[Date "2026.10.18"]
+[X "alice"]
+[O "bob"]
+[Variant "standard"]
+[TimeControl "-"]
+[Result "1-0"]
+
+1. b2 a1 2. c3 a3 3. a2 c1 4. c2 1-0
+`
+    if got := r.String(); got != want {
+        t.Fatalf("unexpected record:\n%s\nwant:\n%s", got, want)
+    }
+}
+
+func TestAbortedGameHasNoResult(t *testing.T) {
+    g := domain.New()
+    playAll(t, &g, "b2", "a1")
+    if err := g.End(); err != nil {
+        t.Fatal(err)
+    }
+    r := FromGame(g)
+    if r.Tag("Result") != ResultInProgress || r.Tag("Termination") != "abandoned" {
+        t.Fatalf("unexpected tags for an aborted game: %+v", r.Tags)
+    }
+    parsed, err := Parse(r.String())
+    if err != nil {
+        t.Fatalf("Parse: %v", err)
+    }
+    replayed, err := parsed.Game()
+    if err != nil {
+        t.Fatalf("Game: %v", err)
+    }
+    if !replayed.Over || replayed.Outcome != domain.Aborted || replayed.Moves != 2 {
+        t.Fatalf("expected the replay to end aborted, got %+v", replayed)
+    }
+}
+
+func TestRoundTripReplaysGame(t *testing.T) {
+    g := domain.New()
+    playAll(t, &g, "a1", "b2", "c3", "b1", "b3", "a3", "c1", "c2", "a2")
+    var b strings.Builder
+    if err := Write(&b, FromGame(g, Tag{"Event", `quoted "name"`})); err != nil {
+        t.Fatalf("Write: %v", err)
+    }
+    r, err := Parse(b.String())
+    if err != nil {
+        t.Fatalf("Parse: %v", err)
+    }
+    if r.Tag("Event") != `quoted "name"` || r.Tag("Result") != ResultDraw {
+        t.Fatalf("unexpected tags %v", r.Tags)
+    }
+    replay, err := r.Game()
+    if err != nil {
+        t.Fatalf("Game: %v", err)
+    }
+    if replay.Board != g.Board || replay.Outcome != domain.Draw || len(replay.History) != 9 {
+        t.Fatalf("replay differs: %+v", replay)
+    }
+}
+
+func TestPositionTagForGamesFromPosition(t *testing.T) {
+    g, err := domain.ParsePosition("X.O/.X./... o")
+    if err != nil {
+        t.Fatalf("ParsePosition: %v", err)
+    }
+    playAll(t, &g, "c3", "a3")
+    r := FromGame(g)
+    if r.Tag("Position") != "X.O/.X./... o" || len(r.Moves) != 2 {
+        t.Fatalf("unexpected record %+v", r)
+    }
+    parsed, err := Parse(r.String())
+    if err != nil {
+        t.Fatalf("Parse: %v", err)
+    }
+    replay, err := parsed.Game()
+    if err != nil {
+        t.Fatalf("Game: %v", err)
+    }
+    if replay.Board != g.Board || replay.Turn != g.Turn {
+        t.Fatalf("replay differs: %+v vs %+v", replay, g)
+    }
+}
+
+func TestParseIsTolerant(t *testing.T) {
+    src := "\ufeff[x alice]\r\n[ O  \"bob\" ]\r\n\r\n; a line comment\r\n" +
+        "1.B2 {centre first} a1\n2... c3\n\n a3 3. A2 {multi\nline} c1 4.c2\n"
+    r, err := Parse(src)
+    if err != nil {
+        t.Fatalf("Parse: %v", err)
+    }
+    if r.Tag("X") != "alice" || r.Tag("O") != "bob" || r.Tag("Result") != "" {
+        t.Fatalf("unexpected tags %v", r.Tags)
+    }
+    g, err := r.Game()
+    if err != nil {
+        t.Fatalf("Game: %v", err)
+    }
+    if g.Outcome != domain.XWon || len(g.History) != 7 {
+        t.Fatalf("expected X win after 7 moves, got %v after %d", g.Outcome, len(g.History))
+    }
+}
+
+func TestParseResultTokenFillsTag(t *testing.T) {
+    r, err := Parse("1. a1 b2 *")
+    if err != nil {
+        t.Fatalf("Parse: %v", err)
+    }
+    if r.Tag("Result") != ResultInProgress || len(r.Moves) != 2 {
+        t.Fatalf("unexpected record %+v", r)
+    }
+}
+
+func TestInvalidRecords(t *testing.T) {
+    cases := map[string]string{
+        "bad token":      "1. b2 zz",
+        "occupied cell":  "1. b2 b2",
+        "after game end": "1. a1 b1 2. a2 b2 3. a3 c3",
+        "open comment":   "1. b2 {oops",
+        "bad tag":        "[Event \"x\"\n1. b2",
+        "bad position":   "[Position \"XXX/XXX/XXX -\"]\n",
+        "variant":        "[Variant \"quantum\"]\n1. b2",
+    }
+    for name, src := range cases {
+        r, err := Parse(src)
+        if err == nil {
+            _, err = r.Game()
+        }
+        if !errors.Is(err, ErrInvalidRecord) {
+            t.Fatalf("%s: expected ErrInvalidRecord, got %v", name, err)
+        }
+    }
+}
+
+func TestVariantRoundTrip(t *testing.T) {
+    g := domain.NewVariant(domain.Misere)
+    playAll(t, &g, "a1", "a2", "b1", "b2", "c1")
+    r := FromGame(g)
+    if r.Tag("Variant") != "misere" || r.Tag("Result") != ResultOWon {
+        t.Fatalf("unexpected tags %v", r.Tags)
+    }
+    parsed, err := Parse(r.String())
+    if err != nil {
+        t.Fatalf("Parse: %v", err)
+    }
+    replay, err := parsed.Game()
+    if err != nil {
+        t.Fatalf("Game: %v", err)
+    }
+    if replay.Variant != domain.Misere || replay.Outcome != domain.OWon {
+        t.Fatalf("replay lost the variant: %+v", replay)
+    }
+}
+
+func TestWildMovesCarryMarks(t *testing.T) {
+    g := domain.NewVariant(domain.Wild)
+    for _, m := range []domain.Move{{Index: 0, Mark: domain.O}, {Index: 1, Mark: domain.O}, {Index: 2, Mark: domain.O}} {
+        if err := g.PlayMark(m.Index/3, m.Index%3, m.Mark); err != nil {
+            t.Fatalf("PlayMark: %v", err)
+        }
+    }
+    text := FromGame(g).String()
+    if !strings.Contains(text, "1. Oa1 Ob1 2. Oc1 1-0") {
+        t.Fatalf("expected marked moves, got:\n%s", text)
+    }
+    parsed, err := Parse(strings.Replace(text, "Ob1", "ob1", 1))
+    if err != nil {
+        t.Fatalf("Parse: %v", err)
+    }
+    replay, err := parsed.Game()
+    if err != nil {
+        t.Fatalf("Game: %v", err)
+    }
+    if replay.Board != g.Board || replay.Outcome != domain.XWon {
+        t.Fatalf("replay differs: %+v", replay)
+    }
+}
+
+func TestNumericalMovesCarryNumbers(t *testing.T) {
+    g := domain.NewVariant(domain.Numerical)
+    for _, m := range []domain.Move{{Index: 0, Mark: domain.NumberCell(5)}, {Index: 4, Mark: domain.NumberCell(2)}, {Index: 1, Mark: domain.NumberCell(3)}, {Index: 8, Mark: domain.NumberCell(4)}, {Index: 2, Mark: domain.NumberCell(7)}} {
+        if err := g.PlayMark(m.Index/3, m.Index%3, m.Mark); err != nil {
+            t.Fatalf("PlayMark: %v", err)
+        }
+    }
+    text := FromGame(g).String()
+    if !strings.Contains(text, `[Variant "numerical"]`) || !strings.Contains(text, "1. 5a1 2b2 2. 3b1 4c3 3. 7c1 1-0") {
+        t.Fatalf("unexpected record:\n%s", text)
+    }
+    parsed, err := Parse(text)
+    if err != nil {
+        t.Fatalf("Parse: %v", err)
+    }
+    replay, err := parsed.Game()
+    if err != nil {
+        t.Fatalf("Game: %v", err)
+    }
+    if replay.Board != g.Board || replay.Outcome != domain.XWon {
+        t.Fatalf("replay differs: %+v", replay)
+    }
+}
+
+func TestQubicMovesNameTheLayer(t *testing.T) {
+    geo := domain.Cube4
+    if got := CoordIn(geo, geo.Index(2, 1, 3)); got != "d2:3" {
+        t.Fatalf("CoordIn = %q, want d2:3", got)
+    }
+    for _, bad := range []string{"b2", "b2:0", "b2:5", "e1:1", "a5:1", "b2:x"} {
+        if _, err := ParseCoordIn(geo, bad); !errors.Is(err, ErrInvalidRecord) {
+            t.Fatalf("expected ErrInvalidRecord for %q, got %v", bad, err)
+        }
+    }
+
+    g := domain.NewVariant(domain.Qubic)
+    for i := 0; i < 4; i++ {
+        _ = g.PlayAt(geo.Index(i, 0, 0), domain.X)
+        if i < 3 {
+            _ = g.PlayAt(geo.Index(i, 3, 3), domain.O)
+        }
+    }
+    text := FromGame(g).String()
+    if !strings.Contains(text, "1. a1:1 d4:1 2. a1:2 d4:2") || !strings.Contains(text, "[Variant \"qubic\"]") {
+        t.Fatalf("unexpected record %q", text)
+    }
+    parsed, err := Parse(text)
+    if err != nil {
+        t.Fatalf("Parse: %v", err)
+    }
+    back, err := parsed.Game()
+    if err != nil {
+        t.Fatalf("Game: %v", err)
+    }
+    if back.Board != g.Board || back.Winner != domain.X || back.Variant != domain.Qubic {
+        t.Fatalf("replayed game differs: %+v", back)
+    }
+}
+
+func TestOFirstGameKeepsItsStart(t *testing.T) {
+    g := domain.New()
+    g.Turn = domain.O
+    playAll(t, &g, "b2", "a1", "c1")
+    r := FromGame(g)
+    if got := r.Tag("Position"); got != ".../.../... o" {
+        t.Fatalf("expected the empty board with O to move, got %q", got)
+    }
+    back, err := r.Game()
+    if err != nil {
+        t.Fatalf("Game: %v", err)
+    }
+    if back.Board != g.Board || back.Turn != g.Turn || back.History[0].Side != domain.O {
+        t.Fatalf("replayed game differs: %+v", back)
+    }
+}
@@ -0,0 +1,103 @@
+package web
+
+import (
+    "crypto/subtle"
+    "errors"
+    "net/http"
+    "runtime"
+    "strings"
+
+    "github.com/go-chi/chi/v5"
+    "github.com/jaminalder/codex-tic-tac-toe/internal/app"
+    "github.com/jaminalder/codex-tic-tac-toe/internal/domain"
+)
+
+func (h *handlers) healthz(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+    _, _ = w.Write([]byte("ok\n"))
+}
+
+// readyz fails once the service is shutting down or its store is unavailable.
+func (h *handlers) readyz(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+    if err := h.svc.Ping(); err != nil {
+        w.WriteHeader(http.StatusServiceUnavailable)
+        _, _ = w.Write([]byte("not ready: " + err.Error() + "\n"))
+        return
+    }
+    _, _ = w.Write([]byte("ready\n"))
+}
+
+// adminAuth accepts the token as a bearer token or as the basic auth password.
+// Cross-site form posts are refused so a logged-in browser cannot be driven by another site.
+func adminAuth(token string) func(http.Handler) http.Handler {
+    return func(next http.Handler) http.Handler {
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            var got string
+            if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+                got = bearer
+            } else if _, pass, ok := r.BasicAuth(); ok {
+                got = pass
+            }
+            if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+                w.Header().Set("WWW-Authenticate", `Basic realm="admin"`)
+                http.Error(w, "unauthorized", http.StatusUnauthorized)
+                return
+            }
+            if site := r.Header.Get("Sec-Fetch-Site"); r.Method != http.MethodGet && site != "" && site != "same-origin" && site != "none" {
+                http.Error(w, "cross-site request refused", http.StatusForbidden)
+                return
+            }
+            next.ServeHTTP(w, r)
+        })
+    }
+}
+
+func (h *handlers) adminIndex(w http.ResponseWriter, r *http.Request) {
+    var mem runtime.MemStats
+    runtime.ReadMemStats(&mem)
+    data := struct {
+        Stats      app.Stats
+        Games      []app.GameSummary
+        HeapAlloc  uint64
+        Sys        uint64
+        NumGC      uint32
+        Goroutines int
+    }{
+        Stats:      h.svc.Stats(),
+        Games:      h.svc.List(),
+        HeapAlloc:  mem.HeapAlloc,
+        Sys:        mem.Sys,
+        NumGC:      mem.NumGC,
+        Goroutines: runtime.NumGoroutine(),
+    }
+    w.Header().Set("Content-Type", "text/html; charset=utf-8")
+    w.Header().Set("Cache-Control", "no-store")
+    _, _ = w.Write(h.render(r.Context(), h.templates().admin, data))
+}
+
+func (h *handlers) adminEnd(w http.ResponseWriter, r *http.Request) {
+    id := chi.URLParam(r, "id")
+    _, err := h.svc.EndGame(r.Context(), id)
+    switch {
+    case errors.Is(err, app.ErrNotFound):
+        http.NotFound(w, r)
+        return
+    case err != nil && !errors.Is(err, domain.ErrGameOver):
+        h.logger(r.Context()).Error("force-end failed", "err", err)
+        http.Error(w, "failed to end game", http.StatusInternalServerError)
+        return
+    }
+    h.logger(r.Context()).Info("admin ended game")
+    http.Redirect(w, r, "/admin", http.StatusSeeOther)
+}
+
+func (h *handlers) adminDelete(w http.ResponseWriter, r *http.Request) {
+    id := chi.URLParam(r, "id")
+    if err := h.svc.DeleteGame(r.Context(), id); err != nil {
+        http.NotFound(w, r)
+        return
+    }
+    h.logger(r.Context()).Info("admin deleted game")
+    http.Redirect(w, r, "/admin", http.StatusSeeOther)
+}
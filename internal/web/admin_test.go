@@ -0,0 +1,132 @@
+package web
+
+import (
+    "context"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+
+    "github.com/jaminalder/codex-tic-tac-toe/internal/app"
+)
+
+func newAdminServer(t *testing.T) (*app.Service, http.Handler) {
+    t.Helper()
+    s := app.NewService()
+    return s, newServerWithOptions(t, s, Options{AdminToken: "secret"})
+}
+
+func TestHealthAndReadiness(t *testing.T) {
+    svc, h := newTestServer(t)
+    for _, path := range []string{"/healthz", "/readyz"} {
+        rr := httptest.NewRecorder()
+        h.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+        if rr.Code != http.StatusOK {
+            t.Fatalf("%s: expected 200, got %d", path, rr.Code)
+        }
+    }
+    svc.Close()
+    rr := httptest.NewRecorder()
+    h.ServeHTTP(rr, httptest.NewRequest("GET", "/readyz", nil))
+    if rr.Code != http.StatusServiceUnavailable {
+        t.Fatalf("expected 503 after shutdown, got %d", rr.Code)
+    }
+    rr = httptest.NewRecorder()
+    h.ServeHTTP(rr, httptest.NewRequest("GET", "/healthz", nil))
+    if rr.Code != http.StatusOK {
+        t.Fatalf("liveness should stay 200 while draining, got %d", rr.Code)
+    }
+}
+
+func TestAdminRequiresToken(t *testing.T) {
+    _, h := newAdminServer(t)
+    rr := httptest.NewRecorder()
+    h.ServeHTTP(rr, httptest.NewRequest("GET", "/admin", nil))
+    if rr.Code != http.StatusUnauthorized {
+        t.Fatalf("expected 401 without token, got %d", rr.Code)
+    }
+    req := httptest.NewRequest("GET", "/admin", nil)
+    req.SetBasicAuth("admin", "wrong")
+    rr = httptest.NewRecorder()
+    h.ServeHTTP(rr, req)
+    if rr.Code != http.StatusUnauthorized {
+        t.Fatalf("expected 401 with wrong password, got %d", rr.Code)
+    }
+}
+
+func TestAdminDisabledWithoutToken(t *testing.T) {
+    _, h := newTestServer(t)
+    rr := httptest.NewRecorder()
+    h.ServeHTTP(rr, httptest.NewRequest("GET", "/admin", nil))
+    if rr.Code != http.StatusNotFound {
+        t.Fatalf("expected 404 when admin disabled, got %d", rr.Code)
+    }
+}
+
+func TestAdminListsGamesAndEndsAndDeletes(t *testing.T) {
+    svc, h := newAdminServer(t)
+    gs, _ := svc.CreateGame(context.Background())
+    svc.Join(context.Background(), gs.ID, "p1")
+
+    req := httptest.NewRequest("GET", "/admin", nil)
+    req.Header.Set("Authorization", "Bearer secret")
+    rr := httptest.NewRecorder()
+    h.ServeHTTP(rr, req)
+    if rr.Code != http.StatusOK {
+        t.Fatalf("expected 200, got %d", rr.Code)
+    }
+    body := rr.Body.String()
+    if !strings.Contains(body, gs.ID) || !strings.Contains(body, "Heap:") || !strings.Contains(body, "in progress") || !strings.Contains(body, "/end") {
+        t.Fatalf("expected game row and memory stats; body=%q", body)
+    }
+
+    req = httptest.NewRequest("POST", "/admin/games/"+gs.ID+"/end", nil)
+    req.SetBasicAuth("admin", "secret")
+    rr = httptest.NewRecorder()
+    h.ServeHTTP(rr, req)
+    if rr.Code != http.StatusSeeOther {
+        t.Fatalf("expected redirect after end, got %d", rr.Code)
+    }
+    if latest, _ := svc.Get(gs.ID); !latest.Game.Over || !latest.Aborted {
+        t.Fatalf("expected game force-ended")
+    }
+
+    req = httptest.NewRequest("POST", "/admin/games/"+gs.ID+"/delete", nil)
+    req.SetBasicAuth("admin", "secret")
+    rr = httptest.NewRecorder()
+    h.ServeHTTP(rr, req)
+    if rr.Code != http.StatusSeeOther {
+        t.Fatalf("expected redirect after delete, got %d", rr.Code)
+    }
+    if _, ok := svc.Get(gs.ID); ok {
+        t.Fatalf("expected game deleted")
+    }
+
+    // The board's event stream reconnecting gets 404 rather than a new game.
+    req = httptest.NewRequest("GET", "/game/"+gs.ID+"/events", nil)
+    req.Header.Set("Accept", "text/event-stream")
+    rr = httptest.NewRecorder()
+    h.ServeHTTP(rr, req)
+    if rr.Code != http.StatusNotFound {
+        t.Fatalf("expected 404 for a deleted game's events, got %d", rr.Code)
+    }
+    if _, ok := svc.Get(gs.ID); ok {
+        t.Fatalf("event stream recreated the deleted game")
+    }
+}
+
+func TestAdminRefusesCrossSitePost(t *testing.T) {
+    svc, h := newAdminServer(t)
+    gs, _ := svc.CreateGame(context.Background())
+    req := httptest.NewRequest("POST", "/admin/games/"+gs.ID+"/delete", nil)
+    req.SetBasicAuth("admin", "secret")
+    req.Header.Set("Sec-Fetch-Site", "cross-site")
+    rr := httptest.NewRecorder()
+    h.ServeHTTP(rr, req)
+    if rr.Code != http.StatusForbidden {
+        t.Fatalf("expected 403, got %d", rr.Code)
+    }
+    if _, ok := svc.Get(gs.ID); !ok {
+        t.Fatalf("game must survive a refused request")
+    }
+}
@@ -0,0 +1,288 @@
+package web
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "net/http"
+    "time"
+
+    "github.com/go-chi/chi/v5"
+    "github.com/jaminalder/codex-tic-tac-toe/internal/app"
+    "github.com/jaminalder/codex-tic-tac-toe/internal/domain"
+    "github.com/jaminalder/codex-tic-tac-toe/internal/record"
+)
+
+// The game API serves programs such as the terminal client. Players are
+// identified by the player_id cookie, as in the browser:
+//
+//	POST /api/games                  create and join; JSON body {variant, side, first}
+//	GET  /api/games/{id}             game state
+//	POST /api/games/{id}/join        claim a free seat
+//	POST /api/games/{id}/moves       JSON body {move} in record notation
+//	GET  /api/games/{id}/events      SSE: a "state" event per change
+//
+// Responses carry the game as apiGame, with the caller's seat where it
+// matters; errors are {"error": msg}. Seats, and the side to move, go by
+// their role (see app.Roles): "x" and "o", or "order" and "chaos" in Order
+// and Chaos, which side and first also accept. Quantum games are not
+// available.
+
+// apiGame is a game as the JSON APIs describe it.
+type apiGame struct {
+    ID      string `json:"id"`
+    Variant string `json:"variant"`
+    // Position is the board in domain notation (see domain.FormatPosition).
+    Position string   `json:"position"`
+    Moves    []string `json:"moves"`
+    // Turn is the role of the seat to move.
+    Turn string `json:"turn,omitempty"`
+    // Status is "started", "over" or "aborted".
+    Status string `json:"status"`
+    Result string `json:"result,omitempty"`
+    // Winner is the role of the winning seat, once there is one.
+    Winner string `json:"winner,omitempty"`
+    Line   []int  `json:"line,omitempty"`
+    // Seats holds the two seats keyed by role.
+    Seats map[app.Role]apiSeat `json:"seats"`
+}
+
+// apiSeat is one seat: what it is called and whether a player holds it.
+type apiSeat struct {
+    Name  string `json:"name"`
+    Taken bool   `json:"taken"`
+}
+
+// newAPIGame describes gs. Moves are in record notation.
+func newAPIGame(gs app.GameState) apiGame {
+    g := gs.Game
+    out := apiGame{ID: gs.ID, Variant: g.Variant.String(), Position: domain.FormatPosition(g), Moves: []string{}, Status: "started", Line: g.Line}
+    geo := g.Geometry()
+    for _, m := range g.History {
+        if !g.Variant.ChoosesMark() {
+            m.Mark = domain.Empty
+        }
+        out.Moves = append(out.Moves, record.MoveText(m, geo))
+    }
+    switch {
+    case gs.Aborted, g.Outcome == domain.Aborted:
+        out.Status, out.Result = "aborted", record.Result(g.Outcome)
+    case g.Over:
+        out.Status, out.Result = "over", record.Result(g.Outcome)
+        out.Winner = string(gs.RoleOf(g.Winner))
+    default:
+        out.Turn = string(gs.RoleOf(g.Turn))
+    }
+    out.Seats = make(map[app.Role]apiSeat, len(gs.Seats))
+    for _, st := range gs.Seats {
+        out.Seats[st.Role] = apiSeat{Name: gs.SeatName(gs.SideOf(st.Role)), Taken: st.Player != ""}
+    }
+    return out
+}
+
+// writeJSON sends v as the JSON response body.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(status)
+    _ = json.NewEncoder(w).Encode(v)
+}
+
+// writeJSONError sends {"error": msg}.
+func writeJSONError(w http.ResponseWriter, status int, msg string) {
+    writeJSON(w, status, map[string]string{"error": msg})
+}
+
+// apiErrorStatus maps a service or move error to an HTTP status.
+func apiErrorStatus(err error) int {
+    switch {
+    case errors.Is(err, app.ErrNotFound), errors.Is(err, app.ErrNoChallenge):
+        return http.StatusNotFound
+    case errors.Is(err, app.ErrNotAPlayer):
+        return http.StatusForbidden
+    case errors.Is(err, app.ErrNotYourTurn), errors.Is(err, domain.ErrGameOver), errors.Is(err, app.ErrNoSeat):
+        return http.StatusConflict
+    default:
+        return http.StatusBadRequest
+    }
+}
+
+// apiSeated is the answer to create and join: the role of the caller's seat
+// ("" for a spectator) and the game.
+type apiSeated struct {
+    Seat string  `json:"seat"`
+    Game apiGame `json:"game"`
+}
+
+// readJSON decodes a small JSON request body into v; an empty body leaves v as is.
+func readJSON(r *http.Request, v any) error {
+    err := json.NewDecoder(io.LimitReader(r.Body, 4<<10)).Decode(v)
+    if err == io.EOF {
+        return nil
+    }
+    return err
+}
+
+// apiGameOf fetches game id for the API, answering the request itself when
+// it cannot be served.
+func (h *handlers) apiGameOf(w http.ResponseWriter, id string) (*app.GameState, bool) {
+    gs, ok := h.svc.Get(id)
+    if !ok {
+        writeJSONError(w, http.StatusNotFound, app.ErrNotFound.Error())
+        return nil, false
+    }
+    if gs.Game.Variant == domain.Quantum {
+        writeJSONError(w, http.StatusConflict, "quantum games are not available through the API")
+        return nil, false
+    }
+    return gs, true
+}
+
+func (h *handlers) apiCreate(w http.ResponseWriter, r *http.Request) {
+    var req struct {
+        Variant string `json:"variant"`
+        Side    string `json:"side"`
+        First   string `json:"first"`
+    }
+    if err := readJSON(r, &req); err != nil {
+        writeJSONError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+        return
+    }
+    v, err := domain.ParseVariant(req.Variant)
+    if err != nil {
+        writeJSONError(w, http.StatusBadRequest, err.Error())
+        return
+    }
+    if v == domain.Quantum {
+        writeJSONError(w, http.StatusBadRequest, "quantum games are not available through the API")
+        return
+    }
+    side, err := parseSideChoice(req.Side)
+    if err != nil {
+        writeJSONError(w, http.StatusBadRequest, "invalid side")
+        return
+    }
+    first, err := parseSideChoice(req.First)
+    if err != nil {
+        writeJSONError(w, http.StatusBadRequest, "invalid first mover")
+        return
+    }
+    pid := ensurePlayerCookie(w, r)
+    gs, err := h.svc.CreateGameWithOptions(r.Context(), app.GameOptions{Variant: v, First: first, Creator: pid, CreatorSide: side})
+    if err != nil {
+        h.logger(r.Context()).Error("create game failed", "err", err)
+        writeJSONError(w, http.StatusInternalServerError, "failed to create")
+        return
+    }
+    seat, gs, err := h.svc.Join(r.Context(), gs.ID, pid)
+    if err != nil {
+        writeJSONError(w, apiErrorStatus(err), err.Error())
+        return
+    }
+    writeJSON(w, http.StatusCreated, apiSeated{Seat: string(gs.RoleOf(seat)), Game: newAPIGame(*gs)})
+}
+
+func (h *handlers) apiGet(w http.ResponseWriter, r *http.Request) {
+    if gs, ok := h.apiGameOf(w, chi.URLParam(r, "id")); ok {
+        writeJSON(w, http.StatusOK, newAPIGame(*gs))
+    }
+}
+
+func (h *handlers) apiJoin(w http.ResponseWriter, r *http.Request) {
+    id := chi.URLParam(r, "id")
+    if _, ok := h.apiGameOf(w, id); !ok {
+        return
+    }
+    pid := ensurePlayerCookie(w, r)
+    seat, gs, err := h.svc.Join(r.Context(), id, pid)
+    if err != nil {
+        writeJSONError(w, apiErrorStatus(err), err.Error())
+        return
+    }
+    writeJSON(w, http.StatusOK, apiSeated{Seat: string(gs.RoleOf(seat)), Game: newAPIGame(*gs)})
+}
+
+func (h *handlers) apiMove(w http.ResponseWriter, r *http.Request) {
+    id := chi.URLParam(r, "id")
+    gs, ok := h.apiGameOf(w, id)
+    if !ok {
+        return
+    }
+    var req struct {
+        Move string `json:"move"`
+    }
+    if err := readJSON(r, &req); err != nil {
+        writeJSONError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+        return
+    }
+    geo := gs.Game.Geometry()
+    m, err := record.ParseMove(req.Move, geo)
+    if err != nil {
+        writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid move %q", req.Move))
+        return
+    }
+    pid := ensurePlayerCookie(w, r)
+    gs, err = h.svc.PlayMark(r.Context(), id, pid, m.Index/geo.Cols, m.Index%geo.Cols, m.Mark)
+    if err != nil {
+        h.logger(r.Context()).Info("move rejected", "player_id", pid, "move", req.Move, "err", err)
+        writeJSONError(w, apiErrorStatus(err), moveErrorText(err))
+        return
+    }
+    writeJSON(w, http.StatusOK, newAPIGame(*gs))
+}
+
+// apiEvents streams the game as a "state" event on connect and after every
+// change, as server-sent events.
+func (h *handlers) apiEvents(w http.ResponseWriter, r *http.Request) {
+    id := chi.URLParam(r, "id")
+    if _, ok := h.apiGameOf(w, id); !ok {
+        return
+    }
+    flusher, ok := w.(http.Flusher)
+    if !ok {
+        writeJSONError(w, http.StatusInternalServerError, "streaming unsupported")
+        return
+    }
+    ctx := r.Context()
+    ch, unsub := h.svc.Subscribe(ctx, id, ensurePlayerCookie(w, r))
+    defer unsub()
+    w.Header().Set("Content-Type", "text/event-stream")
+    w.Header().Set("Cache-Control", "no-cache")
+    w.Header().Set("X-Accel-Buffering", "no")
+    w.WriteHeader(http.StatusOK)
+    send := func() bool {
+        gs, ok := h.svc.Get(id)
+        if !ok {
+            return false
+        }
+        b, _ := json.Marshal(newAPIGame(*gs))
+        writeEvent(w, "state", b)
+        flusher.Flush()
+        return true
+    }
+    if !send() {
+        return
+    }
+    ticker := time.NewTicker(heartbeatInterval)
+    defer ticker.Stop()
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            _, _ = io.WriteString(w, ": ping\n\n")
+            flusher.Flush()
+        case ev, ok := <-ch:
+            if !ok {
+                return
+            }
+            // Presence matters too: it shows when an opponent takes a seat.
+            if ev.Name == app.EventChat {
+                continue
+            }
+            if !send() {
+                return
+            }
+        }
+    }
+}
@@ -0,0 +1,165 @@
+package web
+
+import (
+    "bufio"
+    "context"
+    "encoding/json"
+    "net/http"
+    "net/http/cookiejar"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/jaminalder/codex-tic-tac-toe/internal/app"
+    "github.com/jaminalder/codex-tic-tac-toe/internal/domain"
+)
+
+// apiClient is an API client keeping its player_id cookie.
+type apiClient struct {
+    t    *testing.T
+    base string
+    http *http.Client
+}
+
+func newAPIClient(t *testing.T, base string) apiClient {
+    jar, _ := cookiejar.New(nil)
+    return apiClient{t: t, base: base, http: &http.Client{Jar: jar}}
+}
+
+// call sends body as JSON and decodes the JSON answer into out, returning the status.
+func (c apiClient) call(method, path, body string, out any) int {
+    c.t.Helper()
+    req, _ := http.NewRequest(method, c.base+path, strings.NewReader(body))
+    req.Header.Set("Content-Type", "application/json")
+    resp, err := c.http.Do(req)
+    if err != nil {
+        c.t.Fatalf("%s %s: %v", method, path, err)
+    }
+    defer resp.Body.Close()
+    if out != nil {
+        _ = json.NewDecoder(resp.Body).Decode(out)
+    }
+    return resp.StatusCode
+}
+
+func TestAPISeatsGoByRole(t *testing.T) {
+    _, srv := newBotServer(t)
+    alice := newAPIClient(t, srv.URL)
+    var created apiSeated
+    if code := alice.call("POST", "/api/games", `{"variant":"orderchaos","side":"chaos"}`, &created); code != http.StatusCreated {
+        t.Fatalf("create: status %d", code)
+    }
+    g := created.Game
+    if created.Seat != "chaos" || g.Turn != "order" || len(g.Seats) != 2 || !g.Seats[app.RoleChaos].Taken || g.Seats[app.RoleOrder].Name != "Order" {
+        t.Fatalf("expected role-keyed seats, got %+v", created)
+    }
+}
+
+func TestAPICreateJoinAndMove(t *testing.T) {
+    _, srv := newBotServer(t)
+    alice, bob := newAPIClient(t, srv.URL), newAPIClient(t, srv.URL)
+
+    var created apiSeated
+    if code := alice.call("POST", "/api/games", `{"variant":"standard","side":"o"}`, &created); code != http.StatusCreated {
+        t.Fatalf("create: status %d", code)
+    }
+    id := created.Game.ID
+    if created.Seat != "o" || created.Game.Turn != "x" || created.Game.Seats[app.RoleX].Taken {
+        t.Fatalf("unexpected created game %+v", created)
+    }
+    var joined apiSeated
+    if code := bob.call("POST", "/api/games/"+id+"/join", "", &joined); code != http.StatusOK || joined.Seat != "x" {
+        t.Fatalf("join: status %d, %+v", code, joined)
+    }
+
+    var errBody map[string]string
+    if code := alice.call("POST", "/api/games/"+id+"/moves", `{"move":"b2"}`, &errBody); code != http.StatusConflict || errBody["error"] != "Not your turn" {
+        t.Fatalf("expected 409 out of turn, got %d %v", code, errBody)
+    }
+    if code := bob.call("POST", "/api/games/"+id+"/moves", `{"move":"q7"}`, nil); code != http.StatusBadRequest {
+        t.Fatalf("expected 400 for a bad move, got %d", code)
+    }
+    var g apiGame
+    if code := bob.call("POST", "/api/games/"+id+"/moves", `{"move":"b2"}`, &g); code != http.StatusOK {
+        t.Fatalf("move: status %d", code)
+    }
+    if g.Position != ".../.X./... o" {
+        t.Fatalf("unexpected position %q", g.Position)
+    }
+    if len(g.Moves) != 1 || g.Moves[0] != "b2" || g.Turn != "o" {
+        t.Fatalf("unexpected game after the move %+v", g)
+    }
+    if code := alice.call("GET", "/api/games/"+id, "", &g); code != http.StatusOK || g.Position != ".../.X./... o" {
+        t.Fatalf("get: status %d, %+v", code, g)
+    }
+    if code := alice.call("GET", "/api/games/missing", "", nil); code != http.StatusNotFound {
+        t.Fatalf("expected 404, got %d", code)
+    }
+}
+
+func TestAPIRejectsBadCreate(t *testing.T) {
+    _, srv := newBotServer(t)
+    c := newAPIClient(t, srv.URL)
+    for _, body := range []string{`{"variant":"chess"}`, `{"variant":"quantum"}`, `{"side":"purple"}`, `{`} {
+        if code := c.call("POST", "/api/games", body, nil); code != http.StatusBadRequest {
+            t.Fatalf("%s: expected 400, got %d", body, code)
+        }
+    }
+}
+
+func TestAPIEventsStreamState(t *testing.T) {
+    svc, srv := newBotServer(t)
+    gs, _ := svc.CreateGameWithOptions(context.Background(), app.GameOptions{})
+    svc.Join(context.Background(), gs.ID, "p1")
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+    req, _ := http.NewRequestWithContext(ctx, "GET", srv.URL+"/api/games/"+gs.ID+"/events", nil)
+    resp, err := http.DefaultClient.Do(req)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer resp.Body.Close()
+    if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+        t.Fatalf("unexpected content type %q", ct)
+    }
+    states := make(chan apiGame, 16)
+    go func() {
+        sc := bufio.NewScanner(resp.Body)
+        for sc.Scan() {
+            if data, ok := strings.CutPrefix(sc.Text(), "data: "); ok {
+                var g apiGame
+                if json.Unmarshal([]byte(data), &g) == nil {
+                    states <- g
+                }
+            }
+        }
+    }()
+    await := func() apiGame {
+        t.Helper()
+        select {
+        case g := <-states:
+            return g
+        case <-time.After(5 * time.Second):
+            t.Fatal("no state event")
+        }
+        return apiGame{}
+    }
+    if g := await(); g.ID != gs.ID || len(g.Moves) != 0 {
+        t.Fatalf("unexpected initial state %+v", g)
+    }
+    if _, err := svc.Play(context.Background(), gs.ID, "p1", 0, 0); err != nil {
+        t.Fatal(err)
+    }
+    // Presence events may come first; wait for the move.
+    for {
+        if g := await(); len(g.Moves) == 1 {
+            if g.Moves[0] != "a1" || g.Turn != "o" {
+                t.Fatalf("unexpected state %+v", g)
+            }
+            break
+        }
+    }
+    if got, _ := svc.Get(gs.ID); got.Game.Board[0] != domain.X {
+        t.Fatal("move not applied")
+    }
+}
@@ -0,0 +1,306 @@
+package web
+
+import (
+    "context"
+    "crypto/subtle"
+    "encoding/json"
+    "fmt"
+    "io"
+    "net/http"
+    "sort"
+    "strings"
+    "time"
+
+    "github.com/go-chi/chi/v5"
+    "github.com/jaminalder/codex-tic-tac-toe/internal/app"
+    "github.com/jaminalder/codex-tic-tac-toe/internal/domain"
+    "github.com/jaminalder/codex-tic-tac-toe/internal/record"
+)
+
+// The bot API lets programs play as bot accounts over HTTP, in the manner of
+// streaming bot APIs. A bot authenticates with its token as a bearer token
+// and plays as the player ID "bot:<name>":
+//
+//	GET  /api/bot/stream/event              NDJSON: pending, then new challenges
+//	POST /api/bot/challenge/{id}/accept     take the seat offered in game id
+//	POST /api/bot/challenge/{id}/decline
+//	GET  /api/bot/game/stream/{id}          NDJSON: gameFull, then gameState per move
+//	POST /api/bot/game/{id}/move/{move}     move in record notation, e.g. b2 or Ob2
+//
+// Game lines carry the game as apiGame (see api.go). Streams send an empty
+// line as a keep-alive. The game stream ends after the state that finishes
+// the game. Quantum games are not available to bots.
+
+// botPrefix starts the player IDs of bot accounts.
+const botPrefix = "bot:"
+
+// computerBot names the server's own bot (see app.Service.PlayBot), which
+// needs no account; no bot account may take the name.
+const computerBot = "computer"
+
+// botKey is the context key holding the authenticated bot's player ID.
+type botKey struct{}
+
+// botIDs maps each bot token to the bot's player ID.
+type botIDs map[string]string
+
+// newBotIDs builds the token table from bot names keyed by token.
+func newBotIDs(tokens map[string]string) botIDs {
+    ids := make(botIDs, len(tokens))
+    for token, name := range tokens {
+        ids[token] = botPrefix + name
+    }
+    return ids
+}
+
+// names returns the bot names, sorted.
+func (b botIDs) names() []string {
+    var out []string
+    for _, id := range b {
+        out = append(out, strings.TrimPrefix(id, botPrefix))
+    }
+    sort.Strings(out)
+    return out
+}
+
+// known reports whether a bot with the given name exists.
+func (b botIDs) known(name string) bool {
+    for _, id := range b {
+        if id == botPrefix+name {
+            return true
+        }
+    }
+    return false
+}
+
+// auth accepts requests bearing a bot token and records the bot's player ID.
+func (b botIDs) auth(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        got, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+        var id string
+        for token, bot := range b {
+            if subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1 {
+                id = bot
+            }
+        }
+        if id == "" {
+            writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+            return
+        }
+        next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), botKey{}, id)))
+    })
+}
+
+// botID returns the player ID of the bot making the request.
+func botID(r *http.Request) string {
+    id, _ := r.Context().Value(botKey{}).(string)
+    return id
+}
+
+// botChallenge is a challenge as sent on the event stream.
+type botChallenge struct {
+    ID string `json:"id"`
+    // Challenger is a label for the challenger (see challengerLabel).
+    Challenger string `json:"challenger"`
+    Variant    string `json:"variant"`
+}
+
+// challengerLabel names the challenger of c in game gs for the challenged
+// bot. As in records (see playerLabel), player IDs are the players'
+// credentials and never sent: the challenger goes by the seat they hold or
+// keep, or "?" when they have none.
+func challengerLabel(gs *app.GameState, c app.Challenge) string {
+    if gs == nil {
+        return "?"
+    }
+    side := gs.SeatOf(c.Challenger)
+    if side == domain.Empty && c.Challenger == gs.Creator {
+        side = gs.CreatorSide
+    }
+    if side == domain.Empty {
+        return "?"
+    }
+    return gs.SeatName(side)
+}
+
+// botGameState is a line of the game stream: the game, and on the first
+// (gameFull) line the bot's seat.
+type botGameState struct {
+    Type string `json:"type"`
+    Seat string `json:"seat,omitempty"`
+    apiGame
+}
+
+// newBotGameState describes gs to the bot playing as botID.
+func newBotGameState(gs app.GameState, botID string, full bool) botGameState {
+    if full {
+        return botGameState{Type: "gameFull", Seat: string(gs.RoleOf(gs.SeatOf(botID))), apiGame: newAPIGame(gs)}
+    }
+    return botGameState{Type: "gameState", apiGame: newAPIGame(gs)}
+}
+
+// ndjson writes values as newline-delimited JSON, flushing after each.
+type ndjson struct {
+    w       http.ResponseWriter
+    flusher http.Flusher
+    enc     *json.Encoder
+}
+
+func newNDJSON(w http.ResponseWriter) *ndjson {
+    w.Header().Set("Content-Type", "application/x-ndjson")
+    w.Header().Set("Cache-Control", "no-cache")
+    w.Header().Set("X-Accel-Buffering", "no")
+    w.WriteHeader(http.StatusOK)
+    flusher, _ := w.(http.Flusher)
+    s := &ndjson{w: w, flusher: flusher, enc: json.NewEncoder(w)}
+    s.flush()
+    return s
+}
+
+func (s *ndjson) send(v any) {
+    _ = s.enc.Encode(v)
+    s.flush()
+}
+
+func (s *ndjson) keepAlive() {
+    _, _ = io.WriteString(s.w, "\n")
+    s.flush()
+}
+
+func (s *ndjson) flush() {
+    if s.flusher != nil {
+        s.flusher.Flush()
+    }
+}
+
+// botEvents streams the bot's pending challenges, then new ones as they come.
+func (h *handlers) botEvents(w http.ResponseWriter, r *http.Request) {
+    bot := botID(r)
+    ctx := r.Context()
+    // Subscribe before listing so no challenge falls in between; one issued
+    // meanwhile may then be sent twice.
+    ch, unsub := h.svc.SubscribeChallenges(ctx, bot)
+    defer unsub()
+    stream := newNDJSON(w)
+    send := func(c app.Challenge) {
+        gs, _ := h.svc.Get(c.GameID)
+        stream.send(struct {
+            Type      string       `json:"type"`
+            Challenge botChallenge `json:"challenge"`
+        }{"challenge", botChallenge{ID: c.GameID, Challenger: challengerLabel(gs, c), Variant: c.Variant.String()}})
+    }
+    for _, c := range h.svc.Challenges(bot) {
+        send(c)
+    }
+    ticker := time.NewTicker(heartbeatInterval)
+    defer ticker.Stop()
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            stream.keepAlive()
+        case c, ok := <-ch:
+            if !ok {
+                return
+            }
+            send(c)
+        }
+    }
+}
+
+func (h *handlers) botAccept(w http.ResponseWriter, r *http.Request) {
+    id := chi.URLParam(r, "id")
+    side, gs, err := h.svc.AcceptChallenge(r.Context(), id, botID(r))
+    if err != nil {
+        writeJSONError(w, apiErrorStatus(err), err.Error())
+        return
+    }
+    writeJSON(w, http.StatusOK, map[string]string{"id": id, "seat": string(gs.RoleOf(side))})
+}
+
+func (h *handlers) botDecline(w http.ResponseWriter, r *http.Request) {
+    if err := h.svc.DeclineChallenge(r.Context(), chi.URLParam(r, "id"), botID(r)); err != nil {
+        writeJSONError(w, apiErrorStatus(err), err.Error())
+        return
+    }
+    writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+}
+
+// botGameStream streams the state of a game the bot is seated in.
+func (h *handlers) botGameStream(w http.ResponseWriter, r *http.Request) {
+    id := chi.URLParam(r, "id")
+    bot := botID(r)
+    gs, ok := h.svc.Get(id)
+    switch {
+    case !ok:
+        writeJSONError(w, http.StatusNotFound, app.ErrNotFound.Error())
+        return
+    case gs.SeatOf(bot) == domain.Empty:
+        writeJSONError(w, http.StatusForbidden, app.ErrNotAPlayer.Error())
+        return
+    case gs.Game.Variant == domain.Quantum:
+        writeJSONError(w, http.StatusConflict, "quantum games are not available through the API")
+        return
+    }
+    ctx := r.Context()
+    ch, unsub := h.svc.Subscribe(ctx, id, bot)
+    defer unsub()
+    stream := newNDJSON(w)
+    // Read the game again now that updates are coming, so none is missed.
+    gs, ok = h.svc.Get(id)
+    if !ok {
+        return
+    }
+    stream.send(newBotGameState(*gs, bot, true))
+    moves := len(gs.Game.History)
+    over := gs.Game.Over
+    ticker := time.NewTicker(heartbeatInterval)
+    defer ticker.Stop()
+    for !over {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            stream.keepAlive()
+        case ev, ok := <-ch:
+            if !ok {
+                return
+            }
+            if ev.Name != app.EventBoard {
+                continue
+            }
+            gs, found := h.svc.Get(id)
+            if !found {
+                return
+            }
+            // Several events may report the same move once the stream lags.
+            if len(gs.Game.History) == moves && gs.Game.Over == over {
+                continue
+            }
+            moves, over = len(gs.Game.History), gs.Game.Over
+            stream.send(newBotGameState(*gs, bot, false))
+        }
+    }
+}
+
+// botMove plays the move in the URL for the bot.
+func (h *handlers) botMove(w http.ResponseWriter, r *http.Request) {
+    id := chi.URLParam(r, "id")
+    gs, ok := h.apiGameOf(w, id)
+    if !ok {
+        return
+    }
+    geo := gs.Game.Geometry()
+    m, err := record.ParseMove(chi.URLParam(r, "move"), geo)
+    if err != nil {
+        writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid move %q", chi.URLParam(r, "move")))
+        return
+    }
+    if _, err := h.svc.PlayMark(r.Context(), id, botID(r), m.Index/geo.Cols, m.Index%geo.Cols, m.Mark); err != nil {
+        h.logger(r.Context()).Info("bot move rejected", "player_id", botID(r), "move", chi.URLParam(r, "move"), "err", err)
+        writeJSONError(w, apiErrorStatus(err), moveErrorText(err))
+        return
+    }
+    writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+}
@@ -0,0 +1,256 @@
+package web
+
+import (
+    "bufio"
+    "context"
+    "encoding/json"
+    "net/http"
+    "net/http/cookiejar"
+    "net/http/httptest"
+    "net/url"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/jaminalder/codex-tic-tac-toe/internal/app"
+    "github.com/jaminalder/codex-tic-tac-toe/internal/domain"
+    "github.com/jaminalder/codex-tic-tac-toe/internal/record"
+)
+
+// fakeBot is an in-process client of the bot API.
+type fakeBot struct {
+    t     *testing.T
+    base  string
+    token string
+}
+
+func (b fakeBot) request(ctx context.Context, method, path string) *http.Response {
+    b.t.Helper()
+    req, _ := http.NewRequestWithContext(ctx, method, b.base+path, nil)
+    if b.token != "" {
+        req.Header.Set("Authorization", "Bearer "+b.token)
+    }
+    resp, err := http.DefaultClient.Do(req)
+    if err != nil {
+        b.t.Fatalf("%s %s: %v", method, path, err)
+    }
+    return resp
+}
+
+// post sends a POST and returns its status, closing the body.
+func (b fakeBot) post(path string) int {
+    b.t.Helper()
+    resp := b.request(context.Background(), "POST", path)
+    resp.Body.Close()
+    return resp.StatusCode
+}
+
+// stream opens an NDJSON stream and returns its JSON lines, skipping keep-alives.
+func (b fakeBot) stream(ctx context.Context, path string) <-chan map[string]any {
+    b.t.Helper()
+    resp := b.request(ctx, "GET", path)
+    if resp.StatusCode != http.StatusOK {
+        b.t.Fatalf("GET %s: status %d", path, resp.StatusCode)
+    }
+    if ct := resp.Header.Get("Content-Type"); ct != "application/x-ndjson" {
+        b.t.Fatalf("GET %s: content type %q", path, ct)
+    }
+    out := make(chan map[string]any)
+    go func() {
+        defer close(out)
+        defer resp.Body.Close()
+        sc := bufio.NewScanner(resp.Body)
+        for sc.Scan() {
+            if strings.TrimSpace(sc.Text()) == "" {
+                continue
+            }
+            var v map[string]any
+            if json.Unmarshal(sc.Bytes(), &v) == nil {
+                out <- v
+            }
+        }
+    }()
+    return out
+}
+
+func next(t *testing.T, ch <-chan map[string]any) map[string]any {
+    t.Helper()
+    select {
+    case v, ok := <-ch:
+        if !ok {
+            t.Fatal("stream ended")
+        }
+        return v
+    case <-time.After(5 * time.Second):
+        t.Fatal("timed out waiting for the stream")
+    }
+    return nil
+}
+
+// firstFree returns the coordinate of the first empty cell of a 3x3 position.
+func firstFree(position string) string {
+    board, _, _ := strings.Cut(position, " ")
+    board = strings.ReplaceAll(board, "/", "")
+    return record.Coord(strings.IndexByte(board, '.'))
+}
+
+func newBotServer(t *testing.T) (*app.Service, *httptest.Server) {
+    t.Helper()
+    svc := app.NewService()
+    srv := httptest.NewServer(newServerWithOptions(t, svc, Options{BotTokens: map[string]string{"tok-a": "alpha"}}))
+    t.Cleanup(srv.Close)
+    return svc, srv
+}
+
+func TestBotAPIRequiresToken(t *testing.T) {
+    _, srv := newBotServer(t)
+    for _, token := range []string{"", "wrong"} {
+        resp := fakeBot{t: t, base: srv.URL, token: token}.request(context.Background(), "GET", "/api/bot/stream/event")
+        resp.Body.Close()
+        if resp.StatusCode != http.StatusUnauthorized {
+            t.Fatalf("token %q: expected 401, got %d", token, resp.StatusCode)
+        }
+    }
+}
+
+func TestBotAPIDisabledWithoutTokens(t *testing.T) {
+    _, h := newTestServer(t)
+    rr := httptest.NewRecorder()
+    h.ServeHTTP(rr, httptest.NewRequest("GET", "/api/bot/stream/event", nil))
+    if rr.Code != http.StatusNotFound {
+        t.Fatalf("expected 404, got %d", rr.Code)
+    }
+}
+
+func TestFakeBotPlaysChallengedGame(t *testing.T) {
+    svc, srv := newBotServer(t)
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+    bot := fakeBot{t: t, base: srv.URL, token: "tok-a"}
+    events := bot.stream(ctx, "/api/bot/stream/event")
+
+    // A human creates a game against the bot from the index form.
+    jar, _ := cookiejar.New(nil)
+    human := &http.Client{Jar: jar, CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+    resp, err := human.PostForm(srv.URL+"/game", url.Values{"bot": {"alpha"}, "side": {"x"}})
+    if err != nil {
+        t.Fatal(err)
+    }
+    resp.Body.Close()
+    id := strings.TrimPrefix(resp.Header.Get("Location"), "/game/")
+    u, _ := url.Parse(srv.URL)
+    var humanID string
+    for _, c := range jar.Cookies(u) {
+        if c.Name == "player_id" {
+            humanID = c.Value
+        }
+    }
+
+    ev := next(t, events)
+    challenge, _ := ev["challenge"].(map[string]any)
+    if ev["type"] != "challenge" || challenge["id"] != id || challenge["variant"] != "standard" {
+        t.Fatalf("unexpected event %v", ev)
+    }
+    // The challenger goes by their seat, both when the challenge is new and
+    // when it is listed as pending: their player ID is their credential.
+    for _, ev := range []map[string]any{ev, next(t, bot.stream(ctx, "/api/bot/stream/event"))} {
+        line, _ := json.Marshal(ev)
+        challenge, _ := ev["challenge"].(map[string]any)
+        if challenge["challenger"] != "X" || humanID == "" || strings.Contains(string(line), humanID) {
+            t.Fatalf("challenge must name the seat, not the player ID %q: %s", humanID, line)
+        }
+    }
+    if code := bot.post("/api/bot/challenge/" + id + "/accept"); code != http.StatusOK {
+        t.Fatalf("accept: status %d", code)
+    }
+    svc.Join(context.Background(), id, humanID)
+
+    game := bot.stream(ctx, "/api/bot/game/stream/"+id)
+    st := next(t, game)
+    if st["type"] != "gameFull" || st["seat"] != "o" || st["turn"] != "x" {
+        t.Fatalf("unexpected first game line %v", st)
+    }
+    if code := bot.post("/api/bot/game/" + id + "/move/b1"); code != http.StatusConflict {
+        t.Fatalf("expected 409 for a move out of turn, got %d", code)
+    }
+
+    // X takes the diagonal while the bot fills the top row from the left.
+    for _, cell := range []int{0, 4, 8} {
+        if _, err := svc.Play(context.Background(), id, humanID, cell/3, cell%3); err != nil {
+            t.Fatalf("human move: %v", err)
+        }
+        st = next(t, game)
+        if st["type"] != "gameState" {
+            t.Fatalf("unexpected game line %v", st)
+        }
+        if st["turn"] != "o" {
+            break
+        }
+        if code := bot.post("/api/bot/game/" + id + "/move/" + firstFree(st["position"].(string))); code != http.StatusOK {
+            t.Fatalf("bot move: status %d", code)
+        }
+        next(t, game) // the bot's own move
+    }
+    if st["status"] != "over" || st["result"] != record.ResultXWon || st["winner"] != "x" {
+        t.Fatalf("expected X to have won, got %v", st)
+    }
+    if moves, _ := st["moves"].([]any); len(moves) != 5 || moves[1] != "b1" {
+        t.Fatalf("unexpected move list %v", st["moves"])
+    }
+    select {
+    case _, ok := <-game:
+        if ok {
+            t.Fatal("expected the game stream to end with the game")
+        }
+    case <-time.After(5 * time.Second):
+        t.Fatal("game stream still open after the game ended")
+    }
+    gs, _ := svc.Get(id)
+    if gs.PlayerOf(domain.O) != "bot:alpha" || gs.Game.Winner != domain.X {
+        t.Fatalf("unexpected final game %+v", gs)
+    }
+}
+
+func TestBotMoveErrors(t *testing.T) {
+    svc, srv := newBotServer(t)
+    bot := fakeBot{t: t, base: srv.URL, token: "tok-a"}
+    gs, _ := svc.CreateGame(context.Background())
+    if code := bot.post("/api/bot/game/" + gs.ID + "/move/b2"); code != http.StatusForbidden {
+        t.Fatalf("expected 403 for a bot not seated, got %d", code)
+    }
+    svc.Join(context.Background(), gs.ID, "bot:alpha")
+    if code := bot.post("/api/bot/game/" + gs.ID + "/move/z9"); code != http.StatusBadRequest {
+        t.Fatalf("expected 400 for a bad move, got %d", code)
+    }
+    if code := bot.post("/api/bot/challenge/" + gs.ID + "/accept"); code != http.StatusNotFound {
+        t.Fatalf("expected 404 without a challenge, got %d", code)
+    }
+    if code := bot.post("/api/bot/game/missing/move/b2"); code != http.StatusNotFound {
+        t.Fatalf("expected 404 for a missing game, got %d", code)
+    }
+}
+
+func TestCreateRejectsUnknownBot(t *testing.T) {
+    _, srv := newBotServer(t)
+    resp, err := http.PostForm(srv.URL+"/game", url.Values{"bot": {"beta"}})
+    if err != nil {
+        t.Fatal(err)
+    }
+    resp.Body.Close()
+    if resp.StatusCode != http.StatusBadRequest {
+        t.Fatalf("expected 400, got %d", resp.StatusCode)
+    }
+}
+
+func TestCookieCannotClaimBotID(t *testing.T) {
+    svc, h := newTestServer(t)
+    gs, _ := svc.CreateGame(context.Background())
+    svc.Join(context.Background(), gs.ID, "bot:alpha")
+    req := httptest.NewRequest("POST", "/game/"+gs.ID+"/play", strings.NewReader("r=0&c=0"))
+    req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+    req.AddCookie(&http.Cookie{Name: "player_id", Value: "bot:alpha"})
+    h.ServeHTTP(httptest.NewRecorder(), req)
+    if got, _ := svc.Get(gs.ID); got.Game.Moves != 0 {
+        t.Fatal("a cookie played as the bot")
+    }
+}
@@ -1,43 +1,302 @@
 package web
 
 import (
+    "bytes"
+    "context"
     "errors"
     "fmt"
     "html/template"
     "io"
+    "log/slog"
+    "math/rand"
     "net/http"
     "strconv"
+    "strings"
     "time"
 
     "github.com/go-chi/chi/v5"
     "github.com/jaminalder/codex-tic-tac-toe/internal/app"
+    "github.com/jaminalder/codex-tic-tac-toe/internal/bot"
     "github.com/jaminalder/codex-tic-tac-toe/internal/domain"
 )
 
 type handlers struct {
     svc *app.Service
     tpl *templates
+    log *slog.Logger
+    // reload, when set, supersedes tpl with templates reloaded from disk.
+    reload *templateReloader
+    // bots holds the bot accounts that may be challenged.
+    bots botIDs
+}
+
+// templates returns the template set to render with.
+func (h *handlers) templates() *templates {
+    if h.reload != nil {
+        return h.reload.get()
+    }
+    return h.tpl
+}
+
+// logger returns the request-scoped logger, falling back to the server logger.
+func (h *handlers) logger(ctx context.Context) *slog.Logger {
+    if h.log == nil {
+        return loggerFrom(ctx, slog.Default())
+    }
+    return loggerFrom(ctx, h.log)
+}
+
+// render executes t and logs failures; the partial output is still returned.
+func (h *handlers) render(ctx context.Context, t *template.Template, data any) []byte {
+    b, err := renderTemplate(t, "", data)
+    if err != nil {
+        h.logger(ctx).Error("template render failed", "template", t.Name(), "err", err)
+    }
+    return b
+}
+
+// boardCell is one square of the rendered board.
+type boardCell struct {
+    R, C     int // form values; rows count on through the layers
+    Label    string
+    Symbol   string
+    Win      bool // part of the winning line
+    Disabled bool // occupied, or the game is over
+}
+
+// boardLayer is one layer of the board; Title is empty on single-layer boards.
+type boardLayer struct {
+    Title string
+    Rows  [][]boardCell
+}
+
+// boardView is the data rendered by the board partial. It is shared by every
+// subscriber, so it carries no viewer-specific state.
+type boardView struct {
+    ID      string
+    Error   string
+    Layers  []boardLayer
+    Compact bool // smaller cells for boards larger than 3x3
+    Turn    string // side to move while in progress
+    Status  string
+    Over    bool
+    Waiting bool // a seat is still open
+    // Position is the shareable notation of the board (see domain.FormatPosition).
+    Position string
+    Variant  domain.Variant
+    // Marks lists the marks the mover may choose from; empty unless the
+    // variant offers a choice.
+    Marks []string
+    // Numerical is set for the Numerical variant, with the numbers each
+    // seat has left.
+    Numerical          bool
+    NumbersX, NumbersO []int
+    // Quantum replaces Layers for the Quantum variant.
+    Quantum *quantumView
+}
+
+func newBoardView(gs app.GameState, errMsg string) boardView {
+    g := gs.Game
+    v := boardView{ID: gs.ID, Error: errMsg, Over: g.Over, Waiting: gs.Seats[0].Player == "" || gs.Seats[1].Player == "", Position: domain.FormatPosition(g), Variant: g.Variant}
+    if g.Variant == domain.Quantum {
+        v.Quantum = newQuantumView(gs)
+    }
+    if g.Variant == domain.Numerical {
+        v.Numerical = true
+        v.NumbersX, v.NumbersO = gs.RemainingNumbers(domain.X), gs.RemainingNumbers(domain.O)
+    }
+    geo := g.Geometry()
+    v.Compact = geo.Cells() > 9
+    for l := 0; l < geo.Layers; l++ {
+        var layer boardLayer
+        if geo.Layers > 1 {
+            layer.Title = "Layer " + strconv.Itoa(l+1)
+        }
+        for r := 0; r < geo.Rows; r++ {
+            row := make([]boardCell, geo.Cols)
+            for c := range row {
+                idx := geo.Index(l, r, c)
+                label := fmt.Sprintf("row %d, column %d", r+1, c+1)
+                if layer.Title != "" {
+                    label = fmt.Sprintf("layer %d, %s", l+1, label)
+                }
+                row[c] = boardCell{
+                    R:        l*geo.Rows + r,
+                    C:        c,
+                    Label:    label,
+                    Symbol:   cellSymbol(g.Board[idx]),
+                    Win:      g.OnLine(idx),
+                    Disabled: g.Over || g.Board[idx] != domain.Empty,
+                }
+            }
+            layer.Rows = append(layer.Rows, row)
+        }
+        v.Layers = append(v.Layers, layer)
+    }
+    switch outcome := gs.Outcome(); {
+    case gs.Aborted:
+        v.Status = "Game ended by an administrator"
+    case outcome == domain.Aborted:
+        v.Status = "Game abandoned"
+    case outcome == domain.XWon, outcome == domain.OWon:
+        v.Status = g.Variant.SideName(outcome.Winner()) + " wins!"
+    case outcome == domain.Draw:
+        v.Status = "Draw"
+    default:
+        v.Turn = cellSymbol(g.Turn)
+        v.Status = g.Variant.SideName(g.Turn) + " to move"
+        if v.Quantum != nil && v.Quantum.Pending != nil {
+            v.Status = v.Quantum.Chooser + " chooses the collapse"
+        }
+        if marks := g.Variant.Rules().Marks(g.Board, g.Turn); len(marks) > 1 {
+            for _, m := range marks {
+                v.Marks = append(v.Marks, cellSymbol(m))
+            }
+        }
+        if v.Waiting {
+            v.Status = "Waiting for an opponent · " + v.Status
+        }
+    }
+    return v
 }
 
 func (h *handlers) renderBoard(gs app.GameState, errMsg string) []byte {
+    return h.render(context.Background(), h.templates().board, newBoardView(gs, errMsg))
+}
+
+func (h *handlers) renderPresence(p app.Presence) []byte {
+    return h.render(context.Background(), h.templates().presence, p)
+}
+
+func (h *handlers) renderChatMessage(m app.ChatMessage) []byte {
+    return h.render(context.Background(), h.templates().chatMessage, m)
+}
+
+func (h *handlers) renderChatForm(id, errMsg string) []byte {
     data := struct {
-        ID    string
-        Game  struct{ Board any }
-        Error string
-    }{ID: gs.ID, Error: errMsg}
-    data.Game.Board = gs.Game.Board
-    return renderTemplate(h.tpl.board, "", data)
+        ID        string
+        Error     string
+        MaxLength int
+    }{ID: id, Error: errMsg, MaxLength: app.MaxChatLength}
+    return h.render(context.Background(), h.templates().chatForm, data)
 }
 
-func (h *handlers) index(w http.ResponseWriter, r *http.Request) {
+// indexData is rendered by the index page; Error, Position and Variant echo a
+// rejected position form, ImportError and Record a rejected game record.
+type indexData struct {
+    Variants    []domain.Variant
+    Bots        []string
+    Error       string
+    Position    string
+    Variant     domain.Variant
+    ImportError string
+    Record      string
+}
+
+func (h *handlers) renderIndex(w http.ResponseWriter, r *http.Request, status int, data indexData) {
+    data.Variants = domain.Variants
+    data.Bots = h.bots.names()
     w.Header().Set("Content-Type", "text/html; charset=utf-8")
-    w.WriteHeader(http.StatusOK)
-    _, _ = w.Write(renderTemplate(h.tpl.index, "", nil))
+    w.WriteHeader(status)
+    _, _ = w.Write(h.render(r.Context(), h.templates().index, data))
+}
+
+func (h *handlers) index(w http.ResponseWriter, r *http.Request) {
+    h.renderIndex(w, r, http.StatusOK, indexData{})
 }
 
 func (h *handlers) create(w http.ResponseWriter, r *http.Request) {
-    gs, err := h.svc.CreateGame()
+    v, err := domain.ParseVariant(r.FormValue("variant"))
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+    side, err := parseSideChoice(r.FormValue("side"))
     if err != nil {
+        http.Error(w, "invalid side", http.StatusBadRequest)
+        return
+    }
+    first, err := parseSideChoice(r.FormValue("first"))
+    if err != nil {
+        http.Error(w, "invalid first mover", http.StatusBadRequest)
+        return
+    }
+    opponent := r.FormValue("bot")
+    if opponent != "" && opponent != computerBot && !h.bots.known(opponent) {
+        http.Error(w, "unknown bot", http.StatusBadRequest)
+        return
+    }
+    if opponent != "" && v == domain.Quantum {
+        http.Error(w, "bots cannot play quantum games", http.StatusBadRequest)
+        return
+    }
+    var computer bot.Mover
+    if opponent == computerBot {
+        m, err := bot.For(v)
+        if err != nil {
+            http.Error(w, "the computer cannot play "+v.Title()+" games", http.StatusBadRequest)
+            return
+        }
+        computer = m
+    }
+    if opponent == computerBot && side == domain.Empty {
+        // Otherwise the computer, joining first, would take X.
+        side = domain.X
+    }
+    pid := ensurePlayerCookie(w, r)
+    gs, err := h.svc.CreateGameWithOptions(r.Context(), app.GameOptions{Variant: v, First: first, Creator: pid, CreatorSide: side})
+    if err != nil {
+        h.logger(r.Context()).Error("create game failed", "err", err)
+        http.Error(w, "failed to create", http.StatusInternalServerError)
+        return
+    }
+    if computer != nil {
+        if _, err := h.svc.PlayBot(r.Context(), gs.ID, botPrefix+computerBot, computer); err != nil {
+            h.logger(r.Context()).Error("computer opponent failed", "game_id", gs.ID, "err", err)
+        }
+    } else if opponent != "" {
+        if _, err := h.svc.Challenge(r.Context(), gs.ID, pid, botPrefix+opponent); err != nil {
+            h.logger(r.Context()).Error("challenge failed", "game_id", gs.ID, "bot", opponent, "err", err)
+        }
+    }
+    http.Redirect(w, r, "/game/"+gs.ID, http.StatusSeeOther)
+}
+
+// parseSideChoice reads a side picked on the create form: "x" or "o", a
+// role such as "order", "random" (resolved here), or empty for the default.
+func parseSideChoice(s string) (domain.Cell, error) {
+    switch app.Role(strings.ToLower(strings.TrimSpace(s))) {
+    case "":
+        return domain.Empty, nil
+    case app.RoleX, app.RoleOrder:
+        return domain.X, nil
+    case app.RoleO, app.RoleChaos:
+        return domain.O, nil
+    case "random":
+        if rand.Intn(2) == 0 {
+            return domain.X, nil
+        }
+        return domain.O, nil
+    }
+    return domain.Empty, fmt.Errorf("unknown side %q", s)
+}
+
+// createFromPosition starts a game from a position in domain notation.
+func (h *handlers) createFromPosition(w http.ResponseWriter, r *http.Request) {
+    _ = r.ParseForm()
+    pos := r.Form.Get("position")
+    v, err := domain.ParseVariant(r.Form.Get("variant"))
+    var g domain.Game
+    if err == nil {
+        g, err = domain.ParsePositionVariant(pos, v)
+    }
+    if err != nil {
+        h.renderIndex(w, r, http.StatusBadRequest, indexData{Error: err.Error(), Position: pos, Variant: v})
+        return
+    }
+    gs, err := h.svc.CreateGameFrom(r.Context(), g)
+    if err != nil {
+        h.logger(r.Context()).Error("create game failed", "err", err)
         http.Error(w, "failed to create", http.StatusInternalServerError)
         return
     }
@@ -48,32 +307,61 @@ func (h *handlers) view(w http.ResponseWriter, r *http.Request) {
     id := chi.URLParam(r, "id")
     // ensure cookie and auto-claim seat
     pid := ensurePlayerCookie(w, r)
-    _, _, _ = h.svc.Join(id, pid)
+    seat, _, err := h.svc.Join(r.Context(), id, pid)
+    if err != nil {
+        h.logger(r.Context()).Info("auto-join failed", "player_id", pid, "err", err)
+    }
 
     gs, ok := h.svc.Get(id)
     if !ok {
         http.NotFound(w, r)
         return
     }
+    presence, _ := h.svc.Presence(id)
     data := struct {
-        ID        string
-        Game      struct{ ID string }
-        BoardHTML template.HTML
-    }{ID: gs.ID}
+        ID           string
+        Game         struct{ ID string }
+        BoardHTML    template.HTML
+        PresenceHTML template.HTML
+        Chat         []template.HTML
+        ChatFormHTML template.HTML
+        Seat         string // viewer's seat name, empty for spectators
+    }{ID: gs.ID, Seat: gs.Game.Variant.SideName(seat)}
     data.Game.ID = gs.ID
     data.BoardHTML = template.HTML(h.renderBoard(*gs, ""))
+    data.PresenceHTML = template.HTML(h.renderPresence(presence))
+    for _, m := range gs.Chat {
+        data.Chat = append(data.Chat, template.HTML(h.renderChatMessage(m)))
+    }
+    data.ChatFormHTML = template.HTML(h.renderChatForm(gs.ID, ""))
 
     w.Header().Set("Content-Type", "text/html; charset=utf-8")
     w.WriteHeader(http.StatusOK)
     // Render page with embedded board container
-    _, _ = w.Write(renderTemplate(h.tpl.game, "", data))
+    _, _ = w.Write(h.render(r.Context(), h.templates().game, data))
+}
+
+// parseMark reads the optional mark field of a move: X, O or a number 1-9.
+// Anything else means the player's own mark.
+func parseMark(s string) domain.Cell {
+    switch s {
+    case "X", "x":
+        return domain.X
+    case "O", "o":
+        return domain.O
+    }
+    if n, err := strconv.Atoi(s); err == nil && n >= 1 && n <= 9 {
+        return domain.NumberCell(n)
+    }
+    return domain.Empty
 }
 
 func (h *handlers) join(w http.ResponseWriter, r *http.Request) {
     id := chi.URLParam(r, "id")
     pid := ensurePlayerCookie(w, r)
-    _, gs, err := h.svc.Join(id, pid)
+    _, gs, err := h.svc.Join(r.Context(), id, pid)
     if err != nil || gs == nil {
+        h.logger(r.Context()).Info("join failed", "player_id", pid, "err", err)
         http.NotFound(w, r)
         return
     }
@@ -89,45 +377,94 @@ func (h *handlers) play(w http.ResponseWriter, r *http.Request) {
     cStr := r.Form.Get("c")
     ri, _ := strconv.Atoi(rStr)
     ci, _ := strconv.Atoi(cStr)
-    gs, err := h.svc.Play(id, pid, ri, ci)
+    gs, err := h.svc.PlayMark(r.Context(), id, pid, ri, ci, parseMark(r.Form.Get("mark")))
     var errMsg string
     if err != nil {
-        if gs == nil {
-            if g, ok := h.svc.Get(id); ok { gs = g }
-        }
-        switch {
-        case errors.Is(err, app.ErrNotYourTurn):
-            errMsg = "Not your turn"
-        case errors.Is(err, app.ErrNotAPlayer):
-            errMsg = "You are a spectator"
-        case errors.Is(err, domain.ErrOccupied):
-            errMsg = "Cell is occupied"
-        case errors.Is(err, domain.ErrOutOfBounds):
-            errMsg = "Out of bounds"
-        case errors.Is(err, domain.ErrGameOver):
-            errMsg = "Game is over"
-        default:
-            errMsg = "Invalid move"
-        }
+        h.logger(r.Context()).Info("move rejected", "player_id", pid, "row", ri, "col", ci, "err", err)
+        errMsg = moveErrorText(err)
+    }
+    h.writeBoard(w, r, id, gs, errMsg)
+}
+
+// moveErrorText returns the message shown on the board for a rejected move.
+func moveErrorText(err error) string {
+    switch {
+    case errors.Is(err, app.ErrNotYourTurn):
+        return "Not your turn"
+    case errors.Is(err, app.ErrNotAPlayer):
+        return "You are a spectator"
+    case errors.Is(err, domain.ErrOccupied):
+        return "Cell is occupied"
+    case errors.Is(err, domain.ErrOutOfBounds):
+        return "Out of bounds"
+    case errors.Is(err, domain.ErrGameOver):
+        return "Game is over"
+    case errors.Is(err, domain.ErrInvalidMark):
+        return "You cannot place that mark"
+    case errors.Is(err, domain.ErrSameSquare):
+        return "Pick two different squares"
+    case errors.Is(err, domain.ErrCollapsePending):
+        return "The cycle must collapse first"
+    case errors.Is(err, domain.ErrNoCollapse), errors.Is(err, domain.ErrCollapseSquare):
+        return "Invalid collapse"
+    default:
+        return "Invalid move"
+    }
+}
+
+// writeBoard answers a move with the board fragment of gs, or of the
+// current state when the move was rejected before producing one.
+func (h *handlers) writeBoard(w http.ResponseWriter, r *http.Request, id string, gs *app.GameState, errMsg string) {
+    if gs == nil {
+        if g, ok := h.svc.Get(id); ok { gs = g }
     }
     if gs == nil {
         http.NotFound(w, r)
         return
     }
-    data := struct {
-        ID    string
-        Game  struct{ Board any }
-        Error string
-    }{ID: gs.ID, Error: errMsg}
-    data.Game.Board = gs.Game.Board
     w.Header().Set("Content-Type", "text/html; charset=utf-8")
-    _, _ = w.Write(renderTemplate(h.tpl.board, "", data))
+    _, _ = w.Write(h.render(r.Context(), h.templates().board, newBoardView(*gs, errMsg)))
+}
+
+func (h *handlers) chat(w http.ResponseWriter, r *http.Request) {
+    id := chi.URLParam(r, "id")
+    pid := ensurePlayerCookie(w, r)
+    _ = r.ParseForm()
+    _, err := h.svc.PostChat(r.Context(), id, pid, r.Form.Get("text"))
+    if err != nil {
+        h.logger(r.Context()).Info("chat rejected", "player_id", pid, "err", err)
+    }
+    var errMsg string
+    switch {
+    case err == nil:
+    case errors.Is(err, app.ErrNotFound):
+        http.NotFound(w, r)
+        return
+    case errors.Is(err, app.ErrChatEmpty):
+        errMsg = "Message is empty"
+    case errors.Is(err, app.ErrChatTooLong):
+        errMsg = fmt.Sprintf("Message is longer than %d characters", app.MaxChatLength)
+    case errors.Is(err, app.ErrChatRateLimited):
+        errMsg = "You are sending messages too quickly"
+    case errors.Is(err, app.ErrChatRejected):
+        errMsg = "Message was rejected"
+    default:
+        errMsg = "Could not send message"
+    }
+    // The message itself arrives via the chat SSE event; respond with a fresh form.
+    w.Header().Set("Content-Type", "text/html; charset=utf-8")
+    _, _ = w.Write(h.renderChatForm(id, errMsg))
 }
 
 var heartbeatInterval = 15 * time.Second
 
 func (h *handlers) events(w http.ResponseWriter, r *http.Request) {
     id := chi.URLParam(r, "id")
+    // A deleted game stays gone; EventSource stops reconnecting on an error status.
+    if _, ok := h.svc.Get(id); !ok {
+        http.NotFound(w, r)
+        return
+    }
     w.Header().Set("Content-Type", "text/event-stream")
     w.Header().Set("Cache-Control", "no-cache")
     w.Header().Set("X-Accel-Buffering", "no")
@@ -142,7 +479,12 @@ func (h *handlers) events(w http.ResponseWriter, r *http.Request) {
         return
     }
     ctx := r.Context()
-    ch, _ := h.svc.Subscribe(ctx, id)
+    // Anonymous clients (no cookie yet) are counted as spectators.
+    var pid string
+    if c, err := r.Cookie("player_id"); err == nil {
+        pid = c.Value
+    }
+    ch, _ := h.svc.Subscribe(ctx, id, pid)
     // heartbeat ticker
     ticker := time.NewTicker(heartbeatInterval)
     defer ticker.Stop()
@@ -155,12 +497,19 @@ func (h *handlers) events(w http.ResponseWriter, r *http.Request) {
         case <-ticker.C:
             _, _ = io.WriteString(w, ": ping\n\n")
             flusher.Flush()
-        case b, ok := <-ch:
+        case ev, ok := <-ch:
             if !ok { return }
-            // Emit board event
-            _, _ = fmt.Fprintf(w, "event: board\n")
-            _, _ = fmt.Fprintf(w, "data: %s\n\n", b)
+            writeEvent(w, ev.Name, ev.Data)
             flusher.Flush()
         }
     }
 }
+
+// writeEvent emits one SSE event, prefixing every payload line with "data:".
+func writeEvent(w io.Writer, name string, data []byte) {
+    _, _ = fmt.Fprintf(w, "event: %s\n", name)
+    for _, line := range bytes.Split(data, []byte("\n")) {
+        _, _ = fmt.Fprintf(w, "data: %s\n", line)
+    }
+    _, _ = io.WriteString(w, "\n")
+}
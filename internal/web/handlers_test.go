@@ -7,12 +7,14 @@ import (
     "net/http"
     "net/http/httptest"
     "net/url"
+    "strconv"
     "strings"
     "testing"
     "time"
     "sync"
 
     "github.com/jaminalder/codex-tic-tac-toe/internal/app"
+    "github.com/jaminalder/codex-tic-tac-toe/internal/domain"
     "github.com/go-chi/chi/v5"
 )
 
@@ -24,6 +26,15 @@ func newTestServer(t *testing.T) (*app.Service, http.Handler) {
     return s, h
 }
 
+func newServerWithOptions(t *testing.T, s *app.Service, opts Options) http.Handler {
+    t.Helper()
+    h, err := NewServerWithOptions(s, opts)
+    if err != nil {
+        t.Fatalf("NewServerWithOptions: %v", err)
+    }
+    return h
+}
+
 func TestIndexPage(t *testing.T) {
     _, h := newTestServer(t)
     req := httptest.NewRequest("GET", "/", nil)
@@ -52,10 +63,45 @@ func TestCreateRedirectsToGame(t *testing.T) {
     }
 }
 
+func TestCreateWithSideAndFirstMover(t *testing.T) {
+    svc, h := newTestServer(t)
+    form := url.Values{"side": {"o"}, "first": {"o"}}
+    req := httptest.NewRequest("POST", "/game", strings.NewReader(form.Encode()))
+    req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+    rr := httptest.NewRecorder()
+    h.ServeHTTP(rr, req)
+    if rr.Code != http.StatusSeeOther {
+        t.Fatalf("expected redirect, got %d: %s", rr.Code, rr.Body.String())
+    }
+    id := strings.TrimPrefix(rr.Header().Get("Location"), "/game/")
+    gs, ok := svc.Get(id)
+    if !ok {
+        t.Fatalf("expected game %q to exist", id)
+    }
+    var creator string
+    for _, c := range rr.Result().Cookies() {
+        if c.Name == "player_id" {
+            creator = c.Value
+        }
+    }
+    if creator == "" || gs.Creator != creator || gs.CreatorSide != domain.O || gs.Game.Turn != domain.O {
+        t.Fatalf("game does not carry the creator's choices: %+v", gs)
+    }
+
+    form = url.Values{"side": {"purple"}}
+    req = httptest.NewRequest("POST", "/game", strings.NewReader(form.Encode()))
+    req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+    rr = httptest.NewRecorder()
+    h.ServeHTTP(rr, req)
+    if rr.Code != http.StatusBadRequest {
+        t.Fatalf("expected 400 for an unknown side, got %d", rr.Code)
+    }
+}
+
 func TestGamePageSetsCookieAndAutoClaims(t *testing.T) {
     svc, h := newTestServer(t)
     // Create a game via service to know ID
-    gs, _ := svc.CreateGame()
+    gs, _ := svc.CreateGame(context.Background())
 
     req := httptest.NewRequest("GET", "/game/"+url.PathEscape(gs.ID), nil)
     rr := httptest.NewRecorder()
@@ -77,8 +123,8 @@ func TestGamePageSetsCookieAndAutoClaims(t *testing.T) {
     }
     // Auto-claimed seat
     latest, ok := svc.Get(gs.ID)
-    if !ok || (latest.X != playerID && latest.O != playerID) {
-        t.Fatalf("expected auto-claim X or O; have X=%q O=%q pid=%q", latest.X, latest.O, playerID)
+    if !ok || (latest.PlayerOf(domain.X) != playerID && latest.PlayerOf(domain.O) != playerID) {
+        t.Fatalf("expected auto-claim X or O; have X=%q O=%q pid=%q", latest.PlayerOf(domain.X), latest.PlayerOf(domain.O), playerID)
     }
     // SSE wiring present
     body := rr.Body.String()
@@ -89,7 +135,7 @@ func TestGamePageSetsCookieAndAutoClaims(t *testing.T) {
 
 func TestGamePageRendersPlayableBoard(t *testing.T) {
     svc, h := newTestServer(t)
-    gs, _ := svc.CreateGame()
+    gs, _ := svc.CreateGame(context.Background())
     req := httptest.NewRequest("GET", "/game/"+gs.ID, nil)
     rr := httptest.NewRecorder()
     h.ServeHTTP(rr, req)
@@ -116,7 +162,7 @@ func TestGamePageRendersPlayableBoard(t *testing.T) {
 func TestRenderBoardFragmentHasPlayURLs(t *testing.T) {
     svc := app.NewService()
     h := &handlers{svc: svc, tpl: loadTemplates()}
-    gs, _ := svc.CreateGame()
+    gs, _ := svc.CreateGame(context.Background())
     html := string(h.renderBoard(*gs, ""))
     want := `hx-post="/game/` + gs.ID + `/play"`
     if cnt := strings.Count(html, want); cnt != 9 {
@@ -126,7 +172,7 @@ func TestRenderBoardFragmentHasPlayURLs(t *testing.T) {
 
 func TestJoinEndpointReturnsBoardFragment(t *testing.T) {
     svc, h := newTestServer(t)
-    gs, _ := svc.CreateGame()
+    gs, _ := svc.CreateGame(context.Background())
     // First GET to auto-claim X for p1
     req1 := httptest.NewRequest("GET", "/game/"+gs.ID, nil)
     rr1 := httptest.NewRecorder()
@@ -146,17 +192,17 @@ func TestJoinEndpointReturnsBoardFragment(t *testing.T) {
         t.Fatalf("expected board fragment, got %q", rr.Body.String())
     }
     latest, _ := svc.Get(gs.ID)
-    if latest.O != "p2" && latest.X != "p2" { // allow if X was free
-        t.Fatalf("expected seat for p2, got X=%q O=%q", latest.X, latest.O)
+    if latest.PlayerOf(domain.O) != "p2" && latest.PlayerOf(domain.X) != "p2" { // allow if X was free
+        t.Fatalf("expected seat for p2, got X=%q O=%q", latest.PlayerOf(domain.X), latest.PlayerOf(domain.O))
     }
 }
 
 func TestPlayEndpointUpdatesStateAndReturnsFragment(t *testing.T) {
     svc, h := newTestServer(t)
-    gs, _ := svc.CreateGame()
+    gs, _ := svc.CreateGame(context.Background())
     // Assign X and O
-    svc.Join(gs.ID, "p1")
-    svc.Join(gs.ID, "p2")
+    svc.Join(context.Background(), gs.ID, "p1")
+    svc.Join(context.Background(), gs.ID, "p2")
 
     form := url.Values{"r": {"0"}, "c": {"0"}, "side": {"X"}}
     req := httptest.NewRequest("POST", "/game/"+gs.ID+"/play", strings.NewReader(form.Encode()))
@@ -226,9 +272,9 @@ func TestEventsBroadcastsBoardOnPlay(t *testing.T) {
     svc, _ := newTestServer(t)
     // Build handlers directly to call events method
     h := &handlers{svc: svc, tpl: loadTemplates()}
-    gs, _ := svc.CreateGame()
-    svc.Join(gs.ID, "p1")
-    svc.Join(gs.ID, "p2")
+    gs, _ := svc.CreateGame(context.Background())
+    svc.Join(context.Background(), gs.ID, "p1")
+    svc.Join(context.Background(), gs.ID, "p2")
 
     // Prepare request with route param and Accept header
     req := httptest.NewRequest("GET", "/game/"+gs.ID+"/events", nil)
@@ -249,7 +295,7 @@ func TestEventsBroadcastsBoardOnPlay(t *testing.T) {
     // Small delay to allow subscription to register
     time.Sleep(20 * time.Millisecond)
     // Trigger a move to cause a broadcast
-    if _, err := svc.Play(gs.ID, "p1", 0, 0); err != nil {
+    if _, err := svc.Play(context.Background(), gs.ID, "p1", 0, 0); err != nil {
         t.Fatalf("play failed: %v", err)
     }
 
@@ -270,7 +316,7 @@ func TestEventsBroadcastsBoardOnPlay(t *testing.T) {
 func TestEventsHeartbeat(t *testing.T) {
     svc, _ := newTestServer(t)
     h := &handlers{svc: svc, tpl: loadTemplates()}
-    gs, _ := svc.CreateGame()
+    gs, _ := svc.CreateGame(context.Background())
     req := httptest.NewRequest("GET", "/game/"+gs.ID+"/events", nil)
     rc := chi.NewRouteContext()
     rc.URLParams.Add("id", gs.ID)
@@ -303,10 +349,10 @@ func TestEventsHeartbeat(t *testing.T) {
 
 func TestPlayEndpointRendersErrorAlert(t *testing.T) {
     svc, h := newTestServer(t)
-    gs, _ := svc.CreateGame()
+    gs, _ := svc.CreateGame(context.Background())
     // Assign both seats
-    svc.Join(gs.ID, "p1")
-    svc.Join(gs.ID, "p2")
+    svc.Join(context.Background(), gs.ID, "p1")
+    svc.Join(context.Background(), gs.ID, "p2")
     // O tries to play first (not your turn)
     form := url.Values{"r": {"0"}, "c": {"0"}, "side": {"O"}}
     req := httptest.NewRequest("POST", "/game/"+gs.ID+"/play", strings.NewReader(form.Encode()))
@@ -321,3 +367,518 @@ func TestPlayEndpointRendersErrorAlert(t *testing.T) {
         t.Fatalf("expected inline error alert, got: %q", rr.Body.String())
     }
 }
+
+func TestGamePageRendersPresence(t *testing.T) {
+    svc, h := newTestServer(t)
+    gs, _ := svc.CreateGame(context.Background())
+    req := httptest.NewRequest("GET", "/game/"+gs.ID, nil)
+    rr := httptest.NewRecorder()
+    h.ServeHTTP(rr, req)
+    body := rr.Body.String()
+    if !strings.Contains(body, `id="presence"`) || !strings.Contains(body, `hx-sse="swap:presence"`) {
+        t.Fatalf("expected presence fragment with SSE swap; body=%q", body)
+    }
+    if !strings.Contains(body, "0 watching") {
+        t.Fatalf("expected spectator count; body=%q", body)
+    }
+}
+
+func TestEventsBroadcastsPresence(t *testing.T) {
+    svc, _ := newTestServer(t)
+    h := &handlers{svc: svc, tpl: loadTemplates()}
+    svc.SetPresenceRenderer(h.renderPresence)
+    gs, _ := svc.CreateGame(context.Background())
+    svc.Join(context.Background(), gs.ID, "p1")
+
+    req := httptest.NewRequest("GET", "/game/"+gs.ID+"/events", nil)
+    rc := chi.NewRouteContext()
+    rc.URLParams.Add("id", gs.ID)
+    ctx, cancel := context.WithCancel(context.WithValue(req.Context(), chi.RouteCtxKey, rc))
+    defer cancel()
+    req = req.WithContext(ctx)
+    req.Header.Set("Accept", "text/event-stream")
+    req.AddCookie(&http.Cookie{Name: "player_id", Value: "p1"})
+    rw := &flushRecorder{header: make(http.Header)}
+    go h.events(rw, req)
+
+    deadline := time.Now().Add(2 * time.Second)
+    for time.Now().Before(deadline) {
+        if strings.Contains(rw.String(), "event: presence") {
+            break
+        }
+        time.Sleep(10 * time.Millisecond)
+    }
+    out := rw.String()
+    if !strings.Contains(out, "event: presence") || !strings.Contains(out, "X online") {
+        t.Fatalf("expected presence event with X online, got: %q", out)
+    }
+    if p, _ := svc.Presence(gs.ID); !p.XOnline {
+        t.Fatalf("expected X online while connected, got %+v", p)
+    }
+}
+
+func TestChatEndpointStoresMessageAndResetsForm(t *testing.T) {
+    svc, h := newTestServer(t)
+    gs, _ := svc.CreateGame(context.Background())
+    svc.Join(context.Background(), gs.ID, "p1")
+
+    form := url.Values{"text": {"<b>hi</b>"}}
+    req := httptest.NewRequest("POST", "/game/"+gs.ID+"/chat", strings.NewReader(form.Encode()))
+    req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+    req.AddCookie(&http.Cookie{Name: "player_id", Value: "p1"})
+    rr := httptest.NewRecorder()
+    h.ServeHTTP(rr, req)
+    if rr.Code != http.StatusOK {
+        t.Fatalf("expected 200, got %d", rr.Code)
+    }
+    if !strings.Contains(rr.Body.String(), `id="chat-form"`) || strings.Contains(rr.Body.String(), "alert") {
+        t.Fatalf("expected clean chat form, got %q", rr.Body.String())
+    }
+    latest, _ := svc.Get(gs.ID)
+    if len(latest.Chat) != 1 {
+        t.Fatalf("expected stored chat message, got %+v", latest.Chat)
+    }
+
+    // The game page shows the history, escaped
+    req = httptest.NewRequest("GET", "/game/"+gs.ID, nil)
+    req.AddCookie(&http.Cookie{Name: "player_id", Value: "p1"})
+    rr = httptest.NewRecorder()
+    h.ServeHTTP(rr, req)
+    body := rr.Body.String()
+    if !strings.Contains(body, `hx-sse="swap:chat"`) || !strings.Contains(body, "&lt;b&gt;hi&lt;/b&gt;") {
+        t.Fatalf("expected escaped chat history on game page; body=%q", body)
+    }
+}
+
+func TestChatEndpointRendersErrorAlert(t *testing.T) {
+    svc, h := newTestServer(t)
+    gs, _ := svc.CreateGame(context.Background())
+    form := url.Values{"text": {strings.Repeat("a", app.MaxChatLength+1)}}
+    req := httptest.NewRequest("POST", "/game/"+gs.ID+"/chat", strings.NewReader(form.Encode()))
+    req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+    req.AddCookie(&http.Cookie{Name: "player_id", Value: "p1"})
+    rr := httptest.NewRecorder()
+    h.ServeHTTP(rr, req)
+    if !strings.Contains(rr.Body.String(), "longer than") {
+        t.Fatalf("expected length error alert, got %q", rr.Body.String())
+    }
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+    _, h := newTestServer(t)
+    rr := httptest.NewRecorder()
+    h.ServeHTTP(rr, httptest.NewRequest("POST", "/game", nil))
+    loc := rr.Result().Header.Get("Location")
+    h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", loc, nil))
+    ctx, cancel := context.WithCancel(context.Background())
+    cancel()
+    h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", loc+"/events", nil).WithContext(ctx))
+
+    rr = httptest.NewRecorder()
+    h.ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+    if rr.Code != http.StatusOK {
+        t.Fatalf("expected 200, got %d", rr.Code)
+    }
+    body := rr.Body.String()
+    for _, want := range []string{
+        "ttt_games_created_total 1",
+        "ttt_games_active 1",
+        "ttt_sse_subscribers 0",
+        `ttt_http_request_duration_seconds_count{code="303",method="POST",route="/game"} 1`,
+        `ttt_http_request_duration_seconds_count{code="200",method="GET",route="/game/{id}"} 1`,
+        `ttt_http_stream_duration_seconds_count{route="/game/{id}/events"} 1`,
+    } {
+        if !strings.Contains(body, want) {
+            t.Fatalf("metrics missing %q; body=%s", want, body)
+        }
+    }
+    if strings.Contains(body, `ttt_http_request_duration_seconds_count{code="200",method="GET",route="/game/{id}/events"}`) {
+        t.Fatalf("event stream counted as a request; body=%s", body)
+    }
+}
+
+func TestBoardShowsTurnAndWaitingStatus(t *testing.T) {
+    svc := app.NewService()
+    h := &handlers{svc: svc, tpl: loadTemplates()}
+    gs, _ := svc.CreateGame(context.Background())
+    svc.Join(context.Background(), gs.ID, "p1")
+    latest, _ := svc.Get(gs.ID)
+    html := string(h.renderBoard(*latest, ""))
+    if !strings.Contains(html, "Waiting for an opponent · X to move") {
+        t.Fatalf("expected waiting status with turn, got %q", html)
+    }
+    svc.Join(context.Background(), gs.ID, "p2")
+    latest, _ = svc.Play(context.Background(), gs.ID, "p1", 1, 1)
+    html = string(h.renderBoard(*latest, ""))
+    if !strings.Contains(html, "O to move") || strings.Contains(html, "Waiting") {
+        t.Fatalf("expected O to move, got %q", html)
+    }
+    if cnt := strings.Count(html, " disabled"); cnt != 1 {
+        t.Fatalf("expected only the occupied cell disabled, got %d", cnt)
+    }
+}
+
+func TestBoardHighlightsWinningLineAndDisablesCells(t *testing.T) {
+    svc := app.NewService()
+    h := &handlers{svc: svc, tpl: loadTemplates()}
+    gs, _ := svc.CreateGame(context.Background())
+    svc.Join(context.Background(), gs.ID, "p1")
+    svc.Join(context.Background(), gs.ID, "p2")
+    var latest *app.GameState
+    for i, m := range [][2]int{{0, 0}, {1, 0}, {0, 1}, {1, 1}, {0, 2}} {
+        pid := "p1"
+        if i%2 == 1 {
+            pid = "p2"
+        }
+        latest, _ = svc.Play(context.Background(), gs.ID, pid, m[0], m[1])
+    }
+    html := string(h.renderBoard(*latest, ""))
+    if !strings.Contains(html, "X wins!") {
+        t.Fatalf("expected winner status, got %q", html)
+    }
+    if cnt := strings.Count(html, `class="cell win"`); cnt != 3 {
+        t.Fatalf("expected 3 highlighted cells, got %d", cnt)
+    }
+    if cnt := strings.Count(html, " disabled"); cnt != 9 {
+        t.Fatalf("expected all cells disabled after the game ended, got %d", cnt)
+    }
+}
+
+func TestGamePageShowsViewerSeat(t *testing.T) {
+    svc, h := newTestServer(t)
+    gs, _ := svc.CreateGame(context.Background())
+    svc.Join(context.Background(), gs.ID, "p1")
+    svc.Join(context.Background(), gs.ID, "p2")
+    req := httptest.NewRequest("GET", "/game/"+gs.ID, nil)
+    req.AddCookie(&http.Cookie{Name: "player_id", Value: "p2"})
+    rr := httptest.NewRecorder()
+    h.ServeHTTP(rr, req)
+    if !strings.Contains(rr.Body.String(), "You are playing O") {
+        t.Fatalf("expected seat indicator for O; body=%q", rr.Body.String())
+    }
+    req = httptest.NewRequest("GET", "/game/"+gs.ID, nil)
+    req.AddCookie(&http.Cookie{Name: "player_id", Value: "p3"})
+    rr = httptest.NewRecorder()
+    h.ServeHTTP(rr, req)
+    if !strings.Contains(rr.Body.String(), "You are spectating") {
+        t.Fatalf("expected spectator indicator; body=%q", rr.Body.String())
+    }
+}
+
+func TestCreateAgainstComputer(t *testing.T) {
+    svc, h := newTestServer(t)
+    form := url.Values{"bot": {"computer"}, "first": {"o"}}
+    req := httptest.NewRequest("POST", "/game", strings.NewReader(form.Encode()))
+    req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+    rr := httptest.NewRecorder()
+    h.ServeHTTP(rr, req)
+    if rr.Code != http.StatusSeeOther {
+        t.Fatalf("expected redirect, got %d: %s", rr.Code, rr.Body.String())
+    }
+    id := strings.TrimPrefix(rr.Header().Get("Location"), "/game/")
+    // The computer takes O, leaving X to the creator, and opens the game.
+    deadline := time.Now().Add(2 * time.Second)
+    for {
+        gs, _ := svc.Get(id)
+        if gs.PlayerOf(domain.O) == botPrefix+computerBot && gs.CreatorSide == domain.X && gs.Game.Moves == 1 {
+            break
+        }
+        if time.Now().After(deadline) {
+            t.Fatalf("computer did not take its seat and move: %+v", gs)
+        }
+        time.Sleep(5 * time.Millisecond)
+    }
+
+    // On the Qubic board the computer searches instead of solving.
+    form = url.Values{"bot": {"computer"}, "variant": {"qubic"}, "first": {"o"}}
+    req = httptest.NewRequest("POST", "/game", strings.NewReader(form.Encode()))
+    req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+    rr = httptest.NewRecorder()
+    h.ServeHTTP(rr, req)
+    id = strings.TrimPrefix(rr.Header().Get("Location"), "/game/")
+    for {
+        if gs, _ := svc.Get(id); gs.Game.Moves == 1 {
+            break
+        }
+        if time.Now().After(deadline) {
+            t.Fatalf("computer did not open the Qubic game")
+        }
+        time.Sleep(5 * time.Millisecond)
+    }
+
+    // Order and Chaos has no computer opponent; no game is created.
+    games := svc.Stats().Games
+    form = url.Values{"bot": {"computer"}, "variant": {"orderchaos"}}
+    req = httptest.NewRequest("POST", "/game", strings.NewReader(form.Encode()))
+    req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+    rr = httptest.NewRecorder()
+    h.ServeHTTP(rr, req)
+    if rr.Code != http.StatusBadRequest || svc.Stats().Games != games {
+        t.Fatalf("expected Order and Chaos against the computer to be refused, got %d", rr.Code)
+    }
+
+    if _, err := NewServerWithOptions(svc, Options{BotTokens: map[string]string{"tok": computerBot}}); err == nil {
+        t.Fatalf("expected an error for a bot account named %q", computerBot)
+    }
+}
+
+func TestCreateFromPosition(t *testing.T) {
+    svc, h := newTestServer(t)
+    form := url.Values{"position": {"X.O/.X./..O x"}}
+    req := httptest.NewRequest("POST", "/game/position", strings.NewReader(form.Encode()))
+    req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+    rr := httptest.NewRecorder()
+    h.ServeHTTP(rr, req)
+    if rr.Code != http.StatusSeeOther {
+        t.Fatalf("expected redirect, got %d: %s", rr.Code, rr.Body.String())
+    }
+    id := strings.TrimPrefix(rr.Header().Get("Location"), "/game/")
+    gs, ok := svc.Get(id)
+    if !ok {
+        t.Fatalf("expected game %q to exist", id)
+    }
+    if gs.Game.Moves != 4 || gs.Game.Turn != domain.X || gs.Game.Board[0] != domain.X || gs.Game.Board[8] != domain.O {
+        t.Fatalf("game does not match position: %+v", gs.Game)
+    }
+    html := string((&handlers{svc: svc, tpl: loadTemplates()}).renderBoard(*gs, ""))
+    if !strings.Contains(html, "<code>X.O/.X./..O x</code>") {
+        t.Fatalf("expected board to show shareable position, got %q", html)
+    }
+}
+
+func TestCreateFromInvalidPositionShowsError(t *testing.T) {
+    svc, h := newTestServer(t)
+    form := url.Values{"position": {"XXX/OOO/... -"}}
+    req := httptest.NewRequest("POST", "/game/position", strings.NewReader(form.Encode()))
+    req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+    rr := httptest.NewRecorder()
+    h.ServeHTTP(rr, req)
+    if rr.Code != http.StatusBadRequest {
+        t.Fatalf("expected 400, got %d", rr.Code)
+    }
+    body := rr.Body.String()
+    if !strings.Contains(body, "both sides have a line") || !strings.Contains(body, `value="XXX/OOO/... -"`) {
+        t.Fatalf("expected error and echoed position; body=%q", body)
+    }
+    if st := svc.Stats(); st.Games != 0 {
+        t.Fatalf("invalid position must not create a game")
+    }
+}
+
+func TestCreateWithVariant(t *testing.T) {
+    svc, h := newTestServer(t)
+    form := url.Values{"variant": {"misere"}}
+    req := httptest.NewRequest("POST", "/game", strings.NewReader(form.Encode()))
+    req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+    rr := httptest.NewRecorder()
+    h.ServeHTTP(rr, req)
+    if rr.Code != http.StatusSeeOther {
+        t.Fatalf("expected redirect, got %d: %s", rr.Code, rr.Body.String())
+    }
+    id := strings.TrimPrefix(rr.Header().Get("Location"), "/game/")
+    gs, ok := svc.Get(id)
+    if !ok || gs.Game.Variant != domain.Misere {
+        t.Fatalf("expected a misère game, got %+v", gs)
+    }
+    html := string((&handlers{svc: svc, tpl: loadTemplates()}).renderBoard(*gs, ""))
+    if !strings.Contains(html, "Misère: three in a row loses") {
+        t.Fatalf("expected variant on the board, got %q", html)
+    }
+
+    form = url.Values{"variant": {"chess"}}
+    req = httptest.NewRequest("POST", "/game", strings.NewReader(form.Encode()))
+    req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+    rr = httptest.NewRecorder()
+    h.ServeHTTP(rr, req)
+    if rr.Code != http.StatusBadRequest {
+        t.Fatalf("expected 400 for unknown variant, got %d", rr.Code)
+    }
+}
+
+func TestWildPlayCarriesChosenMark(t *testing.T) {
+    svc, h := newTestServer(t)
+    gs, _ := svc.CreateGameWithOptions(context.Background(), app.GameOptions{Variant: domain.Wild})
+    svc.Join(context.Background(), gs.ID, "p1")
+    svc.Join(context.Background(), gs.ID, "p2")
+
+    html := string((&handlers{svc: svc, tpl: loadTemplates()}).renderBoard(*gs, ""))
+    if !strings.Contains(html, `name="mark" value="O"`) || !strings.Contains(html, `hx-include="#marks-`+gs.ID+`"`) {
+        t.Fatalf("expected a mark picker wired into the cell forms, got %q", html)
+    }
+
+    form := url.Values{"r": {"1"}, "c": {"1"}, "mark": {"O"}}
+    req := httptest.NewRequest("POST", "/game/"+gs.ID+"/play", strings.NewReader(form.Encode()))
+    req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+    req.AddCookie(&http.Cookie{Name: "player_id", Value: "p1"})
+    rr := httptest.NewRecorder()
+    h.ServeHTTP(rr, req)
+    if rr.Code != http.StatusOK {
+        t.Fatalf("expected 200, got %d", rr.Code)
+    }
+    got, _ := svc.Get(gs.ID)
+    if got.Game.Board[4] != domain.O || got.Game.Turn != domain.O {
+        t.Fatalf("expected X's seat to have placed an O, got %+v", got.Game)
+    }
+}
+
+func TestPlayRejectsForeignMarkInStandardGame(t *testing.T) {
+    svc, h := newTestServer(t)
+    gs, _ := svc.CreateGame(context.Background())
+    svc.Join(context.Background(), gs.ID, "p1")
+    form := url.Values{"r": {"0"}, "c": {"0"}, "mark": {"O"}}
+    req := httptest.NewRequest("POST", "/game/"+gs.ID+"/play", strings.NewReader(form.Encode()))
+    req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+    req.AddCookie(&http.Cookie{Name: "player_id", Value: "p1"})
+    rr := httptest.NewRecorder()
+    h.ServeHTTP(rr, req)
+    if !strings.Contains(rr.Body.String(), "You cannot place that mark") {
+        t.Fatalf("expected mark error, got %q", rr.Body.String())
+    }
+    if html := string((&handlers{svc: svc, tpl: loadTemplates()}).renderBoard(*gs, "")); strings.Contains(html, `name="mark"`) {
+        t.Fatalf("standard games must not offer a mark picker")
+    }
+}
+
+func TestNumericalBoardOffersNumbers(t *testing.T) {
+    svc, h := newTestServer(t)
+    gs, _ := svc.CreateGameWithOptions(context.Background(), app.GameOptions{Variant: domain.Numerical})
+    svc.Join(context.Background(), gs.ID, "p1")
+    svc.Join(context.Background(), gs.ID, "p2")
+
+    form := url.Values{"r": {"0"}, "c": {"0"}, "mark": {"5"}}
+    req := httptest.NewRequest("POST", "/game/"+gs.ID+"/play", strings.NewReader(form.Encode()))
+    req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+    req.AddCookie(&http.Cookie{Name: "player_id", Value: "p1"})
+    rr := httptest.NewRecorder()
+    h.ServeHTTP(rr, req)
+    html := rr.Body.String()
+    if !strings.Contains(html, `aria-label="row 1, column 1">5</button>`) {
+        t.Fatalf("expected 5 on the board, got %q", html)
+    }
+    if !strings.Contains(html, "X has 1 3 7 9 · O has 2 4 6 8") {
+        t.Fatalf("expected remaining numbers, got %q", html)
+    }
+    for _, n := range []string{"2", "4", "6", "8"} {
+        if !strings.Contains(html, `name="mark" value="`+n+`"`) {
+            t.Fatalf("expected O's picker to offer %s, got %q", n, html)
+        }
+    }
+    if strings.Contains(html, `name="mark" value="1"`) {
+        t.Fatalf("O must not be offered odd numbers")
+    }
+}
+
+func TestQubicBoardShowsLayers(t *testing.T) {
+    svc, h := newTestServer(t)
+    gs, _ := svc.CreateGameWithOptions(context.Background(), app.GameOptions{Variant: domain.Qubic})
+    svc.Join(context.Background(), gs.ID, "p1")
+    svc.Join(context.Background(), gs.ID, "p2")
+
+    // Row 5 is the second row of the second layer.
+    form := url.Values{"r": {"5"}, "c": {"3"}}
+    req := httptest.NewRequest("POST", "/game/"+gs.ID+"/play", strings.NewReader(form.Encode()))
+    req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+    req.AddCookie(&http.Cookie{Name: "player_id", Value: "p1"})
+    rr := httptest.NewRecorder()
+    h.ServeHTTP(rr, req)
+    html := rr.Body.String()
+    if got := strings.Count(html, `class="cell`); got != 64 {
+        t.Fatalf("expected 64 cells, got %d", got)
+    }
+    for _, want := range []string{"Layer 4", `aria-label="layer 2, row 2, column 4">X</button>`, `class="board compact"`} {
+        if !strings.Contains(html, want) {
+            t.Fatalf("expected %q on the board, got %q", want, html)
+        }
+    }
+    got, _ := svc.Get(gs.ID)
+    if got.Game.Board[domain.Cube4.Index(1, 1, 3)] != domain.X {
+        t.Fatalf("expected X on layer 2, got %+v", got.Game.Board)
+    }
+}
+
+func TestOrderChaosBoardNamesRoles(t *testing.T) {
+    svc, h := newTestServer(t)
+    gs, _ := svc.CreateGameWithOptions(context.Background(), app.GameOptions{Variant: domain.OrderChaos})
+    svc.Join(context.Background(), gs.ID, "p1")
+    svc.Join(context.Background(), gs.ID, "p2")
+
+    hs := &handlers{svc: svc, tpl: loadTemplates()}
+    html := string(hs.renderBoard(*gs, ""))
+    if got := strings.Count(html, `class="cell`); got != 36 {
+        t.Fatalf("expected 36 cells, got %d", got)
+    }
+    for _, want := range []string{"Order to move", `name="mark" value="X"`, `name="mark" value="O"`} {
+        if !strings.Contains(html, want) {
+            t.Fatalf("expected %q on the board, got %q", want, html)
+        }
+    }
+
+    // Five O's in the top row win for Order, whoever placed them.
+    for c := 0; c < 5; c++ {
+        pid := "p1"
+        if c%2 == 1 {
+            pid = "p2"
+        }
+        form := url.Values{"r": {"0"}, "c": {strconv.Itoa(c)}, "mark": {"O"}}
+        req := httptest.NewRequest("POST", "/game/"+gs.ID+"/play", strings.NewReader(form.Encode()))
+        req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+        req.AddCookie(&http.Cookie{Name: "player_id", Value: pid})
+        rr := httptest.NewRecorder()
+        h.ServeHTTP(rr, req)
+        html = rr.Body.String()
+    }
+    if !strings.Contains(html, "Order wins!") {
+        t.Fatalf("expected Order to win, got %q", html)
+    }
+    presence, _ := svc.Presence(gs.ID)
+    if p := string(hs.renderPresence(presence)); !strings.Contains(p, "Order offline") || !strings.Contains(p, "Chaos offline") {
+        t.Fatalf("expected role names in presence, got %q", p)
+    }
+}
+
+func TestQuantumSpookyMarksAndCollapse(t *testing.T) {
+    svc, h := newTestServer(t)
+    gs, _ := svc.CreateGameWithOptions(context.Background(), app.GameOptions{Variant: domain.Quantum})
+    svc.Join(context.Background(), gs.ID, "p1")
+    svc.Join(context.Background(), gs.ID, "p2")
+    post := func(path, pid string, form url.Values) string {
+        req := httptest.NewRequest("POST", "/game/"+gs.ID+path, strings.NewReader(form.Encode()))
+        req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+        req.AddCookie(&http.Cookie{Name: "player_id", Value: pid})
+        rr := httptest.NewRecorder()
+        h.ServeHTTP(rr, req)
+        if rr.Code != http.StatusOK {
+            t.Fatalf("POST %s: expected 200, got %d", path, rr.Code)
+        }
+        return rr.Body.String()
+    }
+
+    if html := post("/spooky", "p1", url.Values{}); !strings.Contains(html, "Pick two squares") {
+        t.Fatalf("expected a prompt for two squares, got %q", html)
+    }
+    // A single square is only allowed for the last mark.
+    html := post("/spooky", "p1", url.Values{"cell": {"0"}})
+    if !strings.Contains(html, "Pick two different squares") {
+        t.Fatalf("expected a prompt for two different squares, got %q", html)
+    }
+    html = post("/spooky", "p1", url.Values{"cell": {"0", "4"}})
+    if strings.Count(html, `<span class="spooky">X<sub>1</sub></span>`) != 2 {
+        t.Fatalf("expected X₁ in two squares, got %q", html)
+    }
+    html = post("/spooky", "p2", url.Values{"cell": {"4", "0"}})
+    for _, want := range []string{"X chooses the collapse", `hx-post="/game/` + gs.ID + `/collapse"`, `<button type="submit">b2</button>`} {
+        if !strings.Contains(html, want) {
+            t.Fatalf("expected %q on the board, got %q", want, html)
+        }
+    }
+    if html = post("/collapse", "p2", url.Values{"cell": {"4"}}); !strings.Contains(html, "Not your turn") {
+        t.Fatalf("O closed the cycle and must not choose, got %q", html)
+    }
+    html = post("/collapse", "p1", url.Values{"cell": {"4"}})
+    if !strings.Contains(html, `<span class="classical">O<sub>2</sub></span>`) || !strings.Contains(html, `<span class="classical">X<sub>1</sub></span>`) {
+        t.Fatalf("expected both marks to be classical, got %q", html)
+    }
+    if strings.Contains(html, "Position:") {
+        t.Fatalf("quantum boards have no position notation")
+    }
+}
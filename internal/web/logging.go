@@ -0,0 +1,63 @@
+package web
+
+import (
+    "context"
+    "log/slog"
+    "net/http"
+    "time"
+
+    "github.com/go-chi/chi/v5"
+    "github.com/go-chi/chi/v5/middleware"
+    "github.com/google/uuid"
+    "github.com/jaminalder/codex-tic-tac-toe/internal/app"
+)
+
+// requestIDHeader carries the correlation ID; an incoming value is reused.
+const requestIDHeader = "X-Request-ID"
+
+// loggerFrom returns the request logger stored in ctx (see app.WithLogger),
+// or fallback, naming the game of the route's {id} parameter if there is one.
+// The service is handed the bare request logger and names the game itself.
+func loggerFrom(ctx context.Context, fallback *slog.Logger) *slog.Logger {
+    log := app.LoggerFrom(ctx, fallback)
+    if id := chi.URLParamFromCtx(ctx, "id"); id != "" {
+        log = log.With("game_id", id)
+    }
+    return log
+}
+
+// requestLogger assigns a request ID, attaches a logger carrying it to the
+// context with app.WithLogger, and logs each completed request.
+func requestLogger(base *slog.Logger) func(http.Handler) http.Handler {
+    return func(next http.Handler) http.Handler {
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            start := time.Now()
+            reqID := r.Header.Get(requestIDHeader)
+            if reqID == "" || len(reqID) > 64 {
+                reqID = uuid.NewString()
+            }
+            w.Header().Set(requestIDHeader, reqID)
+            log := base.With("request_id", reqID)
+            ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+            r = r.WithContext(app.WithLogger(r.Context(), log))
+            next.ServeHTTP(ww, r)
+
+            status := ww.Status()
+            if status == 0 {
+                status = http.StatusOK
+            }
+            route := ""
+            if rc := chi.RouteContext(r.Context()); rc != nil {
+                route = rc.RoutePattern()
+            }
+            log.Info("request",
+                "method", r.Method,
+                "path", r.URL.Path,
+                "route", route,
+                "status", status,
+                "bytes", ww.BytesWritten(),
+                "duration", time.Since(start),
+            )
+        })
+    }
+}
@@ -0,0 +1,118 @@
+package web
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "html/template"
+    "log/slog"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+
+    "github.com/jaminalder/codex-tic-tac-toe/internal/app"
+)
+
+func newLoggedServer(t *testing.T) (*app.Service, http.Handler, *bytes.Buffer) {
+    t.Helper()
+    var buf bytes.Buffer
+    log := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+    s := app.NewService()
+    s.SetLogger(log)
+    return s, newServerWithOptions(t, s, Options{Logger: log}), &buf
+}
+
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+    t.Helper()
+    var out []map[string]any
+    for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+        if line == "" {
+            continue
+        }
+        var rec map[string]any
+        if err := json.Unmarshal([]byte(line), &rec); err != nil {
+            t.Fatalf("bad log line %q: %v", line, err)
+        }
+        out = append(out, rec)
+    }
+    return out
+}
+
+func TestRequestIDPropagatedAndLogged(t *testing.T) {
+    svc, h, buf := newLoggedServer(t)
+    gs, _ := svc.CreateGame(context.Background())
+    svc.Join(context.Background(), gs.ID, "p1")
+    svc.Join(context.Background(), gs.ID, "p2")
+
+    req := httptest.NewRequest("POST", "/game/"+gs.ID+"/play", strings.NewReader("r=0&c=0"))
+    req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+    req.Header.Set("X-Request-ID", "req-123")
+    req.AddCookie(&http.Cookie{Name: "player_id", Value: "p2"})
+    rr := httptest.NewRecorder()
+    h.ServeHTTP(rr, req)
+    if got := rr.Header().Get("X-Request-ID"); got != "req-123" {
+        t.Fatalf("expected request ID echoed, got %q", got)
+    }
+
+    var rejected, access bool
+    for _, rec := range logRecords(t, buf) {
+        switch rec["msg"] {
+        case "move rejected":
+            rejected = rec["request_id"] == "req-123" && rec["game_id"] == gs.ID && rec["player_id"] == "p2"
+        case "request":
+            access = rec["request_id"] == "req-123" && rec["route"] == "/game/{id}/play"
+        }
+    }
+    if !rejected || !access {
+        t.Fatalf("expected correlated move and access logs; got %s", buf.String())
+    }
+}
+
+func TestServiceLogsCarryRequestID(t *testing.T) {
+    svc, h, buf := newLoggedServer(t)
+    gs, _ := svc.CreateGame(context.Background())
+    svc.Join(context.Background(), gs.ID, "p1")
+    svc.Join(context.Background(), gs.ID, "p2")
+
+    req := httptest.NewRequest("POST", "/game/"+gs.ID+"/play", strings.NewReader("r=0&c=0"))
+    req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+    req.Header.Set("X-Request-ID", "req-456")
+    req.AddCookie(&http.Cookie{Name: "player_id", Value: "p1"})
+    h.ServeHTTP(httptest.NewRecorder(), req)
+
+    for _, line := range strings.Split(buf.String(), "\n") {
+        if strings.Contains(line, `"msg":"move played"`) && strings.Count(line, `"game_id"`) != 1 {
+            t.Fatalf("game_id repeated in %s", line)
+        }
+    }
+    for _, rec := range logRecords(t, buf) {
+        if rec["msg"] == "move played" {
+            if rec["request_id"] != "req-456" || rec["game_id"] != gs.ID {
+                t.Fatalf("move log not correlated with its request: %v", rec)
+            }
+            return
+        }
+    }
+    t.Fatalf("no move log; got %s", buf.String())
+}
+
+func TestRequestIDGeneratedWhenMissing(t *testing.T) {
+    _, h, _ := newLoggedServer(t)
+    rr := httptest.NewRecorder()
+    h.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+    if rr.Header().Get("X-Request-ID") == "" {
+        t.Fatalf("expected generated request ID")
+    }
+}
+
+func TestTemplateFailureIsLogged(t *testing.T) {
+    var buf bytes.Buffer
+    h := &handlers{log: slog.New(slog.NewJSONHandler(&buf, nil))}
+    broken := template.Must(template.New("broken").Parse(`{{.Missing.Field}}`))
+    h.render(httptest.NewRequest("GET", "/", nil).Context(), broken, struct{}{})
+    recs := logRecords(t, &buf)
+    if len(recs) != 1 || recs[0]["msg"] != "template render failed" || recs[0]["template"] != "broken" {
+        t.Fatalf("expected render failure log, got %s", buf.String())
+    }
+}
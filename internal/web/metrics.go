@@ -0,0 +1,65 @@
+package web
+
+import (
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/go-chi/chi/v5"
+    "github.com/go-chi/chi/v5/middleware"
+    "github.com/prometheus/client_golang/prometheus"
+)
+
+// httpMetrics records request latency per chi route pattern. Event streams
+// (SSE and NDJSON) stay open for minutes, so their connection time goes to a
+// separate histogram with long buckets instead of skewing the latency one.
+type httpMetrics struct {
+    duration *prometheus.HistogramVec
+    streams  *prometheus.HistogramVec
+}
+
+func newHTTPMetrics(reg prometheus.Registerer) *httpMetrics {
+    m := &httpMetrics{
+        duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+            Name:    "ttt_http_request_duration_seconds",
+            Help:    "HTTP request latency by method, route and status code.",
+            Buckets: prometheus.DefBuckets,
+        }, []string{"method", "route", "code"}),
+        streams: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+            Name:    "ttt_http_stream_duration_seconds",
+            Help:    "Connection time of event streams by route.",
+            Buckets: prometheus.ExponentialBuckets(1, 4, 8), // 1s to about 4.5h
+        }, []string{"route"}),
+    }
+    reg.MustRegister(m.duration, m.streams)
+    return m
+}
+
+// middleware observes each request once routing has resolved its pattern.
+// Unmatched requests are grouped under "other" to bound label cardinality.
+func (m *httpMetrics) middleware(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        start := time.Now()
+        ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+        next.ServeHTTP(ww, r)
+        route := "other"
+        if rc := chi.RouteContext(r.Context()); rc != nil && rc.RoutePattern() != "" {
+            route = rc.RoutePattern()
+        }
+        code := ww.Status()
+        if code == 0 {
+            code = http.StatusOK
+        }
+        if isStream(ww.Header().Get("Content-Type")) && code == http.StatusOK {
+            m.streams.WithLabelValues(route).Observe(time.Since(start).Seconds())
+            return
+        }
+        m.duration.WithLabelValues(r.Method, route, strconv.Itoa(code)).Observe(time.Since(start).Seconds())
+    })
+}
+
+// isStream reports whether a response of contentType is an event stream.
+func isStream(contentType string) bool {
+    return strings.HasPrefix(contentType, "text/event-stream") || strings.HasPrefix(contentType, "application/x-ndjson")
+}
@@ -0,0 +1,153 @@
+package web
+
+import (
+    "net/http"
+    "strconv"
+    "strings"
+
+    "github.com/go-chi/chi/v5"
+    "github.com/jaminalder/codex-tic-tac-toe/internal/app"
+    "github.com/jaminalder/codex-tic-tac-toe/internal/domain"
+    "github.com/jaminalder/codex-tic-tac-toe/internal/record"
+)
+
+// quantumMark is a mark with its subscript, e.g. X₃.
+type quantumMark struct {
+    Symbol string
+    Move   int
+}
+
+// quantumCell is one square of a quantum board: a classical mark, or the
+// spooky marks still in superposition there.
+type quantumCell struct {
+    Index      int
+    Label      string
+    Classical  *quantumMark
+    Spooky     []quantumMark
+    Win        bool
+    Selectable bool // may receive the next spooky mark
+}
+
+// collapseChoice is a square the pending mark may collapse into.
+type collapseChoice struct {
+    Index int
+    Coord string
+}
+
+// quantumView is the part of boardView specific to Quantum games.
+type quantumView struct {
+    Rows [][]quantumCell
+    // CanPlace is set while the side to move places a spooky mark; Single
+    // when only one square is left, so the mark goes there classically.
+    CanPlace bool
+    Single   bool
+    // Pending is the mark awaiting collapse, Chooser the side choosing where
+    // it goes among Choices.
+    Pending *quantumMark
+    Chooser string
+    Choices []collapseChoice
+    // Score is set once the game is over, e.g. "X 1 · O ½".
+    Score string
+}
+
+func newQuantumView(gs app.GameState) *quantumView {
+    q := gs.Quantum
+    g := gs.Game
+    v := &quantumView{CanPlace: !g.Over && q.Pending == 0}
+    free := 0
+    for _, n := range q.Classical {
+        if n == 0 {
+            free++
+        }
+    }
+    v.Single = v.CanPlace && free == 1
+    mark := func(m domain.SpookyMark) quantumMark {
+        return quantumMark{Symbol: cellSymbol(m.Side), Move: m.Move}
+    }
+    for r := 0; r < 3; r++ {
+        row := make([]quantumCell, 3)
+        for c := range row {
+            idx := r*3 + c
+            cell := quantumCell{Index: idx, Label: "row " + strconv.Itoa(r+1) + ", column " + strconv.Itoa(c+1), Win: g.OnLine(idx)}
+            if n := q.Classical[idx]; n != 0 {
+                m := mark(q.Mark(n))
+                cell.Classical = &m
+            } else {
+                cell.Selectable = v.CanPlace
+                for _, m := range q.Spooky(idx) {
+                    cell.Spooky = append(cell.Spooky, mark(m))
+                }
+            }
+            row[c] = cell
+        }
+        v.Rows = append(v.Rows, row)
+    }
+    if q.Pending != 0 && !g.Over {
+        m := q.Mark(q.Pending)
+        pending := mark(m)
+        v.Pending = &pending
+        v.Chooser = gs.SeatName(q.Turn)
+        v.Choices = []collapseChoice{{m.A, record.Coord(m.A)}, {m.B, record.Coord(m.B)}}
+    }
+    if g.Over && !gs.Aborted {
+        v.Score = "X " + halfPoints(q.XScore) + " · O " + halfPoints(q.OScore)
+    }
+    return v
+}
+
+// halfPoints formats a score counted in half points, e.g. 3 -> "1½".
+func halfPoints(n int) string {
+    s := ""
+    if n/2 > 0 || n == 0 {
+        s = strconv.Itoa(n / 2)
+    }
+    if n%2 == 1 {
+        s += "½"
+    }
+    return s
+}
+
+// spooky places a spooky mark in the two squares checked in the "cell" form
+// field, or in the single one when it is the last square left.
+func (h *handlers) spooky(w http.ResponseWriter, r *http.Request) {
+    id := chi.URLParam(r, "id")
+    pid := ensurePlayerCookie(w, r)
+    _ = r.ParseForm()
+    var squares []int
+    for _, s := range r.Form["cell"] {
+        if n, err := strconv.Atoi(s); err == nil {
+            squares = append(squares, n)
+        }
+    }
+    if len(squares) == 1 {
+        squares = append(squares, squares[0])
+    }
+    if len(squares) != 2 {
+        h.writeBoard(w, r, id, nil, "Pick two squares")
+        return
+    }
+    gs, err := h.svc.PlaceSpooky(r.Context(), id, pid, squares[0], squares[1])
+    var errMsg string
+    if err != nil {
+        h.logger(r.Context()).Info("spooky mark rejected", "player_id", pid, "squares", squares, "err", err)
+        errMsg = moveErrorText(err)
+    }
+    h.writeBoard(w, r, id, gs, errMsg)
+}
+
+// collapse resolves a pending cycle into the square in the "cell" form field.
+func (h *handlers) collapse(w http.ResponseWriter, r *http.Request) {
+    id := chi.URLParam(r, "id")
+    pid := ensurePlayerCookie(w, r)
+    idx, err := strconv.Atoi(strings.TrimSpace(r.FormValue("cell")))
+    if err != nil {
+        idx = -1
+    }
+    gs, err := h.svc.Collapse(r.Context(), id, pid, idx)
+    var errMsg string
+    if err != nil {
+        h.logger(r.Context()).Info("collapse rejected", "player_id", pid, "square", idx, "err", err)
+        errMsg = moveErrorText(err)
+    }
+    h.writeBoard(w, r, id, gs, errMsg)
+}
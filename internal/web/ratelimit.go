@@ -0,0 +1,212 @@
+package web
+
+import (
+    "log/slog"
+    "math"
+    "net"
+    "net/http"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus"
+)
+
+// Limit is a token bucket refilled at Rate tokens per second up to Burst.
+// A zero Limit disables limiting.
+type Limit struct {
+    Rate  float64
+    Burst int
+}
+
+// RateLimits configures per-client limits by route class.
+type RateLimits struct {
+    Create Limit // POST /game
+    Play   Limit // POST /game/{id}/play
+    Events Limit // GET /game/{id}/events connection attempts
+    // MaxStreams caps concurrent event streams per client IP (0 = unlimited).
+    MaxStreams int
+    // TrustProxy takes the client IP from X-Forwarded-For / X-Real-IP.
+    TrustProxy bool
+}
+
+// DefaultRateLimits returns limits generous enough for people and tight enough for scripts.
+func DefaultRateLimits() RateLimits {
+    return RateLimits{
+        Create:     Limit{Rate: 0.2, Burst: 5},
+        Play:       Limit{Rate: 4, Burst: 20},
+        Events:     Limit{Rate: 1, Burst: 10},
+        MaxStreams: 8,
+    }
+}
+
+// bucketIdle is how long a full bucket is kept before it is swept.
+const bucketIdle = 10 * time.Minute
+
+type bucket struct {
+    tokens float64
+    last   time.Time
+}
+
+// limiter is a set of token buckets sharing one Limit.
+type limiter struct {
+    mu        sync.Mutex
+    limit     Limit
+    buckets   map[string]*bucket
+    now       func() time.Time
+    lastSweep time.Time
+}
+
+func newLimiter(l Limit) *limiter {
+    return &limiter{limit: l, buckets: make(map[string]*bucket), now: time.Now}
+}
+
+// allow takes a token for key; when none is left it reports how long to wait.
+func (l *limiter) allow(key string) (bool, time.Duration) {
+    if l.limit.Rate <= 0 || l.limit.Burst <= 0 {
+        return true, 0
+    }
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    now := l.now()
+    if now.Sub(l.lastSweep) > time.Minute {
+        l.sweepLocked(now)
+    }
+    b, ok := l.buckets[key]
+    if !ok {
+        b = &bucket{tokens: float64(l.limit.Burst), last: now}
+        l.buckets[key] = b
+    }
+    b.tokens = math.Min(float64(l.limit.Burst), b.tokens+now.Sub(b.last).Seconds()*l.limit.Rate)
+    b.last = now
+    if b.tokens >= 1 {
+        b.tokens--
+        return true, 0
+    }
+    wait := time.Duration((1 - b.tokens) / l.limit.Rate * float64(time.Second))
+    return false, wait
+}
+
+// sweepLocked forgets buckets that have been idle long enough to be full again.
+func (l *limiter) sweepLocked(now time.Time) {
+    for k, b := range l.buckets {
+        if now.Sub(b.last) > bucketIdle {
+            delete(l.buckets, k)
+        }
+    }
+    l.lastSweep = now
+}
+
+// rateLimiter applies RateLimits to route classes.
+type rateLimiter struct {
+    cfg     RateLimits
+    classes map[string]*limiter
+    limited *prometheus.CounterVec
+    log     *slog.Logger
+
+    mu      sync.Mutex
+    streams map[string]int
+}
+
+func newRateLimiter(cfg RateLimits, reg prometheus.Registerer, log *slog.Logger) *rateLimiter {
+    rl := &rateLimiter{
+        cfg: cfg,
+        log: log,
+        classes: map[string]*limiter{
+            "create": newLimiter(cfg.Create),
+            "play":   newLimiter(cfg.Play),
+            "events": newLimiter(cfg.Events),
+        },
+        limited: prometheus.NewCounterVec(prometheus.CounterOpts{
+            Name: "ttt_http_rate_limited_total",
+            Help: "Requests refused by the rate limiter, by route class.",
+        }, []string{"class"}),
+        streams: make(map[string]int),
+    }
+    reg.MustRegister(rl.limited)
+    return rl
+}
+
+// clientIP returns the requesting client's address.
+func (rl *rateLimiter) clientIP(r *http.Request) string {
+    if rl.cfg.TrustProxy {
+        if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+            first, _, _ := strings.Cut(xff, ",")
+            return strings.TrimSpace(first)
+        }
+        if ip := r.Header.Get("X-Real-IP"); ip != "" {
+            return ip
+        }
+    }
+    host, _, err := net.SplitHostPort(r.RemoteAddr)
+    if err != nil {
+        return r.RemoteAddr
+    }
+    return host
+}
+
+// limit returns middleware enforcing the bucket of class. Each request must
+// pass both the client IP bucket and, when a cookie is present, the player ID
+// bucket, so rotating cookies does not escape the IP limit.
+func (rl *rateLimiter) limit(class string) func(http.Handler) http.Handler {
+    l := rl.classes[class]
+    return func(next http.Handler) http.Handler {
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            ok, wait := l.allow("ip:" + rl.clientIP(r))
+            if c, err := r.Cookie("player_id"); ok && err == nil && c.Value != "" {
+                ok, wait = l.allow("player:" + c.Value)
+            }
+            if !ok {
+                rl.limited.WithLabelValues(class).Inc()
+                loggerFrom(r.Context(), rl.log).Info("rate limited", "class", class, "client_ip", rl.clientIP(r))
+                writeTooManyRequests(w, wait, "Too many requests, slow down.")
+                return
+            }
+            next.ServeHTTP(w, r)
+        })
+    }
+}
+
+// capStreams caps concurrent event streams per client IP.
+func (rl *rateLimiter) capStreams(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if rl.cfg.MaxStreams <= 0 {
+            next.ServeHTTP(w, r)
+            return
+        }
+        ip := rl.clientIP(r)
+        rl.mu.Lock()
+        if rl.streams[ip] >= rl.cfg.MaxStreams {
+            rl.mu.Unlock()
+            rl.limited.WithLabelValues("streams").Inc()
+            writeTooManyRequests(w, 5*time.Second, "Too many open game streams.")
+            return
+        }
+        rl.streams[ip]++
+        rl.mu.Unlock()
+        defer func() {
+            rl.mu.Lock()
+            if rl.streams[ip]--; rl.streams[ip] <= 0 {
+                delete(rl.streams, ip)
+            }
+            rl.mu.Unlock()
+        }()
+        next.ServeHTTP(w, r)
+    })
+}
+
+// writeTooManyRequests renders a 429 as an alert fragment. HTMX requests are
+// retargeted to the page's #alerts container.
+func writeTooManyRequests(w http.ResponseWriter, wait time.Duration, msg string) {
+    secs := int(math.Ceil(wait.Seconds()))
+    if secs < 1 {
+        secs = 1
+    }
+    w.Header().Set("Retry-After", strconv.Itoa(secs))
+    w.Header().Set("Content-Type", "text/html; charset=utf-8")
+    w.Header().Set("HX-Retarget", "#alerts")
+    w.Header().Set("HX-Reswap", "innerHTML")
+    w.WriteHeader(http.StatusTooManyRequests)
+    _, _ = w.Write([]byte(`<div class="alert" role="alert">` + msg + ` Try again in ` + strconv.Itoa(secs) + `s.</div>`))
+}
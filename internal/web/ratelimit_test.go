@@ -0,0 +1,139 @@
+package web
+
+import (
+    "context"
+    "log/slog"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/go-chi/chi/v5"
+    "github.com/jaminalder/codex-tic-tac-toe/internal/app"
+    "github.com/prometheus/client_golang/prometheus"
+)
+
+func TestLimiterRefillsOverTime(t *testing.T) {
+    now := time.Unix(0, 0)
+    l := newLimiter(Limit{Rate: 1, Burst: 2})
+    l.now = func() time.Time { return now }
+
+    for i := 0; i < 2; i++ {
+        if ok, _ := l.allow("k"); !ok {
+            t.Fatalf("request %d within burst should pass", i)
+        }
+    }
+    ok, wait := l.allow("k")
+    if ok || wait != time.Second {
+        t.Fatalf("expected refusal with 1s wait, got ok=%v wait=%v", ok, wait)
+    }
+    if ok, _ := l.allow("other"); !ok {
+        t.Fatalf("separate keys must not share a bucket")
+    }
+    now = now.Add(time.Second)
+    if ok, _ := l.allow("k"); !ok {
+        t.Fatalf("expected token after refill")
+    }
+}
+
+func TestLimiterZeroLimitDisables(t *testing.T) {
+    l := newLimiter(Limit{})
+    for i := 0; i < 100; i++ {
+        if ok, _ := l.allow("k"); !ok {
+            t.Fatalf("zero limit should never refuse")
+        }
+    }
+}
+
+func TestCreateRateLimitedWithFragment(t *testing.T) {
+    s := app.NewService()
+    h := newServerWithOptions(t, s, Options{RateLimits: &RateLimits{Create: Limit{Rate: 0.001, Burst: 2}}})
+    for i := 0; i < 2; i++ {
+        rr := httptest.NewRecorder()
+        h.ServeHTTP(rr, httptest.NewRequest("POST", "/game", nil))
+        if rr.Code != http.StatusSeeOther {
+            t.Fatalf("create %d: expected redirect, got %d", i, rr.Code)
+        }
+    }
+    req := httptest.NewRequest("POST", "/game", nil)
+    req.Header.Set("HX-Request", "true")
+    rr := httptest.NewRecorder()
+    h.ServeHTTP(rr, req)
+    if rr.Code != http.StatusTooManyRequests {
+        t.Fatalf("expected 429, got %d", rr.Code)
+    }
+    if rr.Header().Get("Retry-After") == "" || rr.Header().Get("HX-Retarget") != "#alerts" {
+        t.Fatalf("expected Retry-After and HX-Retarget headers, got %v", rr.Header())
+    }
+    if !strings.Contains(rr.Body.String(), `class="alert"`) {
+        t.Fatalf("expected alert fragment, got %q", rr.Body.String())
+    }
+    if st := s.Stats(); st.Games != 2 {
+        t.Fatalf("limited request must not create a game, have %d", st.Games)
+    }
+}
+
+func TestPlayRateLimitedPerPlayerAcrossIPs(t *testing.T) {
+    s := app.NewService()
+    h := newServerWithOptions(t, s, Options{RateLimits: &RateLimits{Play: Limit{Rate: 0.001, Burst: 1}}})
+    gs, _ := s.CreateGame(context.Background())
+    play := func(ip string) int {
+        req := httptest.NewRequest("POST", "/game/"+gs.ID+"/play", strings.NewReader("r=0&c=0"))
+        req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+        req.RemoteAddr = ip + ":1234"
+        req.AddCookie(&http.Cookie{Name: "player_id", Value: "p1"})
+        rr := httptest.NewRecorder()
+        h.ServeHTTP(rr, req)
+        return rr.Code
+    }
+    if code := play("10.0.0.1"); code != http.StatusOK {
+        t.Fatalf("first play: expected 200, got %d", code)
+    }
+    if code := play("10.0.0.2"); code != http.StatusTooManyRequests {
+        t.Fatalf("same player from new IP: expected 429, got %d", code)
+    }
+}
+
+func TestConcurrentStreamsCapped(t *testing.T) {
+    s := app.NewService()
+    rl := newRateLimiter(RateLimits{MaxStreams: 1}, prometheus.NewRegistry(), slog.Default())
+    gs, _ := s.CreateGame(context.Background())
+    h := &handlers{svc: s, tpl: loadTemplates()}
+    handler := rl.capStreams(http.HandlerFunc(h.events))
+
+    newReq := func(ctx context.Context) *http.Request {
+        req := httptest.NewRequest("GET", "/game/"+gs.ID+"/events", nil)
+        rc := chi.NewRouteContext()
+        rc.URLParams.Add("id", gs.ID)
+        req = req.WithContext(context.WithValue(ctx, chi.RouteCtxKey, rc))
+        req.Header.Set("Accept", "text/event-stream")
+        return req
+    }
+    ctx, cancel := context.WithCancel(context.Background())
+    done := make(chan struct{})
+    go func() {
+        defer close(done)
+        handler.ServeHTTP(&flushRecorder{header: make(http.Header)}, newReq(ctx))
+    }()
+    deadline := time.Now().Add(2 * time.Second)
+    for s.Stats().Subscribers == 0 && time.Now().Before(deadline) {
+        time.Sleep(5 * time.Millisecond)
+    }
+
+    rr := httptest.NewRecorder()
+    handler.ServeHTTP(rr, newReq(context.Background()))
+    if rr.Code != http.StatusTooManyRequests {
+        t.Fatalf("expected 429 for second stream, got %d", rr.Code)
+    }
+
+    cancel()
+    <-done
+    rr2 := &flushRecorder{header: make(http.Header)}
+    ctx2, cancel2 := context.WithCancel(context.Background())
+    cancel2()
+    handler.ServeHTTP(rr2, newReq(ctx2))
+    if rr2.code == http.StatusTooManyRequests {
+        t.Fatalf("slot should be released after the first stream ends")
+    }
+}
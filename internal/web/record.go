@@ -0,0 +1,170 @@
+package web
+
+import (
+    "html/template"
+    "io"
+    "net/http"
+    "strconv"
+    "strings"
+
+    "github.com/go-chi/chi/v5"
+    "github.com/jaminalder/codex-tic-tac-toe/internal/app"
+    "github.com/jaminalder/codex-tic-tac-toe/internal/domain"
+    "github.com/jaminalder/codex-tic-tac-toe/internal/record"
+)
+
+// maxRecordSize bounds uploaded game records.
+const maxRecordSize = 64 << 10
+
+// gameRecord builds the portable record of gs.
+func gameRecord(gs app.GameState, site string) record.Record {
+    rec := record.FromGame(gs.Game,
+        record.Tag{Name: "Event", Value: "Casual game"},
+        record.Tag{Name: "Site", Value: site},
+        record.Tag{Name: "Date", Value: gs.Created.UTC().Format("2006.01.02")},
+        record.Tag{Name: "X", Value: playerLabel(gs, domain.X)},
+        record.Tag{Name: "O", Value: playerLabel(gs, domain.O)},
+        record.Tag{Name: "TimeControl", Value: "-"},
+    )
+    if gs.Aborted {
+        rec.SetTag("Termination", "ended by an administrator")
+    }
+    return rec
+}
+
+// playerLabel names the player in seat for a record. Player IDs double as
+// the players' credentials, so only bots, whose IDs are granted by token, are
+// named; anyone else goes by their seat. An empty seat is "?".
+func playerLabel(gs app.GameState, seat domain.Cell) string {
+    id := gs.PlayerOf(seat)
+    switch {
+    case id == "":
+        return "?"
+    case strings.HasPrefix(id, botPrefix):
+        return strings.TrimPrefix(id, botPrefix)
+    }
+    return gs.SeatName(seat)
+}
+
+// export downloads the record of a finished game.
+func (h *handlers) export(w http.ResponseWriter, r *http.Request) {
+    id := chi.URLParam(r, "id")
+    gs, ok := h.svc.Get(id)
+    if !ok {
+        http.NotFound(w, r)
+        return
+    }
+    if !gs.Game.Over {
+        http.Error(w, "game is still in progress", http.StatusConflict)
+        return
+    }
+    if gs.Game.Variant == domain.Quantum {
+        http.Error(w, "quantum games have no record", http.StatusConflict)
+        return
+    }
+    w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+    w.Header().Set("Content-Disposition", `attachment; filename="game-`+id+`.ttt"`)
+    _ = record.Write(w, gameRecord(*gs, r.Host))
+}
+
+// importRecord recreates a game from an uploaded record and opens its replay.
+// The record comes from the "record" form field or an uploaded "file".
+func (h *handlers) importRecord(w http.ResponseWriter, r *http.Request) {
+    r.Body = http.MaxBytesReader(w, r.Body, maxRecordSize)
+    src := r.FormValue("record")
+    if f, _, err := r.FormFile("file"); err == nil {
+        b, err := io.ReadAll(f)
+        f.Close()
+        if err == nil && len(b) > 0 {
+            src = string(b)
+        }
+    }
+    rec, err := record.Parse(src)
+    var g domain.Game
+    if err == nil {
+        g, err = rec.Game()
+    }
+    if err != nil {
+        h.renderIndex(w, r, http.StatusBadRequest, indexData{ImportError: err.Error(), Record: src})
+        return
+    }
+    gs, err := h.svc.CreateGameFrom(r.Context(), g)
+    if err != nil {
+        h.logger(r.Context()).Error("create game failed", "err", err)
+        http.Error(w, "failed to create", http.StatusInternalServerError)
+        return
+    }
+    http.Redirect(w, r, "/game/"+gs.ID+"/replay", http.StatusSeeOther)
+}
+
+// replayMove is one entry of the replay move list.
+type replayMove struct {
+    Ply     int    // position after this move
+    Number  string // "1." before X's move of each pair, else ""
+    Coord   string
+    Current bool
+}
+
+// replay shows the game after ?ply= moves of its history, with links to step
+// through it. It defaults to the final position.
+func (h *handlers) replay(w http.ResponseWriter, r *http.Request) {
+    id := chi.URLParam(r, "id")
+    gs, ok := h.svc.Get(id)
+    if !ok {
+        http.NotFound(w, r)
+        return
+    }
+    if gs.Game.Variant == domain.Quantum {
+        http.Error(w, "quantum games cannot be replayed", http.StatusConflict)
+        return
+    }
+    history := gs.Game.History
+    ply := len(history)
+    if v, err := strconv.Atoi(r.URL.Query().Get("ply")); err == nil && v >= 0 && v < ply {
+        ply = v
+    }
+    g := gs.Game.Start()
+    for _, m := range history[:ply] {
+        _ = g.PlayAt(m.Index, m.Mark)
+    }
+    if ply == len(history) {
+        g = gs.Game
+    }
+    at := *gs
+    at.Game = g
+    at.Aborted = gs.Aborted && g.Over
+    view := newBoardView(at, "")
+    if !view.Over {
+        view.Waiting = false
+        view.Status = g.Variant.SideName(g.Turn) + " to move"
+    }
+    for _, layer := range view.Layers {
+        for _, row := range layer.Rows {
+            for i := range row {
+                row[i].Disabled = true
+            }
+        }
+    }
+    data := struct {
+        ID        string
+        BoardHTML template.HTML
+        Ply       int
+        Total     int
+        Moves     []replayMove
+        Over      bool
+    }{ID: gs.ID, Ply: ply, Total: len(history), Over: gs.Game.Over}
+    data.BoardHTML = template.HTML(h.render(r.Context(), h.templates().board, view))
+    for i, m := range history {
+        mv := replayMove{Ply: i + 1, Coord: record.CoordIn(gs.Game.Geometry(), m.Index), Current: i+1 == ply}
+        if gs.Game.Variant.ChoosesMark() {
+            mv.Coord = cellSymbol(m.Mark) + mv.Coord
+        }
+        if i%2 == 0 {
+            mv.Number = strconv.Itoa(i/2+1) + "."
+        }
+        data.Moves = append(data.Moves, mv)
+    }
+    w.Header().Set("Content-Type", "text/html; charset=utf-8")
+    w.WriteHeader(http.StatusOK)
+    _, _ = w.Write(h.render(r.Context(), h.templates().replay, data))
+}
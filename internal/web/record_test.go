@@ -0,0 +1,149 @@
+package web
+
+import (
+    "bytes"
+    "context"
+    "mime/multipart"
+    "net/http"
+    "net/http/httptest"
+    "net/url"
+    "strings"
+    "testing"
+
+    "github.com/jaminalder/codex-tic-tac-toe/internal/domain"
+)
+
+func TestExportFinishedGame(t *testing.T) {
+    svc, h := newTestServer(t)
+    gs, _ := svc.CreateGame(context.Background())
+    svc.Join(context.Background(), gs.ID, "alice")
+    svc.Join(context.Background(), gs.ID, "bob")
+    for i, m := range [][2]int{{1, 1}, {0, 0}, {2, 2}, {2, 0}, {1, 0}, {0, 2}, {0, 1}, {2, 1}, {1, 2}} {
+        pid := "alice"
+        if i%2 == 1 {
+            pid = "bob"
+        }
+        if _, err := svc.Play(context.Background(), gs.ID, pid, m[0], m[1]); err != nil {
+            t.Fatalf("move %d: %v", i, err)
+        }
+    }
+    rr := httptest.NewRecorder()
+    h.ServeHTTP(rr, httptest.NewRequest("GET", "/game/"+gs.ID+"/export", nil))
+    if rr.Code != http.StatusOK {
+        t.Fatalf("expected 200, got %d", rr.Code)
+    }
+    if cd := rr.Header().Get("Content-Disposition"); !strings.Contains(cd, "attachment") {
+        t.Fatalf("expected attachment, got %q", cd)
+    }
+    body := rr.Body.String()
+    for _, want := range []string{`[X "X"]`, `[O "O"]`, `[Result "1-0"]`, `[TimeControl "-"]`, "1. b2 a1 2. c3 a3 3. a2 c1 4. b1 b3 5. c2 1-0"} {
+        if !strings.Contains(body, want) {
+            t.Fatalf("expected %q in record:\n%s", want, body)
+        }
+    }
+    // Player IDs are the players' credentials and must not leak.
+    if strings.Contains(body, "alice") || strings.Contains(body, "bob") {
+        t.Fatalf("record exposes player IDs:\n%s", body)
+    }
+}
+
+func TestExportAbortedGame(t *testing.T) {
+    svc, h := newTestServer(t)
+    gs, _ := svc.CreateGame(context.Background())
+    svc.Join(context.Background(), gs.ID, "alice")
+    svc.Play(context.Background(), gs.ID, "alice", 1, 1)
+    if _, err := svc.EndGame(context.Background(), gs.ID); err != nil {
+        t.Fatalf("EndGame: %v", err)
+    }
+    rr := httptest.NewRecorder()
+    h.ServeHTTP(rr, httptest.NewRequest("GET", "/game/"+gs.ID+"/export", nil))
+    body := rr.Body.String()
+    for _, want := range []string{`[Result "*"]`, `[Termination "ended by an administrator"]`, "1. b2 *"} {
+        if !strings.Contains(body, want) {
+            t.Fatalf("expected %q in record:\n%s", want, body)
+        }
+    }
+    if strings.Contains(body, "1/2-1/2") {
+        t.Fatalf("aborted game exported as a draw:\n%s", body)
+    }
+}
+
+func TestExportRequiresFinishedGame(t *testing.T) {
+    svc, h := newTestServer(t)
+    gs, _ := svc.CreateGame(context.Background())
+    rr := httptest.NewRecorder()
+    h.ServeHTTP(rr, httptest.NewRequest("GET", "/game/"+gs.ID+"/export", nil))
+    if rr.Code != http.StatusConflict {
+        t.Fatalf("expected 409 for a game in progress, got %d", rr.Code)
+    }
+    rr = httptest.NewRecorder()
+    h.ServeHTTP(rr, httptest.NewRequest("GET", "/game/missing/export", nil))
+    if rr.Code != http.StatusNotFound {
+        t.Fatalf("expected 404 for unknown game, got %d", rr.Code)
+    }
+}
+
+func TestImportRecordCreatesGameForReplay(t *testing.T) {
+    svc, h := newTestServer(t)
+    form := url.Values{"record": {"[X \"alice\"]\n\n1. b2 a1 2. c3 a3 3. a2 c1 4. c2 1-0\n"}}
+    req := httptest.NewRequest("POST", "/game/import", strings.NewReader(form.Encode()))
+    req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+    rr := httptest.NewRecorder()
+    h.ServeHTTP(rr, req)
+    if rr.Code != http.StatusSeeOther {
+        t.Fatalf("expected redirect, got %d: %s", rr.Code, rr.Body.String())
+    }
+    loc := rr.Header().Get("Location")
+    id := strings.TrimSuffix(strings.TrimPrefix(loc, "/game/"), "/replay")
+    gs, ok := svc.Get(id)
+    if !ok || gs.Game.Outcome != domain.XWon || len(gs.Game.History) != 7 {
+        t.Fatalf("unexpected imported game %+v", gs)
+    }
+
+    rr = httptest.NewRecorder()
+    h.ServeHTTP(rr, httptest.NewRequest("GET", loc+"?ply=2", nil))
+    body := rr.Body.String()
+    if rr.Code != http.StatusOK || !strings.Contains(body, "Move 2 of 7") {
+        t.Fatalf("expected replay at move 2, got %d: %s", rr.Code, body)
+    }
+    if !strings.Contains(body, "<code>O../.X./... x</code>") {
+        t.Fatalf("expected position after two moves; body=%q", body)
+    }
+}
+
+func TestImportUploadedFile(t *testing.T) {
+    svc, h := newTestServer(t)
+    var buf bytes.Buffer
+    mw := multipart.NewWriter(&buf)
+    fw, _ := mw.CreateFormFile("file", "game.ttt")
+    fw.Write([]byte("1. a1 b2 *"))
+    mw.Close()
+    req := httptest.NewRequest("POST", "/game/import", &buf)
+    req.Header.Set("Content-Type", mw.FormDataContentType())
+    rr := httptest.NewRecorder()
+    h.ServeHTTP(rr, req)
+    if rr.Code != http.StatusSeeOther {
+        t.Fatalf("expected redirect, got %d: %s", rr.Code, rr.Body.String())
+    }
+    if st := svc.Stats(); st.Games != 1 || st.ActiveGames != 1 {
+        t.Fatalf("expected one unfinished imported game, got %+v", st)
+    }
+}
+
+func TestImportInvalidRecordShowsError(t *testing.T) {
+    svc, h := newTestServer(t)
+    form := url.Values{"record": {"1. b2 b2"}}
+    req := httptest.NewRequest("POST", "/game/import", strings.NewReader(form.Encode()))
+    req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+    rr := httptest.NewRecorder()
+    h.ServeHTTP(rr, req)
+    if rr.Code != http.StatusBadRequest {
+        t.Fatalf("expected 400, got %d", rr.Code)
+    }
+    if body := rr.Body.String(); !strings.Contains(body, "invalid game record") || !strings.Contains(body, "1. b2 b2</textarea>") {
+        t.Fatalf("expected error and echoed record; body=%q", body)
+    }
+    if st := svc.Stats(); st.Games != 0 {
+        t.Fatalf("invalid record must not create a game")
+    }
+}
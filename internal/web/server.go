@@ -1,25 +1,127 @@
 package web
 
 import (
+    "fmt"
+    "log/slog"
     "net/http"
 
     "github.com/go-chi/chi/v5"
     "github.com/jaminalder/codex-tic-tac-toe/internal/app"
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/collectors"
+    "github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-// NewServer wires routes and returns an http.Handler.
+// Options configures the HTTP server.
+type Options struct {
+    // Logger receives request and error logs; nil uses slog.Default().
+    Logger *slog.Logger
+    // AdminToken protects /admin; the admin area is disabled when empty.
+    AdminToken string
+    // RateLimits throttles clients per route class; nil uses DefaultRateLimits().
+    RateLimits *RateLimits
+    // TemplateDir, when set, loads templates from this directory instead of
+    // the embedded copy and reloads them on change (development mode).
+    TemplateDir string
+    // BotTokens maps each bot account's token to its name and enables the
+    // bot API under /api/bot (see botapi.go) when not empty.
+    BotTokens map[string]string
+}
+
+// NewServer wires routes with default options and returns an http.Handler.
+// It panics if that fails, which with the embedded templates means a bug.
 func NewServer(s *app.Service) http.Handler {
+    h, err := NewServerWithOptions(s, Options{})
+    if err != nil {
+        panic(err)
+    }
+    return h
+}
+
+// NewServerWithOptions wires routes and returns an http.Handler. It fails
+// when TemplateDir is set and its templates do not parse, or when the
+// service's metrics cannot be registered.
+func NewServerWithOptions(s *app.Service, opts Options) (http.Handler, error) {
+    if opts.Logger == nil {
+        opts.Logger = slog.Default()
+    }
+    if opts.RateLimits == nil {
+        limits := DefaultRateLimits()
+        opts.RateLimits = &limits
+    }
+    for _, name := range opts.BotTokens {
+        if name == computerBot {
+            return nil, fmt.Errorf("bot name %q is reserved", name)
+        }
+    }
     r := chi.NewRouter()
-    h := &handlers{svc: s, tpl: loadTemplates()}
+    h := &handlers{svc: s, tpl: loadTemplates(), log: opts.Logger, bots: newBotIDs(opts.BotTokens)}
+    if opts.TemplateDir != "" {
+        reload, err := newTemplateReloader(opts.TemplateDir, opts.Logger)
+        if err != nil {
+            return nil, err
+        }
+        h.reload = reload
+    }
     // Ensure SSE broadcasts render the board fragment HTML
     s.SetRenderer(func(gs app.GameState) []byte { return h.renderBoard(gs, "") })
+    s.SetPresenceRenderer(h.renderPresence)
+    s.SetChatRenderer(h.renderChatMessage)
+    // Each server owns its registry so several servers can coexist (e.g. in tests).
+    reg := prometheus.NewRegistry()
+    reg.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+    if err := s.RegisterMetrics(reg); err != nil {
+        return nil, err
+    }
+    r.Use(requestLogger(opts.Logger), newHTTPMetrics(reg).middleware)
+    rl := newRateLimiter(*opts.RateLimits, reg, opts.Logger)
+    r.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+    r.Get(staticPrefix+"*", staticAssets.serve)
+    r.Get("/healthz", h.healthz)
+    r.Get("/readyz", h.readyz)
+    if opts.AdminToken != "" {
+        r.Route("/admin", func(r chi.Router) {
+            r.Use(adminAuth(opts.AdminToken))
+            r.Get("/", h.adminIndex)
+            r.Route("/games/{id}", func(r chi.Router) {
+                r.Post("/end", h.adminEnd)
+                r.Post("/delete", h.adminDelete)
+            })
+        })
+    }
+    if len(h.bots) > 0 {
+        r.Route("/api/bot", func(r chi.Router) {
+            r.Use(h.bots.auth)
+            r.Get("/stream/event", h.botEvents)
+            r.Post("/challenge/{id}/accept", h.botAccept)
+            r.Post("/challenge/{id}/decline", h.botDecline)
+            r.Get("/game/stream/{id}", h.botGameStream)
+            r.With(rl.limit("play")).Post("/game/{id}/move/{move}", h.botMove)
+        })
+    }
+    r.Route("/api/games", func(r chi.Router) {
+        r.With(rl.limit("create")).Post("/", h.apiCreate)
+        r.Route("/{id}", func(r chi.Router) {
+            r.Get("/", h.apiGet)
+            r.Post("/join", h.apiJoin)
+            r.With(rl.limit("play")).Post("/moves", h.apiMove)
+            r.With(rl.limit("events"), rl.capStreams).Get("/events", h.apiEvents)
+        })
+    })
     r.Get("/", h.index)
-    r.Post("/game", h.create)
+    r.With(rl.limit("create")).Post("/game", h.create)
+    r.With(rl.limit("create")).Post("/game/position", h.createFromPosition)
+    r.With(rl.limit("create")).Post("/game/import", h.importRecord)
     r.Route("/game/{id}", func(r chi.Router) {
         r.Get("/", h.view)
         r.Post("/join", h.join)
-        r.Post("/play", h.play)
-        r.Get("/events", h.events)
+        r.With(rl.limit("play")).Post("/play", h.play)
+        r.With(rl.limit("play")).Post("/spooky", h.spooky)
+        r.With(rl.limit("play")).Post("/collapse", h.collapse)
+        r.Post("/chat", h.chat)
+        r.Get("/export", h.export)
+        r.Get("/replay", h.replay)
+        r.With(rl.limit("events"), rl.capStreams).Get("/events", h.events)
     })
-    return r
+    return r, nil
 }
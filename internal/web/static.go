@@ -0,0 +1,92 @@
+package web
+
+import (
+    "bytes"
+    "crypto/sha256"
+    "embed"
+    "encoding/hex"
+    "io/fs"
+    "mime"
+    "net/http"
+    "path"
+    "strings"
+    "time"
+
+    "github.com/go-chi/chi/v5"
+)
+
+//go:embed static
+var staticFS embed.FS
+
+// staticPrefix is the URL path under which assets are served.
+const staticPrefix = "/static/"
+
+// asset is one embedded file addressable by its content-hashed name.
+type asset struct {
+    body        []byte
+    contentType string
+    etag        string
+}
+
+// assetSet maps logical asset names ("app.css") to hashed URLs
+// ("/static/app.1a2b3c4d5e.css") and serves the hashed names.
+type assetSet struct {
+    urls   map[string]string
+    hashed map[string]asset
+}
+
+var staticAssets = mustLoadAssets(staticFS, "static")
+
+func mustLoadAssets(fsys fs.FS, root string) *assetSet {
+    set := &assetSet{urls: make(map[string]string), hashed: make(map[string]asset)}
+    err := fs.WalkDir(fsys, root, func(p string, d fs.DirEntry, err error) error {
+        if err != nil || d.IsDir() {
+            return err
+        }
+        body, err := fs.ReadFile(fsys, p)
+        if err != nil {
+            return err
+        }
+        name := strings.TrimPrefix(p, root+"/")
+        sum := sha256.Sum256(body)
+        hash := hex.EncodeToString(sum[:])[:10]
+        ext := path.Ext(name)
+        hashedName := strings.TrimSuffix(name, ext) + "." + hash + ext
+        ct := mime.TypeByExtension(ext)
+        if ct == "" {
+            ct = http.DetectContentType(body)
+        }
+        set.urls[name] = staticPrefix + hashedName
+        set.hashed[hashedName] = asset{body: body, contentType: ct, etag: `"` + hash + `"`}
+        return nil
+    })
+    if err != nil {
+        panic(err)
+    }
+    return set
+}
+
+// url returns the hashed URL for a logical asset name. Unknown names panic
+// at render time so a typo in a template fails loudly in tests.
+func (a *assetSet) url(name string) string {
+    u, ok := a.urls[name]
+    if !ok {
+        panic("unknown static asset " + name)
+    }
+    return u
+}
+
+// serve handles GET /static/*. Hashed names never change content, so they
+// are cached for a year and marked immutable.
+func (a *assetSet) serve(w http.ResponseWriter, r *http.Request) {
+    name := chi.URLParam(r, "*")
+    as, ok := a.hashed[name]
+    if !ok {
+        http.NotFound(w, r)
+        return
+    }
+    w.Header().Set("Content-Type", as.contentType)
+    w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+    w.Header().Set("ETag", as.etag)
+    http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(as.body))
+}
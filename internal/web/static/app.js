@@ -0,0 +1,7 @@
+// Swap 429 alert fragments into #alerts instead of dropping them.
+document.addEventListener("htmx:beforeSwap", function (e) {
+  if (e.detail.xhr.status === 429) {
+    e.detail.shouldSwap = true;
+    e.detail.isError = false;
+  }
+});
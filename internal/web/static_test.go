@@ -0,0 +1,69 @@
+package web
+
+import (
+    "context"
+    "net/http"
+    "net/http/httptest"
+    "regexp"
+    "strings"
+    "testing"
+    "testing/fstest"
+)
+
+func TestAssetNamesAreContentHashed(t *testing.T) {
+    fsys := fstest.MapFS{
+        "static/app.css":       {Data: []byte("body{}")},
+        "static/vendor/lib.js": {Data: []byte("var a;")},
+    }
+    set := mustLoadAssets(fsys, "static")
+    css := set.url("app.css")
+    if !regexp.MustCompile(`^/static/app\.[0-9a-f]{10}\.css$`).MatchString(css) {
+        t.Fatalf("unexpected hashed URL %q", css)
+    }
+    if js := set.url("vendor/lib.js"); !strings.HasPrefix(js, "/static/vendor/lib.") {
+        t.Fatalf("expected nested path preserved, got %q", js)
+    }
+
+    fsys["static/app.css"] = &fstest.MapFile{Data: []byte("body{color:red}")}
+    if changed := mustLoadAssets(fsys, "static").url("app.css"); changed == css {
+        t.Fatalf("expected hash to change with content")
+    }
+}
+
+func TestStaticRouteServesEmbeddedAssets(t *testing.T) {
+    _, h := newTestServer(t)
+    for _, name := range []string{"vendor/htmx/htmx.min.js", "vendor/htmx/sse.js", "app.css", "logo.svg"} {
+        rr := httptest.NewRecorder()
+        h.ServeHTTP(rr, httptest.NewRequest("GET", staticAssets.url(name), nil))
+        if rr.Code != http.StatusOK {
+            t.Fatalf("%s: expected 200, got %d", name, rr.Code)
+        }
+        if cc := rr.Header().Get("Cache-Control"); !strings.Contains(cc, "immutable") {
+            t.Fatalf("%s: expected long-lived cache headers, got %q", name, cc)
+        }
+        if rr.Body.Len() == 0 {
+            t.Fatalf("%s: empty body", name)
+        }
+    }
+    rr := httptest.NewRecorder()
+    h.ServeHTTP(rr, httptest.NewRequest("GET", "/static/app.css", nil))
+    if rr.Code != http.StatusNotFound {
+        t.Fatalf("unhashed names should not be served, got %d", rr.Code)
+    }
+}
+
+func TestPagesReferenceSelfHostedAssets(t *testing.T) {
+    svc, h := newTestServer(t)
+    gs, _ := svc.CreateGame(context.Background())
+    rr := httptest.NewRecorder()
+    h.ServeHTTP(rr, httptest.NewRequest("GET", "/game/"+gs.ID, nil))
+    body := rr.Body.String()
+    if strings.Contains(body, "unpkg.com") {
+        t.Fatalf("page must not load assets from unpkg")
+    }
+    for _, name := range []string{"vendor/htmx/htmx.min.js", "vendor/htmx/sse.js", "app.css"} {
+        if !strings.Contains(body, staticAssets.url(name)) {
+            t.Fatalf("page should reference %s; body=%q", name, body)
+        }
+    }
+}
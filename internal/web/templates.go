@@ -2,8 +2,16 @@ package web
 
 import (
     "bytes"
+    "embed"
+    "fmt"
     "html/template"
+    "io/fs"
+    "log/slog"
     "net/http"
+    "os"
+    "strings"
+    "sync"
+    "time"
 
     "github.com/jaminalder/codex-tic-tac-toe/internal/domain"
     "github.com/google/uuid"
@@ -14,69 +22,170 @@ type templates struct {
     game   *template.Template
     board  *template.Template
     index  *template.Template
+    presence *template.Template
+    chatMessage *template.Template
+    chatForm    *template.Template
+    admin       *template.Template
+    replay      *template.Template
 }
 
 func funcs() template.FuncMap {
     return template.FuncMap{
         "iter": func(n int) []int { a := make([]int, n); for i := range a { a[i] = i }; return a },
-        "cellSymbol": func(c domain.Cell) string {
-            switch c { case domain.X: return "X"; case domain.O: return "O"; default: return "" }
-        },
+        "cellSymbol": cellSymbol,
         "eq": func(a, b any) bool { return a == b },
         "add": func(a, b int) int { return a + b },
         "mul": func(a, b int) int { return a * b },
+        "asset": staticAssets.url,
+        "mib": func(b uint64) string { return fmt.Sprintf("%.1f MiB", float64(b)/(1<<20)) },
     }
 }
 
+//go:embed templates
+var templatesFS embed.FS
+
+// embeddedTemplates is the templates directory compiled into the binary.
+func embeddedTemplates() fs.FS {
+    sub, err := fs.Sub(templatesFS, "templates")
+    if err != nil {
+        panic(err)
+    }
+    return sub
+}
+
+// loadTemplates parses the embedded templates.
 func loadTemplates() *templates {
-    // Minimal inline templates to satisfy tests; can be replaced by file loading later.
-    base := template.Must(template.New("base").Funcs(funcs()).Parse(`<!doctype html><html><head>
-<meta charset="utf-8"/>
-<script src="https://unpkg.com/htmx.org@1.9.12"></script>
-<script src="https://unpkg.com/htmx.org/dist/ext/sse.js"></script>
-</head><body>{{template "content" .}}</body></html>`))
-    // Define the board template within the same set so game can include it
-    template.Must(base.New("board").Funcs(funcs()).Parse(boardTemplate))
-    index := template.Must(template.Must(base.Clone()).New("content").Parse(`<h1>TicTacToe</h1><form action="/game" method="post"><button>Create</button></form>`))
-    game := template.Must(template.Must(base.Clone()).New("content").Parse(`
-<div hx-ext="sse" hx-sse="connect:/game/{{.Game.ID}}/events">
-  {{.BoardHTML}}
-</div>`))
-    // Standalone board template used for fragment rendering
-    board := template.Must(template.New("board_only").Funcs(funcs()).Parse(boardTemplate))
-    return &templates{base: base, game: game, board: board, index: index}
+    t, err := parseTemplates(embeddedTemplates())
+    if err != nil {
+        panic(err)
+    }
+    return t
+}
+
+// parseTemplates builds the template sets from fsys: layout/*.html and
+// partials/*.html form the shared set, and every pages/<name>.html defines the
+// "content" block of one page rendered inside the "base" layout.
+func parseTemplates(fsys fs.FS) (*templates, error) {
+    shared, err := template.New("").Funcs(funcs()).ParseFS(fsys, "layout/*.html", "partials/*.html")
+    if err != nil {
+        return nil, err
+    }
+    page := func(name string) (*template.Template, error) {
+        t, err := shared.Clone()
+        if err != nil {
+            return nil, err
+        }
+        if _, err := t.ParseFS(fsys, "pages/"+name+".html"); err != nil {
+            return nil, err
+        }
+        return t.Lookup("base"), nil
+    }
+    t := &templates{base: shared.Lookup("base")}
+    for name, dst := range map[string]**template.Template{"index": &t.index, "game": &t.game, "admin": &t.admin, "replay": &t.replay} {
+        if *dst, err = page(name); err != nil {
+            return nil, err
+        }
+    }
+    for name, dst := range map[string]**template.Template{
+        "board":        &t.board,
+        "presence":     &t.presence,
+        "chat_message": &t.chatMessage,
+        "chat_form":    &t.chatForm,
+    } {
+        if *dst = shared.Lookup(name); *dst == nil {
+            return nil, fmt.Errorf("template %q not defined in partials", name)
+        }
+    }
+    return t, nil
 }
 
-func renderTemplate(t *template.Template, name string, data any) []byte {
+// templateCheckInterval bounds how often the template directory is scanned
+// for changes; every render in between, including each SSE board push,
+// reuses the last result.
+const templateCheckInterval = time.Second
+
+// templateReloader re-parses templates from a directory whenever a file
+// changes, so markup can be edited without restarting (development mode).
+type templateReloader struct {
+    fsys     fs.FS
+    log      *slog.Logger
+    interval time.Duration
+
+    mu      sync.Mutex
+    stamp   string
+    checked time.Time
+    tpl     *templates
+}
+
+func newTemplateReloader(dir string, log *slog.Logger) (*templateReloader, error) {
+    tr := &templateReloader{fsys: os.DirFS(dir), log: log, interval: templateCheckInterval}
+    stamp, err := fingerprint(tr.fsys)
+    if err != nil {
+        return nil, err
+    }
+    if tr.tpl, err = parseTemplates(tr.fsys); err != nil {
+        return nil, err
+    }
+    tr.stamp, tr.checked = stamp, time.Now()
+    return tr, nil
+}
+
+// get returns the current templates, reloading them if any file changed
+// since the last check, at most once per interval. A broken edit is logged
+// and the last good templates keep serving.
+func (tr *templateReloader) get() *templates {
+    tr.mu.Lock()
+    defer tr.mu.Unlock()
+    if time.Since(tr.checked) < tr.interval {
+        return tr.tpl
+    }
+    tr.checked = time.Now()
+    stamp, err := fingerprint(tr.fsys)
+    if err != nil || stamp == tr.stamp {
+        return tr.tpl
+    }
+    tr.stamp = stamp
+    t, err := parseTemplates(tr.fsys)
+    if err != nil {
+        tr.log.Error("template reload failed", "err", err)
+        return tr.tpl
+    }
+    tr.log.Info("templates reloaded")
+    tr.tpl = t
+    return t
+}
+
+// fingerprint summarizes names, sizes and modification times of all files.
+func fingerprint(fsys fs.FS) (string, error) {
+    var b strings.Builder
+    err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+        if err != nil || d.IsDir() {
+            return err
+        }
+        info, err := d.Info()
+        if err != nil {
+            return err
+        }
+        fmt.Fprintf(&b, "%s:%d:%d;", p, info.Size(), info.ModTime().UnixNano())
+        return nil
+    })
+    return b.String(), err
+}
+
+func renderTemplate(t *template.Template, name string, data any) ([]byte, error) {
     var buf bytes.Buffer
+    var err error
     if name == "" {
-        _ = t.Execute(&buf, data)
+        err = t.Execute(&buf, data)
     } else {
-        _ = t.ExecuteTemplate(&buf, name, data)
+        err = t.ExecuteTemplate(&buf, name, data)
     }
-    return buf.Bytes()
+    return buf.Bytes(), err
 }
 
-const boardTemplate = `
-<div id="board" hx-sse="swap:board" hx-swap="outerHTML">
-  {{ $root := . }}
-  {{if $root.Error}}
-  <div class="alert">{{$root.Error}}</div>
-  {{end}}
-  {{/* 3x3 grid */}}
-  {{range $r := iter 3}}
-  <div class="row">
-    {{range $c := iter 3}}
-      <form hx-post="/game/{{$root.ID}}/play" hx-target="#board" hx-swap="outerHTML" method="post">
-        <input type="hidden" name="r" value="{{$r}}">
-        <input type="hidden" name="c" value="{{$c}}">
-        <button type="submit">{{cellSymbol (index $root.Game.Board (add (mul $r 3) $c))}}</button>
-      </form>
-    {{end}}
-  </div>
-  {{end}}
-</div>
-`
+func cellSymbol(c domain.Cell) string {
+    return c.String()
+}
 
 // Data models for templates
 type pageData struct {
@@ -86,7 +195,8 @@ type pageData struct {
 
 // Helper to set cookie
 func ensurePlayerCookie(w http.ResponseWriter, r *http.Request) string {
-    if c, err := r.Cookie("player_id"); err == nil && c.Value != "" {
+    // Bot player IDs are only ever granted by token (see botapi.go).
+    if c, err := r.Cookie("player_id"); err == nil && c.Value != "" && !strings.HasPrefix(c.Value, botPrefix) {
         return c.Value
     }
     // Generate UUIDv4 for player ID
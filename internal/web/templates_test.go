@@ -0,0 +1,130 @@
+package web
+
+import (
+    "bytes"
+    "io"
+    "io/fs"
+    "log/slog"
+    "net/http/httptest"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+    "time"
+)
+
+// copyTemplates writes the embedded templates into a temp directory.
+func copyTemplates(t *testing.T) string {
+    t.Helper()
+    dir := t.TempDir()
+    err := fs.WalkDir(embeddedTemplates(), ".", func(p string, d fs.DirEntry, err error) error {
+        if err != nil {
+            return err
+        }
+        if d.IsDir() {
+            return os.MkdirAll(filepath.Join(dir, p), 0o755)
+        }
+        b, err := fs.ReadFile(embeddedTemplates(), p)
+        if err != nil {
+            return err
+        }
+        return os.WriteFile(filepath.Join(dir, p), b, 0o644)
+    })
+    if err != nil {
+        t.Fatalf("copy templates: %v", err)
+    }
+    return dir
+}
+
+// touch rewrites a file and bumps its mtime so the change is always visible.
+func touch(t *testing.T, path, content string, at time.Time) {
+    t.Helper()
+    if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+        t.Fatalf("write %s: %v", path, err)
+    }
+    if err := os.Chtimes(path, at, at); err != nil {
+        t.Fatalf("chtimes %s: %v", path, err)
+    }
+}
+
+func TestPagesRenderInsideLayout(t *testing.T) {
+    tpl := loadTemplates()
+    out, err := renderTemplate(tpl.index, "", nil)
+    if err != nil {
+        t.Fatalf("render index: %v", err)
+    }
+    if !bytes.HasPrefix(out, []byte("<!doctype html>")) || !bytes.Contains(out, []byte(`action="/game"`)) {
+        t.Fatalf("expected layout wrapping page content, got %q", out)
+    }
+}
+
+func TestParseTemplatesReportsMissingPartial(t *testing.T) {
+    dir := copyTemplates(t)
+    if err := os.Remove(filepath.Join(dir, "partials", "presence.html")); err != nil {
+        t.Fatal(err)
+    }
+    if _, err := parseTemplates(os.DirFS(dir)); err == nil || !strings.Contains(err.Error(), "presence") {
+        t.Fatalf("expected missing partial error, got %v", err)
+    }
+}
+
+func TestTemplateReloaderPicksUpChanges(t *testing.T) {
+    dir := copyTemplates(t)
+    var logs bytes.Buffer
+    tr, err := newTemplateReloader(dir, slog.New(slog.NewTextHandler(&logs, nil)))
+    if err != nil {
+        t.Fatalf("newTemplateReloader: %v", err)
+    }
+    tr.interval = 0
+    index := filepath.Join(dir, "pages", "index.html")
+    future := time.Now().Add(time.Hour)
+    touch(t, index, `{{define "content"}}<p>edited by a designer</p>{{end}}`, future)
+
+    out, _ := renderTemplate(tr.get().index, "", nil)
+    if !strings.Contains(string(out), "edited by a designer") {
+        t.Fatalf("expected reloaded markup, got %q", out)
+    }
+
+    // A broken edit keeps the last good templates and logs the failure.
+    touch(t, index, `{{define "content"}}{{if}}{{end}}`, future.Add(time.Hour))
+    out, _ = renderTemplate(tr.get().index, "", nil)
+    if !strings.Contains(string(out), "edited by a designer") {
+        t.Fatalf("expected last good templates after broken edit, got %q", out)
+    }
+    if !strings.Contains(logs.String(), "template reload failed") {
+        t.Fatalf("expected reload failure logged, got %q", logs.String())
+    }
+}
+
+func TestTemplateReloaderThrottlesChecks(t *testing.T) {
+    dir := copyTemplates(t)
+    tr, err := newTemplateReloader(dir, slog.New(slog.NewTextHandler(io.Discard, nil)))
+    if err != nil {
+        t.Fatalf("newTemplateReloader: %v", err)
+    }
+    touch(t, filepath.Join(dir, "pages", "index.html"), `{{define "content"}}<p>too soon</p>{{end}}`, time.Now().Add(time.Hour))
+    out, _ := renderTemplate(tr.get().index, "", nil)
+    if strings.Contains(string(out), "too soon") {
+        t.Fatalf("expected no rescan within the check interval")
+    }
+    tr.checked = time.Now().Add(-tr.interval)
+    out, _ = renderTemplate(tr.get().index, "", nil)
+    if !strings.Contains(string(out), "too soon") {
+        t.Fatalf("expected reload once the interval passed, got %q", out)
+    }
+}
+
+func TestServerUsesTemplateDir(t *testing.T) {
+    dir := copyTemplates(t)
+    touch(t, filepath.Join(dir, "pages", "index.html"), `{{define "content"}}<p>from disk</p>{{end}}`, time.Now())
+    svc, _ := newTestServer(t)
+    h := newServerWithOptions(t, svc, Options{TemplateDir: dir})
+    rr := httptest.NewRecorder()
+    h.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+    if !strings.Contains(rr.Body.String(), "from disk") {
+        t.Fatalf("expected page rendered from TemplateDir, got %q", rr.Body.String())
+    }
+    if _, err := NewServerWithOptions(svc, Options{TemplateDir: filepath.Join(dir, "missing")}); err == nil {
+        t.Fatalf("expected error for unusable template dir")
+    }
+}